@@ -0,0 +1,222 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ContentType RFC 7807 錯誤回應的 Content-Type
+const ContentType = "application/problem+json"
+
+// Code 錯誤代碼，每個代碼對應固定的 HTTP 狀態碼，供用戶端判斷錯誤類型
+type Code string
+
+const (
+	// CodeInvalidArgument 請求參數不正確
+	CodeInvalidArgument Code = "invalid_argument"
+	// CodeUnauthenticated 未登入或憑證無效
+	CodeUnauthenticated Code = "unauthenticated"
+	// CodePermissionDenied 沒有權限
+	CodePermissionDenied Code = "permission_denied"
+	// CodeNotFound 資源不存在
+	CodeNotFound Code = "not_found"
+	// CodeNotAcceptable 無法提供請求要求的表示形式，如不支援的 API 版本
+	CodeNotAcceptable Code = "not_acceptable"
+	// CodeConflict 資源已存在或狀態衝突
+	CodeConflict Code = "conflict"
+	// CodeRateLimited 超過速率限制
+	CodeRateLimited Code = "rate_limited"
+	// CodeUnavailable 依賴的服務暫時無法使用
+	CodeUnavailable Code = "unavailable"
+	// CodeDeadlineExceeded 請求未在期限內完成
+	CodeDeadlineExceeded Code = "deadline_exceeded"
+	// CodeInternal 內部錯誤，不對外說明原因
+	CodeInternal Code = "internal"
+)
+
+// statuses 錯誤代碼對應的 HTTP 狀態碼
+var statuses = map[Code]int{
+	CodeInvalidArgument:  http.StatusBadRequest,
+	CodeUnauthenticated:  http.StatusUnauthorized,
+	CodePermissionDenied: http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeNotAcceptable:    http.StatusNotAcceptable,
+	CodeConflict:         http.StatusConflict,
+	CodeRateLimited:      http.StatusTooManyRequests,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+	CodeDeadlineExceeded: http.StatusGatewayTimeout,
+	CodeInternal:         http.StatusInternalServerError,
+}
+
+// Status 錯誤代碼對應的 HTTP 狀態碼，未知的代碼視為 500
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error 應用層錯誤，Message 與 Details 會回傳給用戶端，cause 只寫入日誌
+// 通常宣告為套件層級的 sentinel，回傳時以 Wrap、WithMessage、WithDetail 產生副本，errors.Is 仍能比對到原 sentinel
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+
+	cause  error
+	origin *Error
+}
+
+// New 創建新的應用層錯誤
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// InvalidArgument 請求參數不正確（400）
+func InvalidArgument(message string) *Error {
+	return New(CodeInvalidArgument, message)
+}
+
+// Unauthenticated 未登入或憑證無效（401）
+func Unauthenticated(message string) *Error {
+	return New(CodeUnauthenticated, message)
+}
+
+// PermissionDenied 沒有權限（403）
+func PermissionDenied(message string) *Error {
+	return New(CodePermissionDenied, message)
+}
+
+// NotFound 資源不存在（404）
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+// NotAcceptable 無法提供請求要求的表示形式（406）
+func NotAcceptable(message string) *Error {
+	return New(CodeNotAcceptable, message)
+}
+
+// Conflict 資源已存在或狀態衝突（409）
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// RateLimited 超過速率限制（429）
+func RateLimited(message string) *Error {
+	return New(CodeRateLimited, message)
+}
+
+// Unavailable 依賴的服務暫時無法使用（503）
+func Unavailable(message string) *Error {
+	return New(CodeUnavailable, message)
+}
+
+// DeadlineExceeded 請求未在期限內完成（504）
+func DeadlineExceeded(message string) *Error {
+	return New(CodeDeadlineExceeded, message)
+}
+
+// Internal 內部錯誤（500），cause 只寫入日誌
+func Internal(cause error) *Error {
+	return New(CodeInternal, "internal server error").Wrap(cause)
+}
+
+// Error 實作 error 介面，包含 cause 供日誌使用
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 回傳被包裝的原始錯誤
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 由同一個 sentinel 產生的錯誤視為相同
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.root() == e.root()
+}
+
+// Status HTTP 狀態碼
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// Cause 被包裝的原始錯誤
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// Wrap 回傳包裝 cause 的副本
+func (e *Error) Wrap(cause error) *Error {
+	clone := e.clone()
+	clone.cause = cause
+	return clone
+}
+
+// WithMessage 回傳以 message 取代對外訊息的副本
+func (e *Error) WithMessage(message string) *Error {
+	clone := e.clone()
+	clone.Message = message
+	return clone
+}
+
+// WithDetail 回傳加上一項細節的副本
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	clone := e.clone()
+	clone.Details = make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		clone.Details[k] = v
+	}
+	clone.Details[key] = value
+	return clone
+}
+
+// clone 複製錯誤並記錄來源的 sentinel
+func (e *Error) clone() *Error {
+	clone := *e
+	clone.origin = e.root()
+	return &clone
+}
+
+// root 產生此錯誤的 sentinel
+func (e *Error) root() *Error {
+	if e.origin != nil {
+		return e.origin
+	}
+	return e
+}
+
+// Converter 可轉為應用層錯誤的錯誤，讓遵循其他規範的領域錯誤（如 OAuth）也能對應正確的狀態碼
+type Converter interface {
+	AppError() *Error
+}
+
+// As 取得錯誤鏈中最外層的 *Error，其次為 Converter 轉換的結果
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	var converter Converter
+	if errors.As(err, &converter) {
+		return converter.AppError(), true
+	}
+	return nil, false
+}
+
+// From 取得錯誤鏈中的 *Error，context 期限已過時為 504，其餘包裝為內部錯誤
+func From(err error) *Error {
+	if appErr, ok := As(err); ok {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return DeadlineExceeded("request deadline exceeded").Wrap(err)
+	}
+	return Internal(err)
+}
@@ -0,0 +1,75 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = NotFound("thing not found")
+
+func TestCodeStatus(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, CodeInvalidArgument.Status())
+	assert.Equal(t, http.StatusUnauthorized, CodeUnauthenticated.Status())
+	assert.Equal(t, http.StatusNotAcceptable, CodeNotAcceptable.Status())
+	assert.Equal(t, http.StatusTooManyRequests, CodeRateLimited.Status())
+	assert.Equal(t, http.StatusInternalServerError, Code("unknown").Status())
+}
+
+func TestErrorDerivatives(t *testing.T) {
+	cause := errors.New("record not found")
+	wrapped := errTest.Wrap(cause).WithDetail("id", 1).WithMessage("thing 1 not found")
+
+	assert.ErrorIs(t, wrapped, errTest, "derived errors match the sentinel")
+	assert.ErrorIs(t, wrapped, cause)
+	assert.NotErrorIs(t, wrapped, NotFound("thing not found"), "a different sentinel does not match")
+	assert.Equal(t, "thing 1 not found: record not found", wrapped.Error())
+	assert.Equal(t, map[string]interface{}{"id": 1}, wrapped.Details)
+
+	// The sentinel itself is never modified.
+	assert.Equal(t, "thing not found", errTest.Message)
+	assert.Nil(t, errTest.Details)
+	assert.Nil(t, errTest.Cause())
+}
+
+type convertible struct{}
+
+func (convertible) Error() string { return "convertible" }
+
+func (convertible) AppError() *Error { return Conflict("converted") }
+
+func TestFrom(t *testing.T) {
+	appErr := From(fmt.Errorf("lookup: %w", errTest))
+	assert.Same(t, errTest, appErr)
+
+	appErr = From(fmt.Errorf("lookup: %w", convertible{}))
+	assert.Equal(t, CodeConflict, appErr.Code)
+
+	cause := errors.New("db down")
+	appErr = From(cause)
+	assert.Equal(t, CodeInternal, appErr.Code)
+	assert.Equal(t, "internal server error", appErr.Message, "the cause is not exposed")
+	assert.ErrorIs(t, appErr, cause)
+
+	appErr = From(fmt.Errorf("query: %w", context.DeadlineExceeded))
+	assert.Equal(t, CodeDeadlineExceeded, appErr.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, appErr.Status())
+}
+
+func TestProblem(t *testing.T) {
+	problem := errTest.WithDetail("id", 1).Problem("/things/1")
+	require.Equal(t, Problem{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "thing not found",
+		Instance: "/things/1",
+		Code:     CodeNotFound,
+		Details:  map[string]interface{}{"id": 1},
+	}, problem)
+}
@@ -0,0 +1,28 @@
+package apperror
+
+import "net/http"
+
+// Problem RFC 7807 錯誤回應，type 固定為 about:blank，title 為狀態碼的說明，code 供用戶端判斷錯誤類型
+type Problem struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     Code                   `json:"code"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// Problem 轉為 RFC 7807 錯誤回應，instance 通常為請求路徑
+func (e *Error) Problem(instance string) Problem {
+	status := e.Status()
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Details:  e.Details,
+	}
+}
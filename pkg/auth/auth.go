@@ -1,16 +1,26 @@
 package auth
 
-import "errors"
+import "github.com/POABOB/slack-clone-back-end/pkg/apperror"
 
 var (
 	// ErrInvalidToken 無效的 token
-	ErrInvalidToken = errors.New("invalid token")
+	ErrInvalidToken = apperror.Unauthenticated("invalid token")
 	// ErrInvalidID 無效的 ID
-	ErrInvalidID = errors.New("invalid id")
+	ErrInvalidID = apperror.InvalidArgument("invalid id")
 	// ErrExpiredToken 過期的 token
-	ErrExpiredToken = errors.New("token has expired")
+	ErrExpiredToken = apperror.Unauthenticated("token has expired")
+	// ErrRevokedToken 已撤銷的 token
+	ErrRevokedToken = apperror.Unauthenticated("token has been revoked")
 	// ErrUnauthorized 沒有 Authorization Header
-	ErrUnauthorized = errors.New("unauthorized")
+	ErrUnauthorized = apperror.Unauthenticated("unauthorized")
 	// ErrForbidden Forbidden
-	ErrForbidden = errors.New("forbidden")
+	ErrForbidden = apperror.PermissionDenied("forbidden")
 )
+
+// Unauthenticated 驗證失敗一律以 401 回應：已是 401 的錯誤保留原訊息，其餘以 ErrInvalidToken 包裝，原因只寫入日誌
+func Unauthenticated(err error) error {
+	if appErr, ok := apperror.As(err); ok && appErr.Code == apperror.CodeUnauthenticated {
+		return err
+	}
+	return ErrInvalidToken.Wrap(err)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync/atomic"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractBearerToken 從 Authorization Header 取出 Bearer token
+func ExtractBearerToken(header string) (string, error) {
+	if header == "" {
+		return "", ErrUnauthorized
+	}
+
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || parts[1] == "" {
+		return "", ErrInvalidToken
+	}
+	return parts[1], nil
+}
+
+// StaticTokenMiddleware 以預先配置的 token 驗證請求，適用於 SCIM 佈建等系統對系統的呼叫
+// 比對使用 constant-time 避免 timing attack，未配置任何 token 時一律拒絕
+func StaticTokenMiddleware(tokens ...string) gin.HandlerFunc {
+	return NewTokenSet(tokens...).Middleware()
+}
+
+// TokenSet 可在執行期間替換的 token 集合，用於需要隨配置輪替的系統對系統 token
+type TokenSet struct {
+	allowed atomic.Pointer[[][]byte]
+}
+
+// NewTokenSet 創建新的 token 集合，空字串會被忽略
+func NewTokenSet(tokens ...string) *TokenSet {
+	s := &TokenSet{}
+	s.Set(tokens...)
+	return s
+}
+
+// Set 以 tokens 取代目前的 token，之後的請求立即使用新的 token 驗證
+func (s *TokenSet) Set(tokens ...string) {
+	allowed := make([][]byte, 0, len(tokens))
+	for _, token := range tokens {
+		if token != "" {
+			allowed = append(allowed, []byte(token))
+		}
+	}
+	s.allowed.Store(&allowed)
+}
+
+// Middleware 以目前的 token 驗證請求，比對使用 constant-time，未配置任何 token 時一律拒絕
+func (s *TokenSet) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := ExtractBearerToken(c.GetHeader("Authorization"))
+		if err != nil {
+			middleware.AbortWithError(c, err)
+			return
+		}
+
+		for _, expected := range *s.allowed.Load() {
+			if subtle.ConstantTimeCompare([]byte(token), expected) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		middleware.AbortWithError(c, ErrInvalidToken)
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractBearerToken(t *testing.T) {
+	t.Run("Valid bearer header", func(t *testing.T) {
+		token, err := ExtractBearerToken("Bearer abc")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", token)
+	})
+
+	t.Run("Scheme is case insensitive", func(t *testing.T) {
+		token, err := ExtractBearerToken("bearer abc")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", token)
+	})
+
+	t.Run("Empty header", func(t *testing.T) {
+		_, err := ExtractBearerToken("")
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		_, err := ExtractBearerToken("Basic abc")
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+
+		_, err = ExtractBearerToken("Bearer")
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+}
+
+func TestStaticTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Accept configured token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer secret")
+
+		StaticTokenMiddleware("other", "secret")(c)
+
+		assert.False(t, c.IsAborted())
+		assert.Empty(t, c.Errors)
+	})
+
+	t.Run("Reject wrong token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer wrong")
+
+		StaticTokenMiddleware("secret")(c)
+
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.True(t, errors.Is(c.Errors.Last().Err, ErrInvalidToken))
+	})
+
+	t.Run("Reject everything when no token configured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer ")
+
+		StaticTokenMiddleware("")(c)
+
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestTokenSet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := NewTokenSet("old-token")
+	handler := tokens.Middleware()
+
+	request := func(token string) *gin.Context {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		handler(c)
+		return c
+	}
+
+	assert.False(t, request("old-token").IsAborted())
+
+	tokens.Set("new-token")
+	assert.False(t, request("new-token").IsAborted())
+	c := request("old-token")
+	assert.True(t, c.IsAborted())
+	assert.True(t, errors.Is(c.Errors.Last().Err, ErrInvalidToken))
+}
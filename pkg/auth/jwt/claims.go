@@ -2,6 +2,14 @@ package jwt
 
 import "github.com/golang-jwt/jwt/v5"
 
+// typ header，用於區分不同用途的 token，避免互相冒用
+const (
+	// HeaderTypeAccessToken 第三方應用程式 access token（RFC 9068），第一方 token 不帶此值
+	HeaderTypeAccessToken = "at+jwt"
+	// HeaderTypeServiceToken 服務間呼叫使用的 service token
+	HeaderTypeServiceToken = "svc+jwt"
+)
+
 // BaseClaims 基礎 JWT 聲明介面
 type BaseClaims interface {
 	// GetUserID 獲取用戶 ID
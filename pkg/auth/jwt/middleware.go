@@ -1,45 +1,46 @@
 package jwt
 
 import (
-	"errors"
 	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
 	"github.com/gin-gonic/gin"
-	"net/http"
 	"strings"
 )
 
 // ClaimsHandler 是一個自定義處理驗證後 claims 的函數型別
 type ClaimsHandler func(c *gin.Context, claims BaseClaims)
 
+// ClaimsValidator 簽章驗證通過後的額外檢查（如撤銷清單），回傳錯誤即拒絕請求
+type ClaimsValidator func(c *gin.Context, claims BaseClaims) error
+
 // NewJWTMiddleware 回傳一個 JWT 驗證中間件
-func NewJWTMiddleware(jwtManager TokenManager, handler ClaimsHandler) gin.HandlerFunc {
+func NewJWTMiddleware(jwtManager TokenManager, handler ClaimsHandler, validators ...ClaimsValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidToken})
-			_ = c.Error(auth.ErrInvalidToken).SetType(gin.ErrorTypePrivate)
+			middleware.AbortWithError(c, auth.ErrInvalidToken)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidToken})
-			_ = c.Error(auth.ErrInvalidToken).SetType(gin.ErrorTypePrivate)
+			middleware.AbortWithError(c, auth.ErrInvalidToken)
 			return
 		}
 
 		claims, err := jwtManager.ValidateToken(parts[1])
 		if err != nil {
-			if errors.Is(err, auth.ErrExpiredToken) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrExpiredToken})
-			} else {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidToken})
-			}
-			_ = c.Error(err).SetType(gin.ErrorTypePrivate)
-			c.Abort()
+			middleware.AbortWithError(c, auth.Unauthenticated(err))
 			return
 		}
 
+		for _, validate := range validators {
+			if err := validate(c, claims); err != nil {
+				middleware.AbortWithError(c, auth.Unauthenticated(err))
+				return
+			}
+		}
+
 		// 呼叫自定義處理函數
 		handler(c, claims)
 		c.Next()
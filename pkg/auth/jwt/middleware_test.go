@@ -268,3 +268,52 @@ func TestNewJWTMiddleware(t *testing.T) {
 		assert.True(t, errors.Is(c.Errors.Last().Err, auth.ErrExpiredToken))
 	})
 }
+
+func TestNewJWTMiddlewareValidators(t *testing.T) {
+	// 創建模擬的 TokenManager，總是回傳有效的 claims
+	mockManager := &mockTokenManager{
+		validateTokenFunc: func(token string) (BaseClaims, error) {
+			return &testClaims{UserID: uint(1), Email: "test@example.com", Username: "testuser"}, nil
+		},
+		expiresIn: 3600,
+		secretKey: []byte("test-secret"),
+	}
+
+	t.Run("Validators pass", func(t *testing.T) {
+		handlerCalled := false
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer valid-token")
+
+		middleware := NewJWTMiddleware(mockManager, func(c *gin.Context, claims BaseClaims) {
+			handlerCalled = true
+		}, func(c *gin.Context, claims BaseClaims) error {
+			return nil
+		})
+		middleware(c)
+
+		assert.True(t, handlerCalled)
+		assert.Empty(t, c.Errors)
+	})
+
+	t.Run("Validator rejects token", func(t *testing.T) {
+		handlerCalled := false
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer revoked-token")
+
+		middleware := NewJWTMiddleware(mockManager, func(c *gin.Context, claims BaseClaims) {
+			handlerCalled = true
+		}, func(c *gin.Context, claims BaseClaims) error {
+			return auth.ErrRevokedToken
+		})
+		middleware(c)
+
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Len(t, c.Errors, 1)
+		assert.True(t, errors.Is(c.Errors.Last().Err, auth.ErrRevokedToken))
+	})
+}
@@ -1,13 +1,32 @@
 package rbac
 
 import (
+	"time"
+
 	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/simple"
 )
 
+// 帳號類型，未設定時視為一般成員
+const (
+	AccountTypeMember             = "member"
+	AccountTypeSingleChannelGuest = "single_channel_guest"
+	AccountTypeMultiChannelGuest  = "multi_channel_guest"
+)
+
+// GuestScope 訪客帳號的存取限制
+type GuestScope struct {
+	// 允許存取的頻道
+	Channels []string `json:"channels"`
+	// 帳號到期時間（Unix 秒），token 的有效期限不會超過此時間
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
 // RBACClaims RBAC JWT 聲明
 type RBACClaims struct {
 	*simple.DefaultClaims
 	Role        string              `json:"role"`
+	AccountType string              `json:"account_type,omitempty"`
+	Guest       *GuestScope         `json:"guest,omitempty"`
 	// Permissions 使用 map 作為底層資料結構，key 為權限字串，value 為空結構體
 	// 使用 map 而非 slice 的原因：
 	// 1. 快速查詢：O(1) 時間複雜度檢查權限是否存在
@@ -54,3 +73,34 @@ func (c *RBACClaims) GetPermissions() []string {
 	}
 	return permissions
 }
+
+// WithGuestScope 設定訪客帳號類型與限制，expiresAt 為 nil 表示不限期
+func (c *RBACClaims) WithGuestScope(accountType string, channels []string, expiresAt *time.Time) *RBACClaims {
+	c.AccountType = accountType
+	c.Guest = &GuestScope{Channels: channels}
+	if expiresAt != nil {
+		c.Guest.ExpiresAt = expiresAt.Unix()
+	}
+	return c
+}
+
+// GetAccountType 獲取帳號類型
+func (c *RBACClaims) GetAccountType() string {
+	if c.AccountType == "" {
+		return AccountTypeMember
+	}
+	return c.AccountType
+}
+
+// IsGuest 是否為訪客帳號
+func (c *RBACClaims) IsGuest() bool {
+	return c.AccountType == AccountTypeSingleChannelGuest || c.AccountType == AccountTypeMultiChannelGuest
+}
+
+// GetGuestExpiresAt 獲取訪客帳號到期時間
+func (c *RBACClaims) GetGuestExpiresAt() (time.Time, bool) {
+	if c.Guest == nil || c.Guest.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(c.Guest.ExpiresAt, 0), true
+}
@@ -25,10 +25,21 @@ func NewRBACJWTManager(cfg *config.JWTConfig) *RBACJWTManager {
 
 // GenerateToken 生成 RBAC JWT token
 func (m *RBACJWTManager) GenerateToken(claims jwtlib.BaseClaims) (string, error) {
-	// 設置過期時間
+	// 設置過期時間，訪客 token 不得超過帳號到期時間
 	now := time.Now()
+	expiresAt := now.Add(time.Duration(m.JWTManager.GetExpiresIn()) * time.Millisecond)
+	if rbacClaims, ok := claims.(*RBACClaims); ok {
+		if guestExpiresAt, ok := rbacClaims.GetGuestExpiresAt(); ok {
+			if !guestExpiresAt.After(now) {
+				return "", auth.ErrExpiredToken
+			}
+			if guestExpiresAt.Before(expiresAt) {
+				expiresAt = guestExpiresAt
+			}
+		}
+	}
 	claims.SetRegisteredClaims(jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(m.JWTManager.GetExpiresIn()) * time.Millisecond)),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
 		NotBefore: jwt.NewNumericDate(now),
 		IssuedAt:  jwt.NewNumericDate(now),
 		ID:        simple.GenerateRandomID(), // 每次 Refresh 都可以產生不一樣的 Token，不會被 Date 所侷限
@@ -45,7 +56,7 @@ func (m *RBACJWTManager) ValidateToken(tokenString string) (jwtlib.BaseClaims, e
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return m.GetSecretKey(), nil
+		return m.VerificationKey(), nil
 	})
 
 	if err != nil {
@@ -55,6 +66,11 @@ func (m *RBACJWTManager) ValidateToken(tokenString string) (jwtlib.BaseClaims, e
 		return nil, auth.ErrInvalidToken
 	}
 
+	// 第三方應用程式的 scoped token 與 service token 不得當作第一方 token 使用
+	if typ, _ := token.Header["typ"].(string); typ == jwtlib.HeaderTypeAccessToken || typ == jwtlib.HeaderTypeServiceToken {
+		return nil, auth.ErrInvalidToken
+	}
+
 	claims, ok := token.Claims.(*RBACClaims)
 	if !ok || !token.Valid {
 		return nil, auth.ErrInvalidToken
@@ -63,12 +79,15 @@ func (m *RBACJWTManager) ValidateToken(tokenString string) (jwtlib.BaseClaims, e
 	return claims, nil
 }
 
-// RefreshToken 刷新 RBAC JWT token
+// RefreshToken 刷新 RBAC JWT token，以輪替前金鑰簽發的 token 不予換發
 func (m *RBACJWTManager) RefreshToken(tokenString string) (string, error) {
 	claims, err := m.ValidateToken(tokenString)
 	if err != nil {
 		return "", err
 	}
+	if !m.SignedWithCurrentKey(tokenString) {
+		return "", auth.ErrInvalidToken
+	}
 
 	return m.GenerateToken(claims)
 }
@@ -169,3 +169,40 @@ func TestRBACJWTManager(t *testing.T) {
 		assert.True(t, errors.Is(err, auth.ErrExpiredToken))
 	})
 }
+
+// TestRBACJWTManagerGuest tests guest scope handling in token generation
+func TestRBACJWTManagerGuest(t *testing.T) {
+	t.Run("Guest token expiry is capped by account expiry", func(t *testing.T) {
+		jwtManager := setupTestRBACJWTManager()
+		accountExpiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		claims := getDefaultRBACClaims().WithGuestScope(AccountTypeSingleChannelGuest, []string{"general"}, &accountExpiresAt)
+
+		token, err := jwtManager.GenerateToken(claims)
+		require.NoError(t, err)
+
+		validatedClaims, err := jwtManager.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, accountExpiresAt.Unix(), validatedClaims.GetRegisteredClaims().ExpiresAt.Unix())
+
+		rbacClaims, ok := validatedClaims.(*RBACClaims)
+		require.True(t, ok)
+		assert.True(t, rbacClaims.IsGuest())
+		assert.Equal(t, AccountTypeSingleChannelGuest, rbacClaims.GetAccountType())
+		assert.Equal(t, []string{"general"}, rbacClaims.Guest.Channels)
+	})
+
+	t.Run("Expired guest cannot get a token", func(t *testing.T) {
+		jwtManager := setupTestRBACJWTManager()
+		accountExpiresAt := time.Now().Add(-time.Minute)
+		claims := getDefaultRBACClaims().WithGuestScope(AccountTypeMultiChannelGuest, []string{"a", "b"}, &accountExpiresAt)
+
+		_, err := jwtManager.GenerateToken(claims)
+		assert.True(t, errors.Is(err, auth.ErrExpiredToken))
+	})
+
+	t.Run("Member claims default account type", func(t *testing.T) {
+		claims := getDefaultRBACClaims()
+		assert.False(t, claims.IsGuest())
+		assert.Equal(t, AccountTypeMember, claims.GetAccountType())
+	})
+}
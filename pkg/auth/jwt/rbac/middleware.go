@@ -3,15 +3,13 @@ package rbac
 import (
 	"github.com/POABOB/slack-clone-back-end/pkg/auth"
 	jwtlib "github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
-	"net/http"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-// TODO 重購 回傳 ERROR 與系統紀錄 ERROR
-
-// RBACMiddleware RBAC 中間件
-func RBACMiddleware(jwtManager *RBACJWTManager) gin.HandlerFunc {
+// RBACMiddleware RBAC 中間件，validators 會在簽章驗證後依序執行
+func RBACMiddleware(jwtManager *RBACJWTManager, validators ...jwtlib.ClaimsValidator) gin.HandlerFunc {
 	return jwtlib.NewJWTMiddleware(jwtManager, func(c *gin.Context, claims jwtlib.BaseClaims) {
 		c.Set("user_id", claims.GetUserID())
 		c.Set("email", claims.GetEmail())
@@ -20,8 +18,59 @@ func RBACMiddleware(jwtManager *RBACJWTManager) gin.HandlerFunc {
 		if rbacClaims, ok := claims.(*RBACClaims); ok {
 			c.Set("role", rbacClaims.GetRole())
 			c.Set("permissions", rbacClaims.GetPermissions())
+			c.Set("account_type", rbacClaims.GetAccountType())
+			if rbacClaims.Guest != nil {
+				c.Set("guest_channels", rbacClaims.Guest.Channels)
+			}
+			if expiresAt, ok := rbacClaims.GetGuestExpiresAt(); ok {
+				c.Set("account_expires_at", expiresAt)
+			}
+		}
+	}, validators...)
+}
+
+// RequireCurrentKey 拒絕以輪替前金鑰簽發的 token，用於換發 token 的端點，需放在 RBACMiddleware 之後
+func RequireCurrentKey(jwtManager *RBACJWTManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, err := auth.ExtractBearerToken(c.GetHeader("Authorization"))
+		if err != nil || !jwtManager.SignedWithCurrentKey(tokenString) {
+			middleware.AbortWithError(c, auth.ErrInvalidToken)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireMember 拒絕訪客帳號，用於工作區層級的操作
+func RequireMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accountType := c.GetString("account_type")
+		if accountType == AccountTypeSingleChannelGuest || accountType == AccountTypeMultiChannelGuest {
+			middleware.AbortWithError(c, auth.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireChannelAccess 檢查訪客是否能存取路由參數中的頻道，一般成員不受限制
+func RequireChannelAccess(channelParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accountType := c.GetString("account_type")
+		if accountType != AccountTypeSingleChannelGuest && accountType != AccountTypeMultiChannelGuest {
+			c.Next()
+			return
 		}
-	})
+
+		channel := c.Param(channelParam)
+		for _, allowed := range c.GetStringSlice("guest_channels") {
+			if allowed == channel {
+				c.Next()
+				return
+			}
+		}
+		middleware.AbortWithError(c, auth.ErrForbidden)
+	}
 }
 
 // RequireRole 檢查用戶是否具有特定角色
@@ -33,9 +82,7 @@ func RequireRole(role string) gin.HandlerFunc {
 		}
 
 		if userRole != role {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": auth.ErrForbidden})
-			_ = c.Error(auth.ErrForbidden).SetType(gin.ErrorTypePrivate)
-			c.Abort()
+			middleware.AbortWithError(c, auth.ErrForbidden)
 			return
 		}
 		c.Next()
@@ -51,9 +98,7 @@ func RequirePermission(permission string) gin.HandlerFunc {
 		}
 
 		if _, ok := perms[permission]; !ok {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": auth.ErrForbidden})
-			_ = c.Error(auth.ErrForbidden).SetType(gin.ErrorTypePrivate)
-			c.Abort()
+			middleware.AbortWithError(c, auth.ErrForbidden)
 			return
 		}
 		c.Next()
@@ -74,9 +119,7 @@ func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
 				return
 			}
 		}
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": auth.ErrForbidden})
-		_ = c.Error(auth.ErrForbidden).SetType(gin.ErrorTypePrivate)
-		c.Abort()
+		middleware.AbortWithError(c, auth.ErrForbidden)
 	}
 }
 
@@ -95,9 +138,7 @@ func RequireAllPermissions(permissions ...string) gin.HandlerFunc {
 			}
 		}
 		if len(missingPerms) > 0 {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": auth.ErrForbidden})
-			_ = c.Error(auth.ErrForbidden).SetType(gin.ErrorTypePrivate)
-			c.Abort()
+			middleware.AbortWithError(c, auth.ErrForbidden)
 			return
 		}
 		c.Next()
@@ -108,15 +149,13 @@ func RequireAllPermissions(permissions ...string) gin.HandlerFunc {
 func extractPermissions(c *gin.Context) (map[string]struct{}, bool) {
 	raw, exists := c.Get("permissions")
 	if !exists {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrUnauthorized})
-		_ = c.Error(auth.ErrUnauthorized).SetType(gin.ErrorTypePrivate)
+		middleware.AbortWithError(c, auth.ErrUnauthorized)
 		return nil, false
 	}
 
 	perms, ok := raw.([]string)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidToken})
-		_ = c.Error(auth.ErrInvalidToken).SetType(gin.ErrorTypePrivate)
+		middleware.AbortWithError(c, auth.ErrInvalidToken)
 		return nil, false
 	}
 
@@ -131,16 +170,13 @@ func extractPermissions(c *gin.Context) (map[string]struct{}, bool) {
 func extractRole(c *gin.Context) (string, bool) {
 	raw, exists := c.Get("role")
 	if !exists {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrUnauthorized})
-		_ = c.Error(auth.ErrUnauthorized).SetType(gin.ErrorTypePrivate)
-		c.Abort()
+		middleware.AbortWithError(c, auth.ErrUnauthorized)
 		return "", false
 	}
 
 	role, ok := raw.(string)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidToken})
-		_ = c.Error(auth.ErrInvalidToken).SetType(gin.ErrorTypePrivate)
+		middleware.AbortWithError(c, auth.ErrInvalidToken)
 		return "", false
 	}
 	return role, true
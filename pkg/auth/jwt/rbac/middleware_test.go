@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,6 +93,40 @@ func TestRBACMiddleware(t *testing.T) {
 	})
 }
 
+func TestRequireCurrentKey(t *testing.T) {
+	jwtManager := NewRBACJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: 60000, KeyOverlap: 60000})
+	oldToken, err := jwtManager.GenerateToken(getDefaultRBACClaims())
+	require.NoError(t, err)
+	jwtManager.SetSecretKey("rotated-secret-key")
+	newToken, err := jwtManager.GenerateToken(getDefaultRBACClaims())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "Token signed with the current key", token: newToken, status: http.StatusOK},
+		{name: "Token signed with the previous key", token: oldToken, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.GET("/refresh", RBACMiddleware(jwtManager), RequireCurrentKey(jwtManager), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/refresh", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
 func TestRequireRole(t *testing.T) {
 	// Setup
 	jwtManager := setupTestRBACJWTManager()
@@ -389,3 +426,48 @@ func TestRequireRoleErrorCases(t *testing.T) {
 		assert.True(t, errors.Is(c.Errors.Last().Err, auth.ErrInvalidToken))
 	})
 }
+
+func TestGuestRestrictions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := setupTestRBACJWTManager()
+	expiresAt := time.Now().Add(time.Hour)
+	guestClaims := getDefaultRBACClaims().WithGuestScope(AccountTypeSingleChannelGuest, []string{"general"}, &expiresAt)
+	guestToken, err := jwtManager.GenerateToken(guestClaims)
+	require.NoError(t, err)
+	memberToken, err := jwtManager.GenerateToken(getDefaultRBACClaims())
+	require.NoError(t, err)
+
+	newRouter := func() *gin.Engine {
+		router := gin.New()
+		router.GET("/workspace", RBACMiddleware(jwtManager), RequireMember(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		router.GET("/channels/:channel_id", RBACMiddleware(jwtManager), RequireChannelAccess("channel_id"), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		status int
+	}{
+		{"Member can access workspace", memberToken, "/workspace", http.StatusOK},
+		{"Guest cannot access workspace", guestToken, "/workspace", http.StatusForbidden},
+		{"Member can access any channel", memberToken, "/channels/random", http.StatusOK},
+		{"Guest can access allowed channel", guestToken, "/channels/general", http.StatusOK},
+		{"Guest cannot access other channel", guestToken, "/channels/random", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			newRouter().ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
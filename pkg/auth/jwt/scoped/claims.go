@@ -0,0 +1,50 @@
+package scoped
+
+import (
+	"strings"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/simple"
+)
+
+// ScopedClaims 第三方應用程式 access token 聲明，權限僅限於使用者同意的 scope
+// 與第一方的 RBACClaims 不同，不帶角色與權限；client credentials 取得的 bot token 不代表任何使用者，UserID 為 0
+type ScopedClaims struct {
+	*simple.DefaultClaims
+	ClientID string `json:"client_id"`
+	// Scope 以空白分隔的 scope 列表（RFC 8693 4.2）
+	Scope string `json:"scope"`
+}
+
+// NewScopedClaims 創建新的 scoped 聲明
+func NewScopedClaims(clientID string, userID uint, email, username string, scopes []string) *ScopedClaims {
+	return &ScopedClaims{
+		DefaultClaims: simple.NewDefaultClaims(userID, email, username),
+		ClientID:      clientID,
+		Scope:         strings.Join(scopes, " "),
+	}
+}
+
+// GetClientID 獲取應用程式 client_id
+func (c *ScopedClaims) GetClientID() string {
+	return c.ClientID
+}
+
+// GetScopes 獲取授權的 scope 列表
+func (c *ScopedClaims) GetScopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope 是否具有特定 scope
+func (c *ScopedClaims) HasScope(scope string) bool {
+	for _, s := range c.GetScopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBot 是否為不代表使用者的 bot token
+func (c *ScopedClaims) IsBot() bool {
+	return c.UserID == 0
+}
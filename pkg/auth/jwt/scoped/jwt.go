@@ -0,0 +1,78 @@
+package scoped
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	jwtlib "github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/simple"
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ScopedJWTManager 第三方應用程式 JWT 管理器，簽發的 token 帶有 typ=at+jwt，與第一方 token 互不通用
+type ScopedJWTManager struct {
+	*simple.JWTManager
+}
+
+// NewScopedJWTManager 創建新的 scoped JWT 管理器
+func NewScopedJWTManager(cfg *config.JWTConfig) *ScopedJWTManager {
+	return &ScopedJWTManager{
+		JWTManager: simple.NewJWTManager(cfg),
+	}
+}
+
+// GenerateToken 生成 scoped JWT token
+func (m *ScopedJWTManager) GenerateToken(claims jwtlib.BaseClaims) (string, error) {
+	scopedClaims, ok := claims.(*ScopedClaims)
+	if !ok {
+		return "", auth.ErrInvalidToken
+	}
+
+	now := time.Now()
+	claims.SetRegisteredClaims(jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(m.JWTManager.GetExpiresIn()) * time.Millisecond)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        simple.GenerateRandomID(),
+	})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, scopedClaims)
+	token.Header["typ"] = jwtlib.HeaderTypeAccessToken
+	return token.SignedString(m.JWTManager.GetSecretKey())
+}
+
+// ValidateToken 驗證 scoped JWT token，拒絕第一方 token
+func (m *ScopedJWTManager) ValidateToken(tokenString string) (jwtlib.BaseClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ScopedClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return m.VerificationKey(), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, auth.ErrExpiredToken
+		}
+		return nil, auth.ErrInvalidToken
+	}
+
+	if typ, _ := token.Header["typ"].(string); typ != jwtlib.HeaderTypeAccessToken {
+		return nil, auth.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ScopedClaims)
+	if !ok || !token.Valid || claims.ClientID == "" {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// RefreshToken 第三方應用程式需透過 token 端點換發，不支援直接刷新
+func (m *ScopedJWTManager) RefreshToken(string) (string, error) {
+	return "", auth.ErrInvalidToken
+}
@@ -0,0 +1,81 @@
+package scoped
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ExpiresIn = 60 * 60 * 1000
+	SecretKey = "test-secret-key"
+)
+
+// setupTestScopedJWTManager initializes a ScopedJWTManager instance with test-specific configurations.
+func setupTestScopedJWTManager() *ScopedJWTManager {
+	return NewScopedJWTManager(&config.JWTConfig{
+		SecretKey: SecretKey,
+		ExpiresIn: ExpiresIn,
+	})
+}
+
+// TestScopedJWTManager tests all scoped JWT manager functionality
+func TestScopedJWTManager(t *testing.T) {
+	t.Run("Generate and Validate Token", func(t *testing.T) {
+		jwtManager := setupTestScopedJWTManager()
+		claims := NewScopedClaims("app-1", 1, "test@example.com", "testUser", []string{"users:read", "chat:write"})
+
+		token, err := jwtManager.GenerateToken(claims)
+		require.NoError(t, err)
+
+		validatedClaims, err := jwtManager.ValidateToken(token)
+		require.NoError(t, err)
+
+		scopedClaims, ok := validatedClaims.(*ScopedClaims)
+		require.True(t, ok)
+		assert.Equal(t, "app-1", scopedClaims.GetClientID())
+		assert.Equal(t, uint(1), scopedClaims.GetUserID())
+		assert.Equal(t, []string{"users:read", "chat:write"}, scopedClaims.GetScopes())
+		assert.True(t, scopedClaims.HasScope("chat:write"))
+		assert.False(t, scopedClaims.HasScope("channels:read"))
+		assert.False(t, scopedClaims.IsBot())
+	})
+
+	t.Run("Bot token has no user", func(t *testing.T) {
+		jwtManager := setupTestScopedJWTManager()
+		token, err := jwtManager.GenerateToken(NewScopedClaims("bot-1", 0, "", "", []string{"chat:write"}))
+		require.NoError(t, err)
+
+		validatedClaims, err := jwtManager.ValidateToken(token)
+		require.NoError(t, err)
+		assert.True(t, validatedClaims.(*ScopedClaims).IsBot())
+	})
+
+	t.Run("First-party token is rejected", func(t *testing.T) {
+		rbacManager := rbac.NewRBACJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: ExpiresIn})
+		token, err := rbacManager.GenerateToken(rbac.NewRBACClaims(1, "test@example.com", "testUser", "admin", nil))
+		require.NoError(t, err)
+
+		_, err = setupTestScopedJWTManager().ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Scoped token is rejected by first-party manager", func(t *testing.T) {
+		token, err := setupTestScopedJWTManager().GenerateToken(NewScopedClaims("app-1", 1, "", "", []string{"users:read"}))
+		require.NoError(t, err)
+
+		rbacManager := rbac.NewRBACJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: ExpiresIn})
+		_, err = rbacManager.ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Refresh is not supported", func(t *testing.T) {
+		_, err := setupTestScopedJWTManager().RefreshToken("token")
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+}
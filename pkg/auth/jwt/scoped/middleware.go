@@ -0,0 +1,56 @@
+package scoped
+
+import (
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	jwtlib "github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// ScopedMiddleware 第三方應用程式 token 驗證中間件，validators 會在簽章驗證後依序執行
+func ScopedMiddleware(jwtManager *ScopedJWTManager, validators ...jwtlib.ClaimsValidator) gin.HandlerFunc {
+	return jwtlib.NewJWTMiddleware(jwtManager, func(c *gin.Context, claims jwtlib.BaseClaims) {
+		scopedClaims := claims.(*ScopedClaims)
+		c.Set("client_id", scopedClaims.GetClientID())
+		c.Set("scopes", scopedClaims.GetScopes())
+		if !scopedClaims.IsBot() {
+			c.Set("user_id", claims.GetUserID())
+			c.Set("email", claims.GetEmail())
+			c.Set("username", claims.GetUsername())
+		}
+	}, validators...)
+}
+
+// RequireScope 檢查 token 是否具有所有指定的 scope
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, exists := c.Get("scopes")
+		if !exists {
+			middleware.AbortWithError(c, auth.ErrUnauthorized)
+			return
+		}
+
+		granted := make(map[string]struct{})
+		for _, scope := range raw.([]string) {
+			granted[scope] = struct{}{}
+		}
+		for _, required := range scopes {
+			if _, ok := granted[required]; !ok {
+				middleware.AbortWithError(c, auth.ErrForbidden)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireUser 拒絕不代表使用者的 bot token
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("user_id"); !exists {
+			middleware.AbortWithError(c, auth.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
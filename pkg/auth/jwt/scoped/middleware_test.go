@@ -0,0 +1,81 @@
+package scoped
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// performRequest sends a request with the given token through the scoped middleware chain.
+func performRequest(t *testing.T, claims *ScopedClaims, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	jwtManager := setupTestScopedJWTManager()
+	token, err := jwtManager.GenerateToken(claims)
+	require.NoError(t, err)
+
+	router := gin.New()
+	chain := append([]gin.HandlerFunc{ScopedMiddleware(jwtManager)}, handlers...)
+	chain = append(chain, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/", chain...)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestScopedMiddleware(t *testing.T) {
+	t.Run("Successfully set client info", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		jwtManager := setupTestScopedJWTManager()
+		token, err := jwtManager.GenerateToken(NewScopedClaims("app-1", 1, "test@example.com", "testUser", []string{"users:read"}))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		ScopedMiddleware(jwtManager)(c)
+
+		assert.Equal(t, "app-1", c.GetString("client_id"))
+		assert.Equal(t, []string{"users:read"}, c.GetStringSlice("scopes"))
+		assert.Equal(t, uint(1), c.GetUint("user_id"))
+	})
+
+	t.Run("Bot token does not set user", func(t *testing.T) {
+		w := performRequest(t, NewScopedClaims("bot-1", 0, "", "", []string{"chat:write"}), RequireUser())
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	claims := func() *ScopedClaims {
+		return NewScopedClaims("app-1", 1, "", "", []string{"users:read", "chat:write"})
+	}
+
+	t.Run("Has all scopes", func(t *testing.T) {
+		w := performRequest(t, claims(), RequireScope("users:read", "chat:write"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Missing scope", func(t *testing.T) {
+		w := performRequest(t, claims(), RequireScope("channels:history"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("No scopes in context", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		RequireScope("users:read")(c)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -9,13 +9,19 @@ import (
 	jwtlib "github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
 	"github.com/POABOB/slack-clone-back-end/pkg/config"
 	"github.com/golang-jwt/jwt/v5"
+	"sync"
 	"time"
 )
 
-// JWTManager JWT 管理器
+// JWTManager JWT 管理器，金鑰可在執行期間輪替
 type JWTManager struct {
+	mu        sync.RWMutex
 	secretKey []byte
-	expiresIn int
+	// 輪替前的金鑰，在 previousUntil 前仍可驗證輪替前簽發的 token
+	previousKey   []byte
+	previousUntil time.Time
+	keyOverlap    time.Duration
+	expiresIn     int
 }
 
 // NewJWTManager 創建新的 JWT 管理器
@@ -24,8 +30,9 @@ func NewJWTManager(cfg *config.JWTConfig) *JWTManager {
 		cfg.ExpiresIn = 1 * 24 * 60 * 60 * 1000
 	}
 	return &JWTManager{
-		secretKey: []byte(cfg.SecretKey),
-		expiresIn: cfg.ExpiresIn,
+		secretKey:  []byte(cfg.SecretKey),
+		keyOverlap: time.Duration(cfg.KeyOverlap) * time.Millisecond,
+		expiresIn:  cfg.ExpiresIn,
 	}
 }
 
@@ -51,7 +58,7 @@ func (m *JWTManager) ValidateToken(tokenString string) (jwtlib.BaseClaims, error
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return m.GetSecretKey(), nil
+		return m.VerificationKey(), nil
 	})
 
 	if err != nil {
@@ -69,12 +76,15 @@ func (m *JWTManager) ValidateToken(tokenString string) (jwtlib.BaseClaims, error
 	return claims, nil
 }
 
-// RefreshToken 刷新 JWT token
+// RefreshToken 刷新 JWT token，以輪替前金鑰簽發的 token 不予換發
 func (m *JWTManager) RefreshToken(tokenString string) (string, error) {
 	claims, err := m.ValidateToken(tokenString)
 	if err != nil {
 		return "", err
 	}
+	if !m.SignedWithCurrentKey(tokenString) {
+		return "", auth.ErrInvalidToken
+	}
 
 	return m.GenerateToken(claims)
 }
@@ -86,9 +96,42 @@ func (m *JWTManager) GetExpiresIn() int {
 
 // GetSecretKey 獲取私鑰
 func (m *JWTManager) GetSecretKey() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.secretKey
 }
 
+// SetSecretKey 輪替私鑰，之後簽發的 token 使用新金鑰
+// 輪替前簽發的 token 在 keyOverlap 內仍可驗證，避免所有使用者同時被登出；keyOverlap 為 0 時立即失效
+func (m *JWTManager) SetSecretKey(secretKey string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.previousKey = nil
+	if m.keyOverlap > 0 {
+		m.previousKey = m.secretKey
+		m.previousUntil = time.Now().Add(m.keyOverlap)
+	}
+	m.secretKey = []byte(secretKey)
+}
+
+// SignedWithCurrentKey token 的簽章是否由目前的金鑰產生，不檢查 claims，需先通過 ValidateToken
+func (m *JWTManager) SignedWithCurrentKey(tokenString string) bool {
+	_, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+		return m.GetSecretKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithoutClaimsValidation())
+	return err == nil
+}
+
+// VerificationKey 獲取驗證簽章使用的金鑰，輪替期間同時包含新舊金鑰
+func (m *JWTManager) VerificationKey() interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.previousKey == nil || time.Now().After(m.previousUntil) {
+		return m.secretKey
+	}
+	return jwt.VerificationKeySet{Keys: []jwt.VerificationKey{m.secretKey, m.previousKey}}
+}
+
 // GenerateRandomID 生成一個隨機的 ID
 func GenerateRandomID() string {
 	b := make([]byte, 8) // 使用 8 字節，生成 11 字符的 base64 字符串
@@ -206,4 +206,56 @@ func TestJWTManager(t *testing.T) {
 		manager = NewJWTManager(cfg)
 		assert.Equal(t, customExpiresIn, manager.GetExpiresIn())
 	})
+	t.Run("Rotate Secret Key", func(t *testing.T) {
+		jwtManager := NewJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: ExpiresIn, KeyOverlap: ExpiresIn})
+		oldToken, err := jwtManager.GenerateToken(getDefaultClaims())
+		require.NoError(t, err)
+
+		jwtManager.SetSecretKey("rotated-secret-key")
+		assert.Equal(t, []byte("rotated-secret-key"), jwtManager.GetSecretKey())
+
+		// 新 token 以新金鑰簽發，舊 token 在有效期內仍可驗證
+		newToken, err := jwtManager.GenerateToken(getDefaultClaims())
+		require.NoError(t, err)
+		_, err = jwtManager.ValidateToken(newToken)
+		require.NoError(t, err)
+		_, err = jwtManager.ValidateToken(oldToken)
+		require.NoError(t, err)
+
+		// 舊金鑰簽發的 token 只能驗證，不能換發
+		_, err = jwtManager.RefreshToken(oldToken)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+		_, err = jwtManager.RefreshToken(newToken)
+		require.NoError(t, err)
+
+		_, err = setupTestJWTManager().ValidateToken(newToken)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+
+		// 再次輪替後最初的金鑰失效
+		jwtManager.SetSecretKey("another-secret-key")
+		_, err = jwtManager.ValidateToken(oldToken)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+		_, err = jwtManager.ValidateToken(newToken)
+		require.NoError(t, err)
+	})
+
+	t.Run("No Key Overlap", func(t *testing.T) {
+		jwtManager := setupTestJWTManager()
+		oldToken, err := jwtManager.GenerateToken(getDefaultClaims())
+		require.NoError(t, err)
+
+		jwtManager.SetSecretKey("rotated-secret-key")
+		_, err = jwtManager.ValidateToken(oldToken)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Previous Key Expires", func(t *testing.T) {
+		jwtManager := NewJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: ExpiresIn, KeyOverlap: 1})
+		oldKey := jwtManager.GetSecretKey()
+		jwtManager.SetSecretKey("rotated-secret-key")
+		time.Sleep(10 * time.Millisecond)
+
+		assert.Equal(t, []byte("rotated-secret-key"), jwtManager.VerificationKey())
+		assert.NotEqual(t, oldKey, jwtManager.VerificationKey())
+	})
 }
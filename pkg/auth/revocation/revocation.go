@@ -0,0 +1,122 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	jwtlib "github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// Store 以「使用者 + 撤銷時間點」記錄撤銷，在該時間點之前簽發的 token 一律失效
+// 相較於逐一記錄 jti，不需要知道使用者持有哪些 token
+type Store interface {
+	// RevokeUser 撤銷使用者在 at 之前簽發的所有 token，ttl 應不小於 token 的最長有效期
+	RevokeUser(ctx context.Context, userID uint, at time.Time, ttl time.Duration) error
+	// RevokedAt 獲取使用者的撤銷時間點
+	RevokedAt(ctx context.Context, userID uint) (time.Time, bool, error)
+}
+
+// RedisStore 以 Redis 儲存撤銷紀錄，多個實例之間共享
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore 創建新的 Redis 撤銷紀錄
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// RevokeUser 撤銷使用者的 token
+func (s *RedisStore) RevokeUser(ctx context.Context, userID uint, at time.Time, ttl time.Duration) error {
+	return s.client.Set(ctx, key(userID), at.UnixNano(), ttl).Err()
+}
+
+// RevokedAt 獲取使用者的撤銷時間點
+func (s *RedisStore) RevokedAt(ctx context.Context, userID uint) (time.Time, bool, error) {
+	value, err := s.client.Get(ctx, key(userID)).Result()
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid revocation record: %v", err)
+	}
+	return time.Unix(0, nanos), true, nil
+}
+
+// MemoryStore 以記憶體儲存撤銷紀錄，適用於單一實例或測試
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[uint]memoryRecord
+}
+
+type memoryRecord struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// NewMemoryStore 創建新的記憶體撤銷紀錄
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[uint]memoryRecord)}
+}
+
+// RevokeUser 撤銷使用者的 token
+func (s *MemoryStore) RevokeUser(_ context.Context, userID uint, at time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[userID] = memoryRecord{revokedAt: at, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// RevokedAt 獲取使用者的撤銷時間點
+func (s *MemoryStore) RevokedAt(_ context.Context, userID uint) (time.Time, bool, error) {
+	s.mu.RLock()
+	record, ok := s.records[userID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	if time.Now().After(record.expiresAt) {
+		s.mu.Lock()
+		delete(s.records, userID)
+		s.mu.Unlock()
+		return time.Time{}, false, nil
+	}
+	return record.revokedAt, true, nil
+}
+
+// Validator 回傳 JWT 中間件使用的撤銷檢查
+// JWT 的 iat 只精確到秒，因此與撤銷時間同一秒簽發的 token 也視為已撤銷
+func Validator(store Store) jwtlib.ClaimsValidator {
+	return func(c *gin.Context, claims jwtlib.BaseClaims) error {
+		revokedAt, ok, err := store.RevokedAt(c.Request.Context(), claims.GetUserID())
+		if err != nil {
+			return fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		issuedAt := claims.GetRegisteredClaims().IssuedAt
+		if issuedAt == nil || !issuedAt.Time.After(revokedAt.Truncate(time.Second)) {
+			return auth.ErrRevokedToken
+		}
+		return nil
+	}
+}
+
+// key 撤銷紀錄的 Redis key
+func key(userID uint) string {
+	return fmt.Sprintf("auth:revoked:user:%d", userID)
+}
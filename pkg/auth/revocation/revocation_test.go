@@ -0,0 +1,108 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRedisStore 使用 miniredis 建立測試用的 RedisStore
+func setupRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisStore(client), mr
+}
+
+func TestStores(t *testing.T) {
+	redisStore, _ := setupRedisStore(t)
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"redis":  redisStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, ok, err := store.RevokedAt(ctx, 1)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			at := time.Now()
+			require.NoError(t, store.RevokeUser(ctx, 1, at, time.Hour))
+
+			revokedAt, ok, err := store.RevokedAt(ctx, 1)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, at.UnixNano(), revokedAt.UnixNano())
+
+			_, ok, err = store.RevokedAt(ctx, 2)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestRedisStoreExpiry(t *testing.T) {
+	store, mr := setupRedisStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.RevokeUser(ctx, 1, time.Now(), time.Minute))
+	mr.FastForward(2 * time.Minute)
+
+	_, ok, err := store.RevokedAt(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryStore()
+	validate := Validator(store)
+
+	newClaims := func(userID uint, issuedAt time.Time) *rbac.RBACClaims {
+		claims := rbac.NewRBACClaims(userID, "test@example.com", "testUser", "user", nil)
+		claims.SetRegisteredClaims(jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issuedAt)})
+		return claims
+	}
+	newContext := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		return c
+	}
+
+	revokedAt := time.Now()
+	require.NoError(t, store.RevokeUser(context.Background(), 1, revokedAt, time.Hour))
+
+	t.Run("Token issued before revocation is rejected", func(t *testing.T) {
+		err := validate(newContext(), newClaims(1, revokedAt.Add(-time.Minute)))
+		assert.True(t, errors.Is(err, auth.ErrRevokedToken))
+	})
+
+	t.Run("Token issued in the same second is rejected", func(t *testing.T) {
+		err := validate(newContext(), newClaims(1, revokedAt))
+		assert.True(t, errors.Is(err, auth.ErrRevokedToken))
+	})
+
+	t.Run("Token issued after revocation is accepted", func(t *testing.T) {
+		err := validate(newContext(), newClaims(1, revokedAt.Add(2*time.Second)))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Other users are not affected", func(t *testing.T) {
+		err := validate(newContext(), newClaims(2, revokedAt.Add(-time.Minute)))
+		assert.NoError(t, err)
+	})
+}
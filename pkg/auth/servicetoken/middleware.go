@@ -0,0 +1,75 @@
+package servicetoken
+
+import (
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// 呼叫方身分類型，存放於 context 的 principal_type
+const (
+	PrincipalTypeUser    = "user"
+	PrincipalTypeService = "service"
+)
+
+// Middleware 僅接受 service token 的中間件，用於內部 API
+func Middleware(manager *Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := auth.ExtractBearerToken(c.GetHeader("Authorization"))
+		if err != nil {
+			middleware.AbortWithError(c, err)
+			return
+		}
+		authenticate(c, manager, token)
+	}
+}
+
+// PrincipalMiddleware 同時接受使用者與服務的中間件，依 token 類型分派
+// service token 由 manager 驗證，其餘交給 userMiddleware（如 RBACMiddleware）
+func PrincipalMiddleware(manager *Manager, userMiddleware gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := auth.ExtractBearerToken(c.GetHeader("Authorization"))
+		if err == nil && IsServiceToken(token) {
+			authenticate(c, manager, token)
+			return
+		}
+
+		c.Set("principal_type", PrincipalTypeUser)
+		userMiddleware(c)
+	}
+}
+
+// RequireService 要求呼叫方為服務，指定 services 時僅允許列出的服務
+func RequireService(services ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(services))
+	for _, service := range services {
+		allowed[service] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if c.GetString("principal_type") != PrincipalTypeService {
+			middleware.AbortWithError(c, auth.ErrForbidden)
+			return
+		}
+		if len(allowed) > 0 {
+			if _, ok := allowed[c.GetString("service")]; !ok {
+				middleware.AbortWithError(c, auth.ErrForbidden)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// authenticate 驗證 service token 並設定呼叫方服務
+func authenticate(c *gin.Context, manager *Manager, token string) {
+	claims, err := manager.ValidateToken(token)
+	if err != nil {
+		middleware.AbortWithError(c, auth.Unauthenticated(err))
+		return
+	}
+
+	c.Set("principal_type", PrincipalTypeService)
+	c.Set("service", claims.GetService())
+	c.Next()
+}
@@ -0,0 +1,109 @@
+package servicetoken
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRouter registers a handler that echoes the principal behind the given middlewares.
+func setupTestRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers = append(handlers, func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("principal_type")+":"+c.GetString("service"))
+	})
+	router.GET("/", handlers...)
+	return router
+}
+
+// performRequest sends a GET request with the given bearer token.
+func performRequest(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	receiver := newTestManager(t, "user-service")
+	token, _, err := newTestManager(t, "channel-service").GenerateToken("user-service")
+	require.NoError(t, err)
+
+	t.Run("Valid service token", func(t *testing.T) {
+		w := performRequest(setupTestRouter(Middleware(receiver)), token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "service:channel-service", w.Body.String())
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		w := performRequest(setupTestRouter(Middleware(receiver)), "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		w := performRequest(setupTestRouter(Middleware(receiver)), "invalid")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestPrincipalMiddleware(t *testing.T) {
+	receiver := newTestManager(t, "user-service")
+	userMiddleware := func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	}
+
+	t.Run("Service principal", func(t *testing.T) {
+		token, _, err := newTestManager(t, "channel-service").GenerateToken("user-service")
+		require.NoError(t, err)
+
+		w := performRequest(setupTestRouter(PrincipalMiddleware(receiver, userMiddleware)), token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "service:channel-service", w.Body.String())
+	})
+
+	t.Run("User principal", func(t *testing.T) {
+		w := performRequest(setupTestRouter(PrincipalMiddleware(receiver, userMiddleware)), "user-token")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user:", w.Body.String())
+	})
+}
+
+func TestRequireService(t *testing.T) {
+	receiver := newTestManager(t, "user-service")
+	userMiddleware := func(c *gin.Context) {
+		c.Next()
+	}
+	channelToken, _, err := newTestManager(t, "channel-service").GenerateToken("user-service")
+	require.NoError(t, err)
+	messageToken, _, err := newTestManager(t, "message-service").GenerateToken("user-service")
+	require.NoError(t, err)
+
+	t.Run("Allowed service", func(t *testing.T) {
+		router := setupTestRouter(Middleware(receiver), RequireService("channel-service"))
+		assert.Equal(t, http.StatusOK, performRequest(router, channelToken).Code)
+	})
+
+	t.Run("Other service", func(t *testing.T) {
+		router := setupTestRouter(Middleware(receiver), RequireService("channel-service"))
+		assert.Equal(t, http.StatusForbidden, performRequest(router, messageToken).Code)
+	})
+
+	t.Run("Any service", func(t *testing.T) {
+		router := setupTestRouter(Middleware(receiver), RequireService())
+		assert.Equal(t, http.StatusOK, performRequest(router, messageToken).Code)
+	})
+
+	t.Run("User principal is rejected", func(t *testing.T) {
+		router := setupTestRouter(PrincipalMiddleware(receiver, userMiddleware), RequireService())
+		assert.Equal(t, http.StatusForbidden, performRequest(router, "user-token").Code)
+	})
+}
@@ -0,0 +1,155 @@
+package servicetoken
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	jwtlib "github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultExpiresIn 未配置時 service token 的有效期，短效期降低外洩風險
+const defaultExpiresIn = 5 * time.Minute
+
+// ServiceClaims service token 聲明，Subject 為呼叫方服務，Audience 為被呼叫的服務
+type ServiceClaims struct {
+	jwt.RegisteredClaims
+}
+
+// GetService 獲取呼叫方服務名稱
+func (c *ServiceClaims) GetService() string {
+	return c.Subject
+}
+
+// Manager service token 管理器，以本服務的私鑰簽發 token，並以呼叫方服務的公鑰驗證呼叫本服務的 token
+// 每個服務只持有自己的私鑰，因此無法冒用其他服務的身分
+type Manager struct {
+	name      string
+	keys      atomic.Pointer[keys]
+	expiresIn time.Duration
+}
+
+// keys 簽發與驗證使用的金鑰，輪替時整組替換
+type keys struct {
+	privateKey ed25519.PrivateKey
+	callerKeys map[string]ed25519.PublicKey
+}
+
+// NewManager 創建新的 service token 管理器，金鑰格式不正確時回傳錯誤
+func NewManager(cfg *config.ServiceAuthConfig) (*Manager, error) {
+	expiresIn := time.Duration(cfg.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = defaultExpiresIn
+	}
+
+	manager := &Manager{
+		name:      cfg.Name,
+		expiresIn: expiresIn,
+	}
+	if err := manager.SetKeys(cfg); err != nil {
+		return nil, err
+	}
+	return manager, nil
+}
+
+// SetKeys 輪替本服務的私鑰與呼叫方公鑰，金鑰格式不正確時保留目前的金鑰並回傳錯誤
+func (m *Manager) SetKeys(cfg *config.ServiceAuthConfig) error {
+	parsed := &keys{callerKeys: make(map[string]ed25519.PublicKey, len(cfg.CallerKeys))}
+	if cfg.PrivateKey != "" {
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM([]byte(cfg.PrivateKey))
+		if err != nil {
+			return fmt.Errorf("parse service private key: %w", err)
+		}
+		parsed.privateKey = privateKey.(ed25519.PrivateKey)
+	}
+	for caller, pem := range cfg.CallerKeys {
+		publicKey, err := jwt.ParseEdPublicKeyFromPEM([]byte(pem))
+		if err != nil {
+			return fmt.Errorf("parse public key of %s: %w", caller, err)
+		}
+		parsed.callerKeys[caller] = publicKey.(ed25519.PublicKey)
+	}
+	m.keys.Store(parsed)
+	return nil
+}
+
+// Name 獲取本服務名稱
+func (m *Manager) Name() string {
+	return m.name
+}
+
+// GenerateToken 簽發呼叫 audience 服務的 token，回傳 token 與到期時間
+func (m *Manager) GenerateToken(audience string) (string, time.Time, error) {
+	privateKey := m.keys.Load().privateKey
+	if m.name == "" || len(privateKey) == 0 {
+		return "", time.Time{}, errors.New("service auth is not configured")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(m.expiresIn)
+	claims := &ServiceClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   m.name,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["typ"] = jwtlib.HeaderTypeServiceToken
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ValidateToken 驗證呼叫本服務的 token，以 sub 對應的呼叫方公鑰驗證簽章，audience 必須包含本服務名稱
+func (m *Manager) ValidateToken(tokenString string) (*ServiceClaims, error) {
+	callerKeys := m.keys.Load().callerKeys
+	token, err := jwt.ParseWithClaims(tokenString, &ServiceClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		claims, _ := token.Claims.(*ServiceClaims)
+		publicKey, ok := callerKeys[claims.GetService()]
+		if !ok {
+			return nil, fmt.Errorf("unknown caller: %s", claims.GetService())
+		}
+		return publicKey, nil
+	}, jwt.WithAudience(m.name), jwt.WithExpirationRequired())
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, auth.ErrExpiredToken
+		}
+		return nil, auth.ErrInvalidToken
+	}
+
+	if typ, _ := token.Header["typ"].(string); typ != jwtlib.HeaderTypeServiceToken {
+		return nil, auth.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ServiceClaims)
+	if !ok || !token.Valid || claims.Subject == "" {
+		return nil, auth.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// IsServiceToken 不驗證簽章，僅依 typ header 判斷是否為 service token
+func IsServiceToken(tokenString string) bool {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &jwt.RegisteredClaims{})
+	if err != nil {
+		return false
+	}
+	typ, _ := token.Header["typ"].(string)
+	return typ == jwtlib.HeaderTypeServiceToken
+}
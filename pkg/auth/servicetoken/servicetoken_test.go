@@ -0,0 +1,169 @@
+package servicetoken
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const SecretKey = "test-service-secret"
+
+// testServices are the services whose public keys every test manager trusts.
+var testServices = []string{"user-service", "channel-service", "message-service"}
+
+// testPrivateKey derives a deterministic Ed25519 key for the named service.
+func testPrivateKey(name string) ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	copy(seed, name)
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+// encodePrivateKey encodes key as a PKCS #8 PEM block.
+func encodePrivateKey(t *testing.T, key ed25519.PrivateKey) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// encodePublicKey encodes key as a PKIX PEM block.
+func encodePublicKey(t *testing.T, key ed25519.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// newTestManager creates a Manager for the named service trusting the keys of all test services.
+func newTestManager(t *testing.T, name string) *Manager {
+	callerKeys := make(map[string]string, len(testServices))
+	for _, service := range testServices {
+		callerKeys[service] = encodePublicKey(t, testPrivateKey(service).Public().(ed25519.PublicKey))
+	}
+	manager, err := NewManager(&config.ServiceAuthConfig{
+		Name:       name,
+		PrivateKey: encodePrivateKey(t, testPrivateKey(name)),
+		ExpiresIn:  60,
+		CallerKeys: callerKeys,
+	})
+	require.NoError(t, err)
+	return manager
+}
+
+func TestManager(t *testing.T) {
+	t.Run("Generate and Validate Token", func(t *testing.T) {
+		token, expiresAt, err := newTestManager(t, "channel-service").GenerateToken("user-service")
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+		assert.True(t, IsServiceToken(token))
+
+		claims, err := newTestManager(t, "user-service").ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, "channel-service", claims.GetService())
+	})
+
+	t.Run("Wrong audience", func(t *testing.T) {
+		token, _, err := newTestManager(t, "channel-service").GenerateToken("message-service")
+		require.NoError(t, err)
+
+		_, err = newTestManager(t, "user-service").ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		token, _, err := newTestManager(t, "channel-service").GenerateToken("user-service")
+		require.NoError(t, err)
+
+		manager, err := NewManager(&config.ServiceAuthConfig{
+			Name:       "user-service",
+			CallerKeys: map[string]string{"channel-service": encodePublicKey(t, testPrivateKey("other").Public().(ed25519.PublicKey))},
+		})
+		require.NoError(t, err)
+		_, err = manager.ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Caller cannot impersonate another service", func(t *testing.T) {
+		impostor, err := NewManager(&config.ServiceAuthConfig{
+			Name:       "channel-service",
+			PrivateKey: encodePrivateKey(t, testPrivateKey("message-service")),
+		})
+		require.NoError(t, err)
+		token, _, err := impostor.GenerateToken("user-service")
+		require.NoError(t, err)
+
+		_, err = newTestManager(t, "user-service").ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Rotate keys", func(t *testing.T) {
+		caller := newTestManager(t, "channel-service")
+		callee := newTestManager(t, "user-service")
+		oldToken, _, err := caller.GenerateToken("user-service")
+		require.NoError(t, err)
+
+		rotated := testPrivateKey("channel-service-rotated")
+		require.NoError(t, caller.SetKeys(&config.ServiceAuthConfig{PrivateKey: encodePrivateKey(t, rotated)}))
+		require.NoError(t, callee.SetKeys(&config.ServiceAuthConfig{
+			CallerKeys: map[string]string{"channel-service": encodePublicKey(t, rotated.Public().(ed25519.PublicKey))},
+		}))
+
+		newToken, _, err := caller.GenerateToken("user-service")
+		require.NoError(t, err)
+		_, err = callee.ValidateToken(newToken)
+		require.NoError(t, err)
+		_, err = callee.ValidateToken(oldToken)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+
+		// 格式錯誤的金鑰不會取代目前的金鑰
+		assert.Error(t, callee.SetKeys(&config.ServiceAuthConfig{CallerKeys: map[string]string{"channel-service": "invalid"}}))
+		_, err = callee.ValidateToken(newToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unknown caller", func(t *testing.T) {
+		token, _, err := newTestManager(t, "search-service").GenerateToken("user-service")
+		require.NoError(t, err)
+
+		_, err = newTestManager(t, "user-service").ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		manager, err := NewManager(&config.ServiceAuthConfig{})
+		require.NoError(t, err)
+		_, _, err = manager.GenerateToken("user-service")
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		_, err := NewManager(&config.ServiceAuthConfig{Name: "user-service", PrivateKey: SecretKey})
+		assert.Error(t, err)
+	})
+
+	t.Run("User token is not a service token", func(t *testing.T) {
+		rbacManager := rbac.NewRBACJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: 60000})
+		token, err := rbacManager.GenerateToken(rbac.NewRBACClaims(1, "test@example.com", "testUser", "admin", nil))
+		require.NoError(t, err)
+
+		assert.False(t, IsServiceToken(token))
+		_, err = newTestManager(t, "user-service").ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+
+	t.Run("Service token is rejected by first-party manager", func(t *testing.T) {
+		token, _, err := newTestManager(t, "channel-service").GenerateToken("user-service")
+		require.NoError(t, err)
+
+		rbacManager := rbac.NewRBACJWTManager(&config.JWTConfig{SecretKey: SecretKey, ExpiresIn: 60000})
+		_, err = rbacManager.ValidateToken(token)
+		assert.True(t, errors.Is(err, auth.ErrInvalidToken))
+	})
+}
@@ -0,0 +1,73 @@
+package servicetoken
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenSource 快取呼叫特定服務的 token，剩餘有效期不足 1/5 時自動更新
+type TokenSource struct {
+	manager  *Manager
+	audience string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewTokenSource 創建新的 token 來源
+func NewTokenSource(manager *Manager, audience string) *TokenSource {
+	return &TokenSource{
+		manager:  manager,
+		audience: audience,
+	}
+}
+
+// Token 獲取可用的 token，必要時重新簽發
+func (s *TokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > s.manager.expiresIn/5 {
+		return s.token, nil
+	}
+
+	token, expiresAt, err := s.manager.GenerateToken(s.audience)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// Transport 為每個請求加上 service token 的 http.RoundTripper
+type Transport struct {
+	Source *TokenSource
+	// Base 底層的 RoundTripper，nil 時使用 http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// RoundTrip 實作 http.RoundTripper，不修改原始請求
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+	return base.RoundTrip(clone)
+}
+
+// NewHTTPClient 創建會自動帶上 service token 的 HTTP 客戶端
+func NewHTTPClient(manager *Manager, audience string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &Transport{Source: NewTokenSource(manager, audience)},
+		Timeout:   timeout,
+	}
+}
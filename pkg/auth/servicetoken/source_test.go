@@ -0,0 +1,59 @@
+package servicetoken
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSource(t *testing.T) {
+	t.Run("Reuses token until renewal window", func(t *testing.T) {
+		source := NewTokenSource(newTestManager(t, "channel-service"), "user-service")
+
+		first, err := source.Token()
+		require.NoError(t, err)
+		second, err := source.Token()
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("Renews token close to expiry", func(t *testing.T) {
+		source := NewTokenSource(newTestManager(t, "channel-service"), "user-service")
+		first, err := source.Token()
+		require.NoError(t, err)
+
+		source.expiresAt = time.Now().Add(time.Second)
+		time.Sleep(time.Second) // iat 以秒為單位，確保新 token 不同
+		second, err := source.Token()
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+}
+
+func TestTransport(t *testing.T) {
+	receiver := newTestManager(t, "user-service")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")[len("Bearer "):]
+		claims, err := receiver.ValidateToken(token)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(claims.GetService()))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(newTestManager(t, "channel-service"), "user-service", time.Second)
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, req.Header.Get("Authorization"), "original request must not be modified")
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+// CommandName 服務執行檔的配置子命令，如 user-service config validate
+const CommandName = "config"
+
+// 子命令結束代碼
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+)
+
+// RunCommand 執行配置子命令並回傳結束代碼，args 為 config 之後的參數
+// 載入流程與服務啟動相同，支援 --env 與所有配置鍵的命令列參數
+func RunCommand(name string, args []string, stdout, stderr io.Writer) int {
+	usage := func() {
+		fmt.Fprintf(stderr, `usage: %s %s <command> [flags]
+
+commands:
+  validate              驗證配置，不合法時以非 0 結束
+  print                 輸出有效配置與每個值的來源，機密欄位已遮蔽
+  diff <env> [<env>]    比較兩個執行環境的有效配置，只指定一個時與目前環境比較
+`, name, CommandName)
+	}
+	if len(args) == 0 {
+		usage()
+		return exitUsage
+	}
+
+	flags := NewFlagSet(name + " " + CommandName + " " + args[0])
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	opts := LoadOptions{Flags: flags}
+
+	switch args[0] {
+	case "validate":
+		return runValidate(opts, stdout, stderr)
+	case "print":
+		return runPrint(opts, stdout, stderr)
+	case "diff":
+		return runDiff(opts, flags.Args(), stdout, stderr)
+	default:
+		usage()
+		return exitUsage
+	}
+}
+
+// runValidate 驗證配置
+func runValidate(opts LoadOptions, stdout, stderr io.Writer) int {
+	inspection, err := Inspect(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitInvalid
+	}
+	if inspection.Err != nil {
+		fmt.Fprintln(stderr, inspection.Err)
+		return exitInvalid
+	}
+	fmt.Fprintf(stdout, "config is valid (%s)\n", describeFiles(inspection.Files))
+	return exitOK
+}
+
+// runPrint 輸出有效配置與來源
+func runPrint(opts LoadOptions, stdout, stderr io.Writer) int {
+	inspection, err := Inspect(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitInvalid
+	}
+
+	fmt.Fprintf(stdout, "# files: %s\n", describeFiles(inspection.Files))
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, setting := range inspection.Settings {
+		source := setting.Source
+		if setting.Ref != "" {
+			source += " (" + setting.Ref + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, formatValue(setting.Value), source)
+	}
+	_ = w.Flush()
+
+	if inspection.Err != nil {
+		fmt.Fprintln(stderr, inspection.Err)
+		return exitInvalid
+	}
+	return exitOK
+}
+
+// runDiff 比較兩個執行環境的有效配置
+func runDiff(opts LoadOptions, envs []string, stdout, stderr io.Writer) int {
+	var fromOpts, toOpts LoadOptions
+	switch len(envs) {
+	case 1:
+		fromOpts, toOpts = opts, opts
+		toOpts.Env = envs[0]
+	case 2:
+		fromOpts, toOpts = opts, opts
+		fromOpts.Env, toOpts.Env = envs[0], envs[1]
+	default:
+		fmt.Fprintf(stderr, "diff expects one or two environments, got %d\n", len(envs))
+		return exitUsage
+	}
+
+	from, err := Inspect(fromOpts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitInvalid
+	}
+	to, err := Inspect(toOpts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitInvalid
+	}
+
+	fmt.Fprintf(stdout, "--- %s\n+++ %s\n", describeFiles(from.Files), describeFiles(to.Files))
+	differences := Diff(from, to)
+	if len(differences) == 0 {
+		fmt.Fprintln(stdout, "no differences")
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, difference := range differences {
+		fmt.Fprintf(w, "%s\t%s\t->\t%s\n", difference.Key, formatValue(difference.Old), formatValue(difference.New))
+	}
+	_ = w.Flush()
+
+	code := exitOK
+	for _, inspection := range []*Inspection{from, to} {
+		if inspection.Err != nil {
+			fmt.Fprintln(stderr, inspection.Err)
+			code = exitInvalid
+		}
+	}
+	return code
+}
+
+// describeFiles 列出讀取的配置檔，未讀取任何檔案時只使用預設值與環境變數
+func describeFiles(files []string) string {
+	if len(files) == 0 {
+		return "no config file"
+	}
+	return strings.Join(files, ", ")
+}
+
+// formatValue 格式化輸出的值，列表以逗號分隔，空字串以 "" 表示
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
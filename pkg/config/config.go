@@ -1,67 +1,162 @@
 package config
 
 import (
-	"fmt"
-	"github.com/spf13/viper"
+	"github.com/POABOB/slack-clone-back-end/pkg/observability"
+	"github.com/POABOB/slack-clone-back-end/pkg/resilience"
 )
 
 // Config 應用配置結構
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Router   RouterConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Log         LogConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	Router      RouterConfig
+	JWT         JWTConfig
+	SCIM        SCIMConfig
+	OAuth       OAuthConfig
+	Service     ServiceAuthConfig
+	Secrets     SecretsConfig
+	FeatureFlag FeatureFlagConfig
+	Tracing     observability.TracingConfig
+	Resilience  resilience.Config
 }
 
 // ServerConfig 服務器配置
 type ServerConfig struct {
-	Host string
-	Port int
-	Mode string
+	Host string `validate:"required"`
+	Port int    `validate:"min=1,max=65535"`
+	Mode string `validate:"oneof=debug release test"`
+	// 讀取整個請求（含 body）的逾時（秒），0 表示不限制
+	ReadTimeout int `validate:"min=0"`
+	// 讀取請求標頭的逾時（秒），避免慢速連線佔用資源
+	ReadHeaderTimeout int `validate:"min=0"`
+	// 寫入回應的逾時（秒），0 表示不限制
+	WriteTimeout int `validate:"min=0"`
+	// keep-alive 連線閒置多久（秒）後關閉
+	IdleTimeout int `validate:"min=0"`
+	// 請求標頭大小上限（位元組）
+	MaxHeaderBytes int `validate:"min=0"`
+	// 關閉時等待處理中請求完成的上限（秒），逾時後強制關閉連線
+	ShutdownTimeout int `validate:"min=0"`
+	// 是否啟用 HTTP/2，啟用 TLS 時以 ALPN 協商，否則以 h2c（prior knowledge）提供
+	EnableHTTP2 bool
+	// TLS 配置
+	TLS TLSConfig
+}
+
+// TLSConfig HTTPS 配置
+type TLSConfig struct {
+	Enabled bool
+	// PEM 格式的憑證檔案路徑
+	CertFile string `validate:"required_if=Enabled true"`
+	// PEM 格式的私鑰檔案路徑
+	KeyFile string `validate:"required_if=Enabled true"`
+	// 最低 TLS 版本
+	MinVersion string `validate:"oneof=1.2 1.3"`
+}
+
+// LogConfig 日誌配置，支援熱更新
+type LogConfig struct {
+	Level string `validate:"oneof=debug info warn error"`
 }
 
 // DatabaseConfig 資料庫配置
 type DatabaseConfig struct {
-	Host         string
-	Port         int
-	User         string
-	Password     string
-	DBName       string
-	SSLMode      string
-	MaxIdleConns int
-	MaxOpenConns int
+	Host         string `validate:"required"`
+	Port         int    `validate:"min=1,max=65535"`
+	User         string `validate:"required"`
+	Password     string `secret:"true"`
+	DBName       string `validate:"required"`
+	SSLMode      string `validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	MaxIdleConns int    `validate:"min=0"`
+	MaxOpenConns int    `validate:"min=1"`
 }
 
 // RedisConfig Redis 配置
 type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+	Host     string `validate:"required"`
+	Port     int    `validate:"min=1,max=65535"`
+	Password string `secret:"true"`
+	DB       int    `validate:"min=0,max=15"`
 }
 
+// JWTConfig JWT 配置
 type JWTConfig struct {
-	SecretKey string
-	ExpiresIn int
+	SecretKey string `validate:"required,min=16" secret:"true"`
+	// token 有效期（毫秒）
+	ExpiresIn int `validate:"min=0"`
+	// 金鑰輪替後舊金鑰仍可驗證的時間（毫秒），為 0 時舊金鑰立即失效；以舊金鑰簽發的 token 不得換發新 token
+	KeyOverlap int `validate:"min=0"`
 }
 
-func LoadConfig() (*Config, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./config")
-	viper.AutomaticEnv()
+// SCIMConfig SCIM 佈建配置
+type SCIMConfig struct {
+	// 身分提供者（IdP）呼叫 SCIM API 時使用的 Bearer token
+	Token string `secret:"true"`
+	// 單次列表查詢最多回傳的資源數量
+	MaxResults int `validate:"min=0"`
+}
 
-	// TODO use logger
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
-	}
+// ServiceAuthConfig 服務間驗證配置
+type ServiceAuthConfig struct {
+	// 本服務的名稱，作為簽發 token 的 subject 與驗證 token 的 audience
+	Name string `validate:"required"`
+	// 本服務簽發 service token 的 Ed25519 私鑰（PKCS #8 PEM），各服務各自持有，不得與使用者 JWT 金鑰相同
+	PrivateKey string `validate:"omitempty,edprivatekey" secret:"true"`
+	// service token 有效期（秒）
+	ExpiresIn int `validate:"min=0"`
+	// 允許呼叫內部 API 的服務
+	AllowedCallers []string
+	// 呼叫方服務的 Ed25519 公鑰（PKIX PEM），以 token 的 sub 查找，未列出的服務無法通過驗證
+	CallerKeys map[string]string `validate:"dive,edpublickey"`
+}
 
-	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
-	}
+// OAuthConfig OAuth 2.0 配置
+type OAuthConfig struct {
+	// Refresh token 有效期（秒）
+	RefreshTokenTTL int `validate:"min=0"`
+	// 授權碼有效期（秒）
+	AuthorizationCodeTTL int `validate:"min=0"`
+	// 裝置授權流程配置
+	Device DeviceFlowConfig
+}
 
-	return &cfg, nil
+// DeviceFlowConfig 裝置授權流程（RFC 8628）配置
+type DeviceFlowConfig struct {
+	// 允許使用裝置授權流程的 client，如 CLI 與桌面工具
+	ClientIDs []string
+	// 使用者輸入 user_code 的前端驗證頁面，頁面以 /auth/device API 顯示與確認授權
+	VerificationURI string `validate:"omitempty,url"`
+	// device_code 有效期（秒）
+	ExpiresIn int `validate:"min=0"`
+	// 最短輪詢間隔（秒）
+	Interval int `validate:"min=0"`
+}
+
+// FeatureFlagConfig 功能旗標配置
+type FeatureFlagConfig struct {
+	// 旗標檔案路徑，設定時改由檔案載入旗標，不使用資料庫
+	File string
+	// Redis 快取有效期（秒）
+	CacheTTL int `validate:"min=0"`
+}
+
+// SecretsConfig 機密資訊來源配置
+// 標記 secret:"true" 的欄位可填入參照而非明文，如 file:///run/secrets/jwt、env://DB_PASSWORD、
+// vault://secret/data/user-service#jwtSecretKey
+type SecretsConfig struct {
+	// Vault 相容 KV API 位址，如 http://vault:8200，未設定時不支援 vault://
+	VaultAddress string `validate:"omitempty,url"`
+	// Vault token，可使用 file:// 或 env:// 參照
+	VaultToken string `secret:"true"`
+	// 解析結果的快取有效期（秒），0 表示不快取
+	CacheTTL int `validate:"min=0"`
+	// 定期重新載入配置以取得輪替後的機密資訊（秒），0 表示停用
+	RefreshInterval int `validate:"min=0"`
+}
+
+// LoadConfig 以預設選項載入配置，詳見 Load
+func LoadConfig() (*Config, error) {
+	return Load(LoadOptions{})
 }
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// SourceDefault 值來自內建預設值
+	SourceDefault = "default"
+	// sourceEnvPrefix 值來自環境變數，如 env SLACK_SERVER_PORT
+	sourceEnvPrefix = "env "
+	// sourceFlagPrefix 值來自命令列參數，如 flag --server.port
+	sourceFlagPrefix = "flag --"
+)
+
+// Setting 單一配置鍵的有效值與來源
+type Setting struct {
+	// 配置鍵，如 database.password
+	Key string
+	// 有效值，機密欄位會以 RedactedValue 取代
+	Value interface{}
+	// 值的來源：default、配置檔名稱、env <變數>、flag --<參數>
+	Source string
+	// 機密欄位使用的參照，如 env://DB_PASSWORD，明文時為空
+	Ref string
+}
+
+// Inspection 配置檢查結果
+type Inspection struct {
+	// 實際讀取的配置檔
+	Files []string
+	// 所有配置鍵，順序與 Config 結構一致
+	Settings []Setting
+	// 驗證錯誤，通過時為 nil
+	Err error
+
+	config *Config
+}
+
+// Difference 兩份配置之間不同的鍵
+type Difference struct {
+	Key string
+	Old interface{}
+	New interface{}
+}
+
+// Inspect 以與 Load 相同的流程載入配置並記錄每個鍵的來源，驗證錯誤記錄於 Err 而非回傳
+func Inspect(opts LoadOptions) (*Inspection, error) {
+	opts = opts.withDefaults()
+	v, files, err := newViper(opts)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decode(v, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fileLayers := make([]*viper.Viper, 0, len(files))
+	for _, file := range files {
+		layer := viper.New()
+		layer.SetConfigFile(file)
+		if err := layer.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		fileLayers = append(fileLayers, layer)
+	}
+
+	secrets := secretKeys()
+	inspection := &Inspection{Files: files, Err: Validate(cfg), config: cfg}
+	walkKeys(reflect.ValueOf(Redact(cfg)).Elem(), "", func(key string, value reflect.Value) {
+		setting := Setting{Key: key, Value: value.Interface(), Source: SourceDefault}
+		for i := len(fileLayers) - 1; i >= 0; i-- {
+			if fileLayers[i].InConfig(key) {
+				setting.Source = filepath.Base(files[i])
+				break
+			}
+		}
+		if os.Getenv(envKey(key)) != "" {
+			setting.Source = sourceEnvPrefix + envKey(key)
+		}
+		if opts.Flags != nil {
+			if flag := opts.Flags.Lookup(key); flag != nil && flag.Changed {
+				setting.Source = sourceFlagPrefix + key
+			}
+		}
+		if _, ok := secrets[key]; ok {
+			if raw := v.GetString(key); strings.Contains(raw, "://") {
+				setting.Ref = raw
+			}
+		}
+		inspection.Settings = append(inspection.Settings, setting)
+	})
+	return inspection, nil
+}
+
+// Diff 比較兩次檢查的有效值，機密欄位比較實際值但只輸出遮蔽後的值
+// 只存在於其中一方的鍵（如新增或移除的限流策略）另一方的值為 nil
+func Diff(from, to *Inspection) []Difference {
+	oldValues, newValues := values(from.config), values(to.config)
+	oldRedacted, newRedacted := values(Redact(from.config)), values(Redact(to.config))
+
+	keys := make([]string, 0, len(to.Settings)+len(from.Settings))
+	for _, setting := range to.Settings {
+		keys = append(keys, setting.Key)
+	}
+	for _, setting := range from.Settings {
+		if _, ok := newValues[setting.Key]; !ok {
+			keys = append(keys, setting.Key)
+		}
+	}
+
+	var differences []Difference
+	for _, key := range keys {
+		if !reflect.DeepEqual(oldValues[key], newValues[key]) {
+			differences = append(differences, Difference{
+				Key: key,
+				Old: oldRedacted[key],
+				New: newRedacted[key],
+			})
+		}
+	}
+	return differences
+}
+
+// values 以配置鍵索引所有欄位的值
+func values(cfg *Config) map[string]interface{} {
+	result := make(map[string]interface{})
+	walkKeys(reflect.ValueOf(cfg).Elem(), "", func(key string, value reflect.Value) {
+		result[key] = value.Interface()
+	})
+	return result
+}
+
+// secretKeys 機密欄位對應的配置鍵，如 database.password
+func secretKeys() map[string]struct{} {
+	keys := make(map[string]struct{})
+	walkSecrets(reflect.ValueOf(Config{}), "", func(field string, _ reflect.Value) {
+		keys[strings.ToLower(field)] = struct{}{}
+	})
+	return keys
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// settingsByKey indexes inspection settings by key.
+func settingsByKey(inspection *Inspection) map[string]Setting {
+	settings := make(map[string]Setting, len(inspection.Settings))
+	for _, setting := range inspection.Settings {
+		settings[setting.Key] = setting
+	}
+	return settings
+}
+
+func TestInspect(t *testing.T) {
+	t.Setenv("TEST_DB_PASSWORD", "db-password-from-env")
+	t.Setenv("SLACK_REDIS_DB", "3")
+
+	content := strings.Replace(baseConfig, `password: "from-file"`, `password: "env://TEST_DB_PASSWORD"`, 1)
+	dir := writeConfig(t, t.TempDir(), "config.yaml", content)
+	writeConfig(t, dir, "config.production.yaml", "server:\n  mode: \"release\"\n")
+
+	flags := NewFlagSet("test")
+	require.NoError(t, flags.Parse([]string{"--server.host=127.0.0.1"}))
+
+	inspection, err := Inspect(LoadOptions{Paths: []string{dir}, Env: "production", Flags: flags})
+	require.NoError(t, err)
+	require.NoError(t, inspection.Err)
+	assert.Len(t, inspection.Files, 2)
+
+	settings := settingsByKey(inspection)
+	assert.Equal(t, Setting{Key: "database.port", Value: 5432, Source: SourceDefault}, settings["database.port"])
+	assert.Equal(t, Setting{Key: "server.port", Value: 8080, Source: "config.yaml"}, settings["server.port"])
+	assert.Equal(t, Setting{Key: "server.mode", Value: "release", Source: "config.production.yaml"}, settings["server.mode"])
+	assert.Equal(t, Setting{Key: "redis.db", Value: 3, Source: "env SLACK_REDIS_DB"}, settings["redis.db"])
+	assert.Equal(t, Setting{Key: "server.host", Value: "127.0.0.1", Source: "flag --server.host"}, settings["server.host"])
+	assert.Equal(t, Setting{
+		Key:    "database.password",
+		Value:  RedactedValue,
+		Source: "config.yaml",
+		Ref:    "env://TEST_DB_PASSWORD",
+	}, settings["database.password"])
+	assert.Equal(t, RedactedValue, settings["jwt.secretkey"].Value)
+	assert.Empty(t, settings["jwt.secretkey"].Ref, "plain-text secrets have no reference")
+}
+
+func TestDiff(t *testing.T) {
+	dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+	writeConfig(t, dir, "config.production.yaml",
+		"server:\n  mode: \"release\"\njwt:\n  secretKey: \"production-secret-key-0123\"\n")
+
+	from, err := Inspect(LoadOptions{Paths: []string{dir}})
+	require.NoError(t, err)
+	to, err := Inspect(LoadOptions{Paths: []string{dir}, Env: "production"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Difference{
+		{Key: "server.mode", Old: "debug", New: "release"},
+		{Key: "jwt.secretkey", Old: RedactedValue, New: RedactedValue},
+	}, Diff(from, to))
+}
+
+func TestDiffMapKeys(t *testing.T) {
+	dir := writeConfig(t, t.TempDir(), "config.yaml",
+		withRouter("  rateLimitPolicies:\n    login:\n      rate: \"5/min\"\n"))
+	writeConfig(t, dir, "config.production.yaml",
+		"router:\n  rateLimitPolicies:\n    search:\n      rate: \"10/s\"\n")
+
+	base, err := Inspect(LoadOptions{Paths: []string{dir}})
+	require.NoError(t, err)
+	production, err := Inspect(LoadOptions{Paths: []string{dir}, Env: "production"})
+	require.NoError(t, err)
+
+	var sources []string
+	for _, setting := range production.Settings {
+		if setting.Key == "router.ratelimitpolicies.login.rate" || setting.Key == "router.ratelimitpolicies.search.rate" {
+			sources = append(sources, setting.Key+"="+setting.Source)
+		}
+	}
+	assert.Equal(t, []string{
+		"router.ratelimitpolicies.login.rate=config.yaml",
+		"router.ratelimitpolicies.search.rate=config.production.yaml",
+	}, sources)
+
+	differences := Diff(production, base)
+	assert.Contains(t, differences, Difference{Key: "router.ratelimitpolicies.search.rate", Old: "10/s", New: nil})
+	for _, difference := range differences {
+		assert.NotEqual(t, "router.ratelimitpolicies.login.rate", difference.Key, "unchanged policy is not reported")
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := RunCommand("user-service", args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("Validate", func(t *testing.T) {
+		t.Chdir(writeConfig(t, t.TempDir(), "config.yaml", baseConfig))
+
+		code, stdout, _ := run("validate")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "config is valid")
+
+		code, _, stderr := run("validate", "--server.port=0")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "Server.Port")
+	})
+
+	t.Run("Print redacts secrets and shows sources", func(t *testing.T) {
+		t.Chdir(writeConfig(t, t.TempDir(), "config.yaml", baseConfig))
+
+		code, stdout, _ := run("print", "--server.port=9090")
+		assert.Equal(t, 0, code)
+		assert.Regexp(t, `server\.port\s+9090\s+flag --server\.port`, stdout)
+		assert.Regexp(t, `database\.password\s+\*{6}\s+config\.yaml`, stdout)
+		assert.NotContains(t, stdout, "from-file")
+		assert.NotContains(t, stdout, "test-secret-key-0123456789")
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		writeConfig(t, dir, "config.staging.yaml", "log:\n  level: \"debug\"\n")
+		t.Chdir(dir)
+
+		code, stdout, _ := run("diff", "staging")
+		assert.Equal(t, 0, code)
+		assert.Regexp(t, `log\.level\s+info\s+->\s+debug`, stdout)
+
+		code, _, _ = run("diff")
+		assert.Equal(t, 2, code)
+	})
+
+	t.Run("Unknown command", func(t *testing.T) {
+		code, _, stderr := run("apply")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "usage:")
+	})
+}
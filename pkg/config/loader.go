@@ -0,0 +1,271 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/observability"
+	"github.com/POABOB/slack-clone-back-end/pkg/resilience"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	// EnvPrefix 環境變數前綴，巢狀鍵以 _ 分隔，如 SLACK_DATABASE_PASSWORD
+	EnvPrefix = "SLACK"
+	// EnvName 指定執行環境的環境變數，如 SLACK_ENV=production 會額外載入 config.production.yaml
+	EnvName = EnvPrefix + "_ENV"
+)
+
+// LoadOptions 配置載入選項
+type LoadOptions struct {
+	// 配置檔名稱（不含副檔名），預設為 config
+	Name string
+	// 配置檔搜尋路徑，預設為 . 與 ./config
+	Paths []string
+	// 執行環境，未指定時讀取 SLACK_ENV
+	Env string
+	// 命令列參數，通常由 NewFlagSet 建立，僅採用有明確設定的 flag
+	Flags *pflag.FlagSet
+	// 機密資訊解析器，未指定時依 secrets 區塊建立
+	Secrets *SecretResolver
+}
+
+// Load 依序疊加各層配置後驗證，後者覆蓋前者：
+// 內建預設值 → config.yaml → config.<env>.yaml → SLACK_ 環境變數 → 命令列參數
+// 接著解析機密欄位中的參照，驗證失敗時回傳 *ValidationError，列出所有不合法的欄位
+func Load(opts LoadOptions) (*Config, error) {
+	v, _, err := newViper(opts)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decode(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decode 將疊加後的配置轉為結構體並解析機密欄位
+func decode(v *viper.Viper, opts LoadOptions) (*Config, error) {
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	resolver := opts.Secrets
+	if resolver == nil {
+		var err error
+		if resolver, err = NewSecretResolver(cfg.Secrets); err != nil {
+			return nil, err
+		}
+	}
+	if err := ResolveSecrets(context.Background(), &cfg, resolver); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Defaults 內建預設值，敏感資訊與環境相關的欄位不提供預設值
+func Defaults() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Host:              "0.0.0.0",
+			Port:              8080,
+			Mode:              "debug",
+			ReadTimeout:       30,
+			ReadHeaderTimeout: 5,
+			WriteTimeout:      30,
+			IdleTimeout:       120,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   20,
+			EnableHTTP2:       true,
+			TLS: TLSConfig{
+				MinVersion: "1.2",
+			},
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Database: DatabaseConfig{
+			Port:         5432,
+			SSLMode:      "disable",
+			MaxIdleConns: 10,
+			MaxOpenConns: 100,
+		},
+		Redis: RedisConfig{
+			Port: 6379,
+		},
+		Router: *DefaultRouterConfig(),
+		JWT: JWTConfig{
+			ExpiresIn: 24 * 60 * 60 * 1000,
+		},
+		SCIM: SCIMConfig{
+			MaxResults: 100,
+		},
+		OAuth: OAuthConfig{
+			RefreshTokenTTL:      30 * 24 * 60 * 60,
+			AuthorizationCodeTTL: 600,
+			Device: DeviceFlowConfig{
+				ExpiresIn: 600,
+				Interval:  5,
+			},
+		},
+		Service: ServiceAuthConfig{
+			ExpiresIn: 300,
+		},
+		Secrets: SecretsConfig{
+			CacheTTL: 300,
+		},
+		FeatureFlag: FeatureFlagConfig{
+			CacheTTL: 30,
+		},
+		Tracing: observability.TracingConfig{
+			Exporter:    observability.ExporterOTLP,
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			SampleRatio: 1,
+		},
+		Resilience: resilience.Config{
+			Breaker: resilience.BreakerConfig{
+				Enabled:          true,
+				FailureThreshold: 5,
+				OpenTimeout:      30,
+				HalfOpenRequests: 1,
+			},
+			Retry: resilience.RetryConfig{
+				MaxAttempts: 3,
+				BaseDelay:   50,
+				MaxDelay:    1000,
+			},
+		},
+	}
+}
+
+// NewFlagSet 為每個配置鍵建立對應的命令列參數，如 --server.port=9090，另以 --env 指定執行環境
+func NewFlagSet(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.String("env", "", "執行環境，會額外載入 config.<env>.yaml")
+	walkKeys(reflect.ValueOf(Defaults()).Elem(), "", func(key string, value reflect.Value) {
+		switch value.Kind() {
+		case reflect.String:
+			flags.String(key, value.String(), "")
+		case reflect.Int:
+			flags.Int(key, int(value.Int()), "")
+		case reflect.Float64:
+			flags.Float64(key, value.Float(), "")
+		case reflect.Bool:
+			flags.Bool(key, value.Bool(), "")
+		case reflect.Slice:
+			flags.StringSlice(key, nil, "")
+		}
+	})
+	return flags
+}
+
+// withDefaults 補上未指定的檔名、路徑與執行環境
+func (opts LoadOptions) withDefaults() LoadOptions {
+	if opts.Name == "" {
+		opts.Name = "config"
+	}
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{".", "./config"}
+	}
+	if opts.Env == "" && opts.Flags != nil {
+		if flag := opts.Flags.Lookup("env"); flag != nil && flag.Changed {
+			opts.Env = flag.Value.String()
+		}
+	}
+	if opts.Env == "" {
+		opts.Env = os.Getenv(EnvName)
+	}
+	return opts
+}
+
+// newViper 建立疊加所有配置來源的 viper 實例，並回傳實際讀取的配置檔
+func newViper(opts LoadOptions) (*viper.Viper, []string, error) {
+	opts = opts.withDefaults()
+	name, paths, env := opts.Name, opts.Paths, opts.Env
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	for _, path := range paths {
+		v.AddConfigPath(path)
+	}
+
+	// 預先註冊所有鍵，未出現在配置檔中的鍵也能由環境變數覆蓋
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	walkKeys(reflect.ValueOf(Defaults()).Elem(), "", func(key string, value reflect.Value) {
+		v.SetDefault(key, value.Interface())
+		_ = v.BindEnv(key)
+	})
+
+	var files []string
+	v.SetConfigName(name)
+	if err := v.ReadInConfig(); err == nil {
+		files = append(files, v.ConfigFileUsed())
+	} else if !isConfigNotFound(err) {
+		return nil, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if env != "" {
+		v.SetConfigName(name + "." + env)
+		if err := v.MergeInConfig(); err == nil {
+			files = append(files, v.ConfigFileUsed())
+		} else if !isConfigNotFound(err) {
+			return nil, nil, fmt.Errorf("failed to read %s config: %w", env, err)
+		}
+	}
+
+	// viper 只採用有明確設定的 flag，未設定時仍以其他來源為準
+	if opts.Flags != nil {
+		if err := v.BindPFlags(opts.Flags); err != nil {
+			return nil, nil, fmt.Errorf("failed to bind flags: %w", err)
+		}
+	}
+	return v, files, nil
+}
+
+// envKey 配置鍵對應的環境變數，如 database.password → SLACK_DATABASE_PASSWORD
+func envKey(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// walkKeys 走訪結構體的所有葉節點，鍵為小寫並以 . 串接，與 viper 的鍵格式一致
+// 值為結構體的 map 依鍵排序後展開，如 router.ratelimitpolicies.login.rate；預設值中的空 map 不會產生任何鍵
+func walkKeys(value reflect.Value, prefix string, fn func(key string, value reflect.Value)) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := prefix + strings.ToLower(field.Name)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			walkKeys(value.Field(i), key+".", fn)
+		case field.Type.Kind() == reflect.Map && field.Type.Elem().Kind() == reflect.Struct:
+			keys := value.Field(i).MapKeys()
+			sort.Slice(keys, func(a, b int) bool { return keys[a].String() < keys[b].String() })
+			for _, mapKey := range keys {
+				walkKeys(value.Field(i).MapIndex(mapKey), key+"."+strings.ToLower(mapKey.String())+".", fn)
+			}
+		default:
+			fn(key, value.Field(i))
+		}
+	}
+}
+
+// isConfigNotFound 配置檔不存在時改用其他來源，不視為錯誤
+func isConfigNotFound(err error) bool {
+	var notFound viper.ConfigFileNotFoundError
+	return errors.As(err, &notFound)
+}
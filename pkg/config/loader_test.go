@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseConfig = `
+server:
+  port: 8080
+database:
+  host: "postgres"
+  user: "postgres"
+  password: "from-file"
+  dbname: "user-service"
+redis:
+  host: "redis"
+jwt:
+  secretKey: "test-secret-key-0123456789"
+router:
+  idempotencyConfig:
+    fingerprintKey: "test-fingerprint-key"
+service:
+  name: "user-service"
+  allowedCallers:
+    - "channel-service"
+`
+
+// withRouter returns baseConfig with extra appended to its router block.
+func withRouter(extra string) string {
+	return strings.Replace(baseConfig, "router:\n", "router:\n"+extra, 1)
+}
+
+// writeConfig writes a config file into dir and returns dir.
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults fill missing fields", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}})
+		require.NoError(t, err)
+		assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+		assert.Equal(t, 5432, cfg.Database.Port)
+		assert.Equal(t, "disable", cfg.Database.SSLMode)
+		assert.Equal(t, "v1", cfg.Router.APIVersion)
+		assert.Equal(t, []string{"channel-service"}, cfg.Service.AllowedCallers)
+	})
+
+	t.Run("Per-environment file overrides base file", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		writeConfig(t, dir, "config.production.yaml", "server:\n  mode: \"release\"\n")
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}, Env: "production"})
+		require.NoError(t, err)
+		assert.Equal(t, "release", cfg.Server.Mode)
+		assert.Equal(t, "from-file", cfg.Database.Password)
+	})
+
+	t.Run("Environment variables override nested keys", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		t.Setenv("SLACK_DATABASE_PASSWORD", "from-env")
+		t.Setenv("SLACK_OAUTH_DEVICE_CLIENTIDS", "slack-cli,slack-desktop")
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}})
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.Database.Password)
+		assert.Equal(t, []string{"slack-cli", "slack-desktop"}, cfg.OAuth.Device.ClientIDs)
+	})
+
+	t.Run("SLACK_ENV selects environment", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		writeConfig(t, dir, "config.staging.yaml", "server:\n  port: 9000\n")
+		t.Setenv(EnvName, "staging")
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}})
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.Server.Port)
+	})
+
+	t.Run("Flags override environment variables", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		t.Setenv("SLACK_SERVER_PORT", "9000")
+		flags := NewFlagSet("test")
+		require.NoError(t, flags.Parse([]string{"--server.port=9090"}))
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}, Flags: flags})
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+	})
+
+	t.Run("Unset flags do not override other sources", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		t.Setenv("SLACK_SERVER_PORT", "9000")
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}, Flags: NewFlagSet("test")})
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.Server.Port)
+		assert.Equal(t, "postgres", cfg.Database.Host)
+	})
+
+	t.Run("Reports all invalid fields", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", "server:\n  port: 70000\n  mode: \"prod\"\n")
+
+		_, err := Load(LoadOptions{Paths: []string{dir}})
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+
+		fields := make([]string, 0, len(validationErr.Fields))
+		for _, field := range validationErr.Fields {
+			fields = append(fields, field.Field)
+		}
+		assert.ElementsMatch(t, []string{
+			"Server.Port", "Server.Mode", "Database.Host", "Database.User", "Database.DBName",
+			"Redis.Host", "JWT.SecretKey", "Service.Name", "Router.IdempotencyConfig.FingerprintKey",
+		}, fields)
+		assert.Contains(t, err.Error(), "Server.Port")
+	})
+
+	t.Run("Rate limit policies", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", withRouter(`  rateLimitPolicies:
+    login:
+      rate: "5/min"
+      keyBy: "ip"
+    default:
+      rate: "100/s"
+      keyBy: "user"
+`))
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}})
+		require.NoError(t, err)
+		require.Len(t, cfg.Router.RateLimitPolicies, 2)
+		assert.Equal(t, "5/min", cfg.Router.RateLimitPolicies["login"].Rate)
+		assert.Equal(t, "user", cfg.Router.RateLimitPolicies["default"].KeyBy)
+
+		writeConfig(t, dir, "config.yaml", withRouter("  rateLimitPolicies:\n    login:\n      rate: \"5/fortnight\"\n"))
+		_, err = Load(LoadOptions{Paths: []string{dir}})
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "ratelimit", validationErr.Fields[0].Rule)
+	})
+
+	t.Run("Router rules", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", withRouter(`  trustedProxies: ["10.0.0.0/8", "127.0.0.1", "proxy.internal"]
+  corsConfig:
+    allowOrigins: ["https://app.example.com", "*"]
+    allowCredentials: true
+  rateLimitConfig:
+    keyBy: "user"
+`))
+
+		_, err := Load(LoadOptions{Paths: []string{dir}})
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		rules := make(map[string]string, len(validationErr.Fields))
+		for _, field := range validationErr.Fields {
+			rules[field.Field] = field.Rule
+		}
+		assert.Equal(t, map[string]string{
+			"Router.TrustedProxies[2]":       "cidr|ip",
+			"Router.RateLimitConfig.KeyBy":   "ne=user",
+			"Router.CORSConfig.AllowOrigins": "excluded_with=AllowCredentials",
+		}, rules)
+	})
+
+	t.Run("Service keys", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig+`
+  privateKey: "test-secret-key-0123456789"
+  callerKeys:
+    channel-service: "not a key"
+`)
+
+		_, err := Load(LoadOptions{Paths: []string{dir}})
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		rules := make(map[string]string, len(validationErr.Fields))
+		for _, field := range validationErr.Fields {
+			rules[field.Field] = field.Rule
+		}
+		assert.Equal(t, map[string]string{
+			"Service.PrivateKey":                  "necsfield=JWT.SecretKey",
+			"Service.CallerKeys[channel-service]": "edpublickey",
+		}, rules)
+	})
+
+	t.Run("Missing config file falls back to other sources", func(t *testing.T) {
+		_, err := Load(LoadOptions{Paths: []string{t.TempDir()}})
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+	})
+}
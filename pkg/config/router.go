@@ -1,54 +1,151 @@
 package config
 
 import (
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/health"
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
 	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+	"github.com/POABOB/slack-clone-back-end/pkg/observability"
+	"github.com/POABOB/slack-clone-back-end/pkg/resilience"
+	"github.com/POABOB/slack-clone-back-end/pkg/validation"
+	"github.com/POABOB/slack-clone-back-end/pkg/versioning"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 )
 
 // RouterConfig 路由配置
 type RouterConfig struct {
-	// API 版本
-	APIVersion string
+	// API 預設版本，路徑與 Accept 標頭都未指定版本時使用
+	APIVersion string `validate:"required"`
+	// API 版本配置，所有版本同時提供
+	VersioningConfig versioning.Config
+	// 信任的反向代理 IP 或 CIDR，只採用這些位址送來的 X-Forwarded-For 作為用戶端 IP
+	// 未設定時不信任任何代理，一律使用連線的來源位址，避免用戶端偽造標頭繞過 IP 限流
+	TrustedProxies []string `validate:"dive,cidr|ip"`
+	// 是否啟用 panic 恢復
+	EnableRecovery bool
+	// 是否啟用存活與就緒探針
+	EnableHealth bool
+	// 健康檢查配置
+	HealthConfig health.Config
+	// 是否為每個請求建立追蹤 span，實際是否匯出由 tracing 區塊決定
+	EnableTracing bool
 	// 是否啟用 CORS
 	EnableCORS bool
+	// CORS 配置
+	CORSConfig middleware.CORSConfig
+	// 是否記錄 Prometheus 指標並匯出於 MetricsPath
+	EnableMetrics bool
+	// 指標端點路徑
+	MetricsPath string `validate:"required_if=EnableMetrics true"`
 	// 是否啟用請求日誌
 	EnableRequestLog bool
 	// 是否啟用錯誤處理
 	EnableErrorHandler bool
+	// 請求處理期限（秒），到期時 context 會被取消並回應 504，0 表示不限制；路由可再以 resilience.Timeout 縮短
+	RequestTimeout int `validate:"min=0"`
 	// 是否啟用速率限制
 	EnableRateLimit bool
-	// 速率限制配置
+	// 速率限制配置，在 JWT 中間件之前執行，因此不支援 keyBy: user
 	RateLimitConfig middleware.RateLimitConfig
+	// 具名的速率限制策略，由處理器掛在單一路由上，名稱一律為小寫
+	RateLimitPolicies map[string]middleware.RateLimitConfig `validate:"dive"`
+	// 冪等請求配置，由處理器掛在建立資源的路由上
+	IdempotencyConfig middleware.IdempotencyConfig
 }
 
 // DefaultRouterConfig 返回默認路由配置
 func DefaultRouterConfig() *RouterConfig {
 	return &RouterConfig{
 		APIVersion:         "v1",
+		EnableRecovery:     true,
+		EnableHealth:       true,
+		EnableTracing:      true,
+		EnableMetrics:      true,
+		MetricsPath:        "/metrics",
 		EnableCORS:         true,
 		EnableRequestLog:   true,
 		EnableErrorHandler: true,
+		RequestTimeout:     10,
 		EnableRateLimit:    true,
+		VersioningConfig: versioning.Config{
+			PathPrefix: "/api",
+			MediaType:  "application/vnd.slack-clone",
+		},
+		CORSConfig: middleware.CORSConfig{
+			AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+			AllowHeaders: []string{"Authorization", "Content-Type", middleware.IdempotencyKeyHeader},
+			ExposeHeaders: []string{middleware.RequestIDHeader, middleware.IdempotentReplayedHeader,
+				versioning.VersionHeader, versioning.DeprecationHeader, versioning.SunsetHeader, "Link"},
+			MaxAge: 600,
+		},
+		HealthConfig: health.Config{
+			LivenessPath:  "/healthz",
+			ReadinessPath: "/readyz",
+			Timeout:       2,
+			CacheTTL:      5,
+		},
 		RateLimitConfig: middleware.RateLimitConfig{
 			RequestsPerSecond: 100,
 			Burst:             200,
+			KeyBy:             middleware.KeyByIP,
+			APIKeyHeader:      "X-API-Key",
+			IdleTimeout:       600,
+			Backend:           middleware.BackendLocal,
+			FailureMode:       middleware.FailureModeLocal,
+		},
+		IdempotencyConfig: middleware.IdempotencyConfig{
+			TTL:          86400,
+			LockTimeout:  60,
+			MaxBodyBytes: 1 << 20,
 		},
 	}
 }
 
-// NewGinEngine 應用路由配置
-func NewGinEngine(config *RouterConfig) *gin.Engine {
+// NewGinEngine 應用路由配置，傳入 watcher 時速率限制會隨配置熱更新，client 供 redis 限流使用，registry 提供健康檢查端點
+func NewGinEngine(config *RouterConfig, watcher *Watcher, client *redis.Client, registry *health.Registry) (*gin.Engine, error) {
 	engine := gin.New()
+	// gin.Context 作為 context 傳遞時可取得 c.Request.Context() 中的值，如請求日誌
+	engine.ContextWithFallback = true
+	// c.ClientIP() 只採用信任代理送來的 X-Forwarded-For
+	if err := engine.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, err
+	}
+	// binding 使用的自訂驗證規則需在處理請求前註冊
+	validation.Default()
+
+	// 設置 panic 恢復，放在最外層以涵蓋所有中間件
+	if config.EnableRecovery {
+		engine.Use(gin.Recovery())
+	}
+
+	// 設置健康檢查端點，放在其他中間件之前，探針不會被追蹤、記錄或限流
+	if config.EnableHealth && registry != nil {
+		engine.GET(config.HealthConfig.LivenessPath, registry.LivenessHandler())
+		engine.GET(config.HealthConfig.ReadinessPath, registry.ReadinessHandler())
+	}
 
-	// 設置模式
+	// 設置追蹤，放在請求日誌之前，存取日誌才會帶有 trace_id
+	if config.EnableTracing {
+		engine.Use(observability.Middleware())
+	}
+
+	// 設置指標，放在 CORS 與限流之前，被拒絕的請求也會計入
+	if config.EnableMetrics {
+		engine.Use(metrics.Middleware())
+		engine.GET(config.MetricsPath, gin.WrapH(metrics.Handler()))
+	}
+
+	// 設置請求日誌，放在其他中間件之前，被拒絕的請求也會記錄並帶有 X-Request-ID
 	if config.EnableRequestLog {
-		engine.Use(gin.Logger())
+		engine.Use(middleware.RequestLogger())
 	}
 
-	// 設置 CORS
+	// 設置 CORS，放在速率限制之前，被拒絕的回應也帶有 CORS 標頭讓前端讀取
 	if config.EnableCORS {
-		engine.Use(gin.Recovery())
+		engine.Use(middleware.CORS(config.CORSConfig))
 	}
 
 	// 設置錯誤處理
@@ -56,10 +153,48 @@ func NewGinEngine(config *RouterConfig) *gin.Engine {
 		engine.Use(middleware.ErrorHandler())
 	}
 
+	// 設置請求期限，放在錯誤處理之內，逾時的回應與日誌由錯誤處理統一產生
+	if config.RequestTimeout > 0 {
+		engine.Use(resilience.Timeout(time.Duration(config.RequestTimeout) * time.Second))
+	}
+
 	// 設置速率限制
 	if config.EnableRateLimit {
-		engine.Use(middleware.RateLimiter(config.RateLimitConfig))
+		// backend 熱更新為 local 或 redis 時重建限流器
+		limiter := middleware.NewReloadableLimiter(config.RateLimitConfig, func(config middleware.RateLimitConfig) middleware.Limiter {
+			return middleware.NewLimiter(config, client)
+		})
+		if watcher != nil {
+			Subscribe(watcher, func(c *Config) middleware.RateLimitConfig { return c.Router.RateLimitConfig },
+				func(change Change[middleware.RateLimitConfig]) { limiter.Update(change.New) })
+		}
+		engine.Use(limiter.Middleware())
 	}
 
-	return engine
+	return engine, nil
+}
+
+// NewHealthRegistry 依路由配置創建健康檢查註冊表，各模組在此註冊依賴檢查
+func NewHealthRegistry(config *RouterConfig) *health.Registry {
+	return health.NewRegistry(config.HealthConfig)
+}
+
+// NewRateLimitPolicies 依路由配置創建具名的速率限制策略，傳入 watcher 時隨配置熱更新
+func NewRateLimitPolicies(config *RouterConfig, watcher *Watcher, client *redis.Client) *middleware.RateLimitPolicies {
+	policies := middleware.NewRateLimitPolicies(config.RateLimitPolicies, client)
+	if watcher != nil {
+		Subscribe(watcher, func(c *Config) map[string]middleware.RateLimitConfig { return c.Router.RateLimitPolicies },
+			func(change Change[map[string]middleware.RateLimitConfig]) { policies.Update(change.New) })
+	}
+	return policies
+}
+
+// NewIdempotency 依路由配置創建冪等請求中間件，紀錄存放於 client
+func NewIdempotency(config *RouterConfig, client *redis.Client) *middleware.Idempotency {
+	return middleware.NewIdempotency(config.IdempotencyConfig, client)
+}
+
+// NewVersioning 依路由配置創建 API 版本註冊表，APIVersion 為預設版本
+func NewVersioning(config *RouterConfig) (*versioning.Registry, error) {
+	return versioning.New(config.APIVersion, config.VersioningConfig)
 }
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedValue 機密欄位在日誌與輸出中的替代值
+const RedactedValue = "******"
+
+const (
+	// SecretSchemeFile 讀取檔案內容，如 file:///run/secrets/jwt
+	SecretSchemeFile = "file"
+	// SecretSchemeEnv 讀取環境變數，如 env://DB_PASSWORD
+	SecretSchemeEnv = "env"
+	// SecretSchemeVault 讀取 Vault 相容的 KV API，如 vault://secret/data/user-service#jwtSecretKey
+	SecretSchemeVault = "vault"
+)
+
+// defaultVaultTimeout Vault 請求逾時時間
+const defaultVaultTimeout = 5 * time.Second
+
+// ErrSecretNotFound 參照的機密資訊不存在
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider 依參照（不含 scheme://）取得機密資訊
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// FileSecretProvider 讀取檔案內容作為機密資訊，如 Docker / Kubernetes secrets
+type FileSecretProvider struct{}
+
+// Resolve 讀取檔案並移除結尾換行
+func (FileSecretProvider) Resolve(_ context.Context, ref string) (string, error) {
+	content, err := os.ReadFile(ref)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// EnvSecretProvider 讀取環境變數作為機密資訊
+type EnvSecretProvider struct{}
+
+// Resolve 讀取環境變數，未設定時回傳 ErrSecretNotFound
+func (EnvSecretProvider) Resolve(_ context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+// VaultSecretProvider 讀取 Vault 相容的 KV API，同時支援 KV v1 與 v2 的回應格式
+type VaultSecretProvider struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewVaultSecretProvider 創建新的 Vault 機密資訊來源
+func NewVaultSecretProvider(address, token string) *VaultSecretProvider {
+	return &VaultSecretProvider{
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: defaultVaultTimeout},
+	}
+}
+
+// Resolve 參照格式為 <path>#<key>，如 secret/data/user-service#jwtSecretKey
+func (p *VaultSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault reference must be <path>#<key>")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrSecretNotFound
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault responded with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	// KV v2 將資料包在 data.data 中，KV v1 則直接放在 data
+	data := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		var v2 map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &v2); err == nil {
+			data = v2
+		}
+	}
+
+	raw, ok := data[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("vault key %s is not a string", key)
+	}
+	return value, nil
+}
+
+// cachedSecret 快取的機密資訊
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// SecretResolver 依 scheme 分派參照至對應的 SecretProvider，並快取解析結果
+type SecretResolver struct {
+	providers map[string]SecretProvider
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+// NewSecretResolver 創建新的機密資訊解析器，配置 Vault 位址時才會註冊 vault://
+func NewSecretResolver(cfg SecretsConfig) (*SecretResolver, error) {
+	r := &SecretResolver{
+		providers: map[string]SecretProvider{
+			SecretSchemeFile: FileSecretProvider{},
+			SecretSchemeEnv:  EnvSecretProvider{},
+		},
+		ttl:   time.Duration(cfg.CacheTTL) * time.Second,
+		cache: make(map[string]cachedSecret),
+	}
+
+	if cfg.VaultAddress != "" {
+		// Vault token 本身也可以是 file:// 或 env:// 參照
+		token, err := r.Resolve(context.Background(), cfg.VaultToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve vault token: %w", err)
+		}
+		r.Register(SecretSchemeVault, NewVaultSecretProvider(cfg.VaultAddress, token))
+	}
+	return r, nil
+}
+
+// Register 註冊或取代 scheme 對應的 SecretProvider
+func (r *SecretResolver) Register(scheme string, provider SecretProvider) {
+	r.providers[scheme] = provider
+}
+
+// Resolve 解析參照，未註冊 scheme 的值視為明文直接回傳
+func (r *SecretResolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	cached, ok := r.cache[value]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	secret, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		// 錯誤訊息只包含參照，不包含機密資訊
+		return "", fmt.Errorf("%s://%s: %w", scheme, ref, err)
+	}
+
+	if r.ttl > 0 {
+		r.mu.Lock()
+		r.cache[value] = cachedSecret{value: secret, expiresAt: now.Add(r.ttl)}
+		r.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// ResolveSecrets 解析所有標記 secret:"true" 的欄位
+func ResolveSecrets(ctx context.Context, cfg *Config, resolver *SecretResolver) error {
+	var errs []error
+	walkSecrets(reflect.ValueOf(cfg).Elem(), "", func(field string, value reflect.Value) {
+		secret, err := resolver.Resolve(ctx, value.String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve secret %s: %w", field, err))
+			return
+		}
+		value.SetString(secret)
+	})
+	return errors.Join(errs...)
+}
+
+// Redact 回傳機密欄位已遮蔽的配置副本，用於日誌與輸出
+func Redact(cfg *Config) *Config {
+	redacted := *cfg
+	walkSecrets(reflect.ValueOf(&redacted).Elem(), "", func(_ string, value reflect.Value) {
+		if value.String() != "" {
+			value.SetString(RedactedValue)
+		}
+	})
+	return &redacted
+}
+
+// IsSecretField 判斷欄位路徑（如 JWT.SecretKey）是否為機密欄位
+func IsSecretField(field string) bool {
+	found := false
+	walkSecrets(reflect.ValueOf(Config{}), "", func(name string, _ reflect.Value) {
+		if name == field {
+			found = true
+		}
+	})
+	return found
+}
+
+// walkSecrets 走訪標記 secret:"true" 的字串欄位，欄位路徑以結構體欄位名稱串接，如 JWT.SecretKey
+func walkSecrets(value reflect.Value, prefix string, fn func(field string, value reflect.Value)) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := prefix + field.Name
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			walkSecrets(value.Field(i), name+".", fn)
+		case field.Type.Kind() == reflect.String && field.Tag.Get("secret") == "true":
+			fn(name, value.Field(i))
+		}
+	}
+}
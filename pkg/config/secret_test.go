@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVaultServer starts a Vault-compatible KV stand-in that serves secrets under path
+// and counts the requests it receives.
+func newVaultServer(t *testing.T, token, path string, secrets map[string]string, v2 bool) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/"+path {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body interface{} = map[string]interface{}{"data": secrets}
+		if v2 {
+			body = map[string]interface{}{"data": map[string]interface{}{
+				"data":     secrets,
+				"metadata": map[string]interface{}{"version": 1},
+			}}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestSecretResolver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Plain values and unknown schemes pass through", func(t *testing.T) {
+		resolver, err := NewSecretResolver(SecretsConfig{})
+		require.NoError(t, err)
+
+		for _, value := range []string{"", "plain-password", "postgres://user@host/db"} {
+			resolved, err := resolver.Resolve(ctx, value)
+			require.NoError(t, err)
+			assert.Equal(t, value, resolved)
+		}
+	})
+
+	t.Run("File and env references", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwt")
+		require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+		t.Setenv("TEST_DB_PASSWORD", "from-env")
+
+		resolver, err := NewSecretResolver(SecretsConfig{})
+		require.NoError(t, err)
+
+		resolved, err := resolver.Resolve(ctx, "file://"+path)
+		require.NoError(t, err)
+		assert.Equal(t, "from-file", resolved)
+
+		resolved, err = resolver.Resolve(ctx, "env://TEST_DB_PASSWORD")
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", resolved)
+
+		_, err = resolver.Resolve(ctx, "env://TEST_MISSING_SECRET")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("Vault KV v1 and v2", func(t *testing.T) {
+		for _, v2 := range []bool{false, true} {
+			server, _ := newVaultServer(t, "vault-token", "secret/data/user-service",
+				map[string]string{"jwtSecretKey": "from-vault"}, v2)
+			t.Setenv("TEST_VAULT_TOKEN", "vault-token")
+
+			resolver, err := NewSecretResolver(SecretsConfig{VaultAddress: server.URL, VaultToken: "env://TEST_VAULT_TOKEN"})
+			require.NoError(t, err)
+
+			resolved, err := resolver.Resolve(ctx, "vault://secret/data/user-service#jwtSecretKey")
+			require.NoError(t, err)
+			assert.Equal(t, "from-vault", resolved)
+
+			_, err = resolver.Resolve(ctx, "vault://secret/data/user-service#missing")
+			assert.ErrorIs(t, err, ErrSecretNotFound)
+		}
+	})
+
+	t.Run("Vault rejects wrong token", func(t *testing.T) {
+		server, _ := newVaultServer(t, "vault-token", "secret/data/user-service", map[string]string{"k": "v"}, true)
+
+		resolver, err := NewSecretResolver(SecretsConfig{VaultAddress: server.URL, VaultToken: "wrong"})
+		require.NoError(t, err)
+
+		_, err = resolver.Resolve(ctx, "vault://secret/data/user-service#k")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("Caches resolved values", func(t *testing.T) {
+		server, requests := newVaultServer(t, "vault-token", "secret/data/user-service", map[string]string{"k": "v"}, true)
+
+		cached, err := NewSecretResolver(SecretsConfig{VaultAddress: server.URL, VaultToken: "vault-token", CacheTTL: 60})
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err := cached.Resolve(ctx, "vault://secret/data/user-service#k")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+		uncached, err := NewSecretResolver(SecretsConfig{VaultAddress: server.URL, VaultToken: "vault-token"})
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err := uncached.Resolve(ctx, "vault://secret/data/user-service#k")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+}
+
+func TestLoadResolvesSecrets(t *testing.T) {
+	t.Run("References are replaced by secret values", func(t *testing.T) {
+		secretFile := filepath.Join(t.TempDir(), "jwt")
+		require.NoError(t, os.WriteFile(secretFile, []byte("jwt-secret-from-file-0123"), 0o600))
+		t.Setenv("TEST_DB_PASSWORD", "db-password-from-env")
+
+		content := strings.Replace(baseConfig, `password: "from-file"`, `password: "env://TEST_DB_PASSWORD"`, 1)
+		content = strings.Replace(content, `secretKey: "test-secret-key-0123456789"`, `secretKey: "file://`+secretFile+`"`, 1)
+		dir := writeConfig(t, t.TempDir(), "config.yaml", content)
+
+		cfg, err := Load(LoadOptions{Paths: []string{dir}})
+		require.NoError(t, err)
+		assert.Equal(t, "db-password-from-env", cfg.Database.Password)
+		assert.Equal(t, "jwt-secret-from-file-0123", cfg.JWT.SecretKey)
+	})
+
+	t.Run("Unresolvable reference fails without leaking values", func(t *testing.T) {
+		content := strings.Replace(baseConfig, `password: "from-file"`, `password: "env://TEST_MISSING_SECRET"`, 1)
+		dir := writeConfig(t, t.TempDir(), "config.yaml", content)
+
+		_, err := Load(LoadOptions{Paths: []string{dir}})
+		require.ErrorIs(t, err, ErrSecretNotFound)
+		assert.Contains(t, err.Error(), "Database.Password")
+	})
+
+	t.Run("Validation errors redact secret values", func(t *testing.T) {
+		content := strings.Replace(baseConfig, `secretKey: "test-secret-key-0123456789"`, `secretKey: "too-short"`, 1)
+		dir := writeConfig(t, t.TempDir(), "config.yaml", content)
+
+		_, err := Load(LoadOptions{Paths: []string{dir}})
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, RedactedValue, validationErr.Fields[0].Value)
+		assert.NotContains(t, err.Error(), "too-short")
+	})
+
+	t.Run("Watcher picks up rotated secrets on reload", func(t *testing.T) {
+		secretFile := filepath.Join(t.TempDir(), "db-password")
+		require.NoError(t, os.WriteFile(secretFile, []byte("old-password"), 0o600))
+
+		content := strings.Replace(baseConfig, `password: "from-file"`, `password: "file://`+secretFile+`"`, 1)
+		dir := writeConfig(t, t.TempDir(), "config.yaml", content+"secrets:\n  cacheTTL: 0\n")
+
+		watcher, err := NewWatcher(LoadOptions{Paths: []string{dir}})
+		require.NoError(t, err)
+		var changes []Change[string]
+		Subscribe(watcher, func(c *Config) string { return c.Database.Password }, func(change Change[string]) {
+			changes = append(changes, change)
+		})
+
+		require.NoError(t, os.WriteFile(secretFile, []byte("new-password"), 0o600))
+		require.NoError(t, watcher.Reload())
+		assert.Equal(t, []Change[string]{{Old: "old-password", New: "new-password"}}, changes)
+	})
+}
+
+func TestRedact(t *testing.T) {
+	cfg := Defaults()
+	cfg.Database.Password = "db-password"
+	cfg.JWT.SecretKey = "jwt-secret"
+
+	redacted := Redact(cfg)
+	assert.Equal(t, RedactedValue, redacted.Database.Password)
+	assert.Equal(t, RedactedValue, redacted.JWT.SecretKey)
+	assert.Empty(t, redacted.Redis.Password, "empty secrets stay empty")
+	assert.Equal(t, cfg.Database.Port, redacted.Database.Port)
+	assert.Equal(t, "db-password", cfg.Database.Password, "original is not modified")
+}
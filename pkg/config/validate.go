@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FieldError 單一不合法的配置欄位
+type FieldError struct {
+	// 欄位路徑，如 Database.Host
+	Field string
+	// 未通過的規則，如 required、min=1
+	Rule string
+	// 實際的值，機密欄位會以 RedactedValue 取代
+	Value interface{}
+}
+
+// ValidationError 配置驗證錯誤，包含所有不合法的欄位
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error 實作 error 介面，每個欄位一行
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Fields)+1)
+	lines = append(lines, fmt.Sprintf("invalid config: %d field(s) failed validation", len(e.Fields)))
+	for _, field := range e.Fields {
+		lines = append(lines, fmt.Sprintf("  - %s: must satisfy %q (got %v)", field.Field, field.Rule, field.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate 依 validate struct tag 驗證所有配置區塊
+func Validate(cfg *Config) error {
+	validate := validator.New()
+	// ratelimit 請求速率格式，如 5/min
+	_ = validate.RegisterValidation("ratelimit", func(fl validator.FieldLevel) bool {
+		_, _, err := middleware.ParseRate(fl.Field().String())
+		return err == nil
+	})
+	// edprivatekey、edpublickey 為 PEM 格式的 Ed25519 金鑰，用於 service token
+	_ = validate.RegisterValidation("edprivatekey", func(fl validator.FieldLevel) bool {
+		_, err := jwt.ParseEdPrivateKeyFromPEM([]byte(fl.Field().String()))
+		return err == nil
+	})
+	_ = validate.RegisterValidation("edpublickey", func(fl validator.FieldLevel) bool {
+		_, err := jwt.ParseEdPublicKeyFromPEM([]byte(fl.Field().String()))
+		return err == nil
+	})
+	// 跨區塊規則：service token 私鑰不得沿用使用者 JWT 金鑰
+	validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		cfg := sl.Current().Interface().(Config)
+		if cfg.Service.PrivateKey != "" && cfg.Service.PrivateKey == cfg.JWT.SecretKey {
+			sl.ReportError(cfg.Service.PrivateKey, "Service.PrivateKey", "PrivateKey", "necsfield", "JWT.SecretKey")
+		}
+	}, Config{})
+	validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		router := sl.Current().Interface().(RouterConfig)
+		// 全域限流在 JWT 中間件之前執行，尚未取得 user_id，keyBy: user 只能用於具名策略
+		if router.RateLimitConfig.KeyBy == middleware.KeyByUser {
+			sl.ReportError(router.RateLimitConfig.KeyBy, "RateLimitConfig.KeyBy", "KeyBy", "ne", middleware.KeyByUser)
+		}
+		// 允許任意來源時不得攜帶憑證，否則任何網站都能以使用者的 cookie 發出請求並讀取回應
+		if router.CORSConfig.AllowCredentials && slices.Contains(router.CORSConfig.AllowOrigins, "*") {
+			sl.ReportError(router.CORSConfig.AllowOrigins, "CORSConfig.AllowOrigins", "AllowOrigins", "excluded_with", "AllowCredentials")
+		}
+	}, RouterConfig{})
+
+	err := validate.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		rule := fieldError.Tag()
+		if fieldError.Param() != "" {
+			rule += "=" + fieldError.Param()
+		}
+		field := strings.TrimPrefix(fieldError.Namespace(), "Config.")
+		value := fieldError.Value()
+		if IsSecretField(field) {
+			value = RedactedValue
+		}
+		fields = append(fields, FieldError{Field: field, Rule: rule, Value: value})
+	}
+	return &ValidationError{Fields: fields}
+}
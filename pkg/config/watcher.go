@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce 編輯器存檔時常連續觸發多個事件，合併為一次重新載入
+const reloadDebounce = 100 * time.Millisecond
+
+// Change 配置區塊的變更事件
+type Change[T any] struct {
+	Old T
+	New T
+}
+
+// Watcher 監聽配置檔變更並重新載入，新配置驗證通過後才會生效並通知訂閱者
+// 配置 secrets.refreshInterval 時也會定期重新載入，以取得輪替後的機密資訊
+type Watcher struct {
+	opts    LoadOptions
+	current atomic.Pointer[Config]
+
+	// reloadMu 確保重新載入與通知依序進行
+	reloadMu    sync.Mutex
+	mu          sync.Mutex
+	subscribers map[int]func(oldConfig, newConfig *Config)
+	nextID      int
+
+	fsWatcher *fsnotify.Watcher
+	ticker    *time.Ticker
+	done      chan struct{}
+}
+
+// NewWatcher 載入初始配置，驗證失敗時回傳錯誤
+func NewWatcher(opts LoadOptions) (*Watcher, error) {
+	opts = opts.withDefaults()
+	cfg, err := Load(opts)
+	if err != nil {
+		return nil, err
+	}
+	// 重新載入時共用同一個解析器，未過期的機密資訊不必重新讀取
+	if opts.Secrets == nil {
+		if opts.Secrets, err = NewSecretResolver(cfg.Secrets); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &Watcher{
+		opts:        opts,
+		subscribers: make(map[int]func(oldConfig, newConfig *Config)),
+	}
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// Config 獲取目前生效的配置，回傳值不應被修改
+func (w *Watcher) Config() *Config {
+	return w.current.Load()
+}
+
+// Subscribe 訂閱配置區塊的變更，selector 取出的值有變動時才會呼叫 fn，回傳取消訂閱的函數
+func Subscribe[T any](w *Watcher, selector func(*Config) T, fn func(Change[T])) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = func(oldConfig, newConfig *Config) {
+		oldValue, newValue := selector(oldConfig), selector(newConfig)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			fn(Change[T]{Old: oldValue, New: newValue})
+		}
+	}
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// Reload 重新載入配置，驗證失敗時保留目前的配置並回傳錯誤
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	newConfig, err := Load(w.opts)
+	if err != nil {
+		return err
+	}
+	oldConfig := w.current.Swap(newConfig)
+
+	w.mu.Lock()
+	subscribers := make([]func(oldConfig, newConfig *Config), 0, len(w.subscribers))
+	for _, subscriber := range w.subscribers {
+		subscribers = append(subscribers, subscriber)
+	}
+	w.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(oldConfig, newConfig)
+	}
+	return nil
+}
+
+// Start 開始監聽配置檔所在目錄
+func (w *Watcher) Start() error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// 監聽目錄而非檔案，才能收到編輯器以 rename 取代檔案的事件
+	for _, path := range w.opts.Paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if err := fsWatcher.Add(path); err != nil {
+				_ = fsWatcher.Close()
+				return err
+			}
+		}
+	}
+
+	var refresh <-chan time.Time
+	if interval := w.Config().Secrets.RefreshInterval; interval > 0 {
+		w.ticker = time.NewTicker(time.Duration(interval) * time.Second)
+		refresh = w.ticker.C
+	}
+
+	w.fsWatcher = fsWatcher
+	w.done = make(chan struct{})
+	go w.watch(refresh)
+	return nil
+}
+
+// Stop 停止監聽
+func (w *Watcher) Stop() error {
+	if w.fsWatcher == nil {
+		return nil
+	}
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	err := w.fsWatcher.Close()
+	<-w.done
+	return err
+}
+
+// watch 處理檔案事件與定期更新，檔案事件會合併短時間內的多個事件後重新載入
+func (w *Watcher) watch(refresh <-chan time.Time) {
+	defer close(w.done)
+
+	files := map[string]struct{}{w.opts.Name + ".yaml": {}}
+	if w.opts.Env != "" {
+		files[w.opts.Name+"."+w.opts.Env+".yaml"] = struct{}{}
+	}
+
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if _, watched := files[filepath.Base(event.Name)]; !watched || event.Has(fsnotify.Chmod) {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(reloadDebounce, func() {
+				if err := w.Reload(); err != nil {
+					logger.Error("config reload rejected, keeping current config", logger.Err(err))
+					return
+				}
+				logger.Info("config reloaded", logger.String("file", event.Name))
+			})
+		case <-refresh:
+			if err := w.Reload(); err != nil {
+				logger.Error("config refresh failed, keeping current config", logger.Err(err))
+			}
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("config watcher error", logger.Err(err))
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Run("Reload publishes typed changes", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		watcher, err := NewWatcher(LoadOptions{Paths: []string{dir}, Env: "test"})
+		require.NoError(t, err)
+
+		var levels []Change[string]
+		Subscribe(watcher, func(c *Config) string { return c.Log.Level }, func(change Change[string]) {
+			levels = append(levels, change)
+		})
+		var rateLimits []Change[middleware.RateLimitConfig]
+		Subscribe(watcher, func(c *Config) middleware.RateLimitConfig { return c.Router.RateLimitConfig },
+			func(change Change[middleware.RateLimitConfig]) { rateLimits = append(rateLimits, change) })
+
+		writeConfig(t, dir, "config.test.yaml", "log:\n  level: \"debug\"\n")
+		require.NoError(t, watcher.Reload())
+
+		assert.Equal(t, []Change[string]{{Old: "info", New: "debug"}}, levels)
+		assert.Empty(t, rateLimits, "unchanged sections are not published")
+		assert.Equal(t, "debug", watcher.Config().Log.Level)
+	})
+
+	t.Run("Invalid config keeps current config", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		watcher, err := NewWatcher(LoadOptions{Paths: []string{dir}, Env: "test"})
+		require.NoError(t, err)
+
+		called := false
+		Subscribe(watcher, func(c *Config) string { return c.Log.Level }, func(Change[string]) { called = true })
+
+		writeConfig(t, dir, "config.test.yaml", "log:\n  level: \"verbose\"\n")
+		var validationErr *ValidationError
+		require.ErrorAs(t, watcher.Reload(), &validationErr)
+
+		assert.False(t, called)
+		assert.Equal(t, "info", watcher.Config().Log.Level)
+	})
+
+	t.Run("Unsubscribe stops notifications", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		watcher, err := NewWatcher(LoadOptions{Paths: []string{dir}, Env: "test"})
+		require.NoError(t, err)
+
+		called := false
+		unsubscribe := Subscribe(watcher, func(c *Config) string { return c.Log.Level }, func(Change[string]) { called = true })
+		unsubscribe()
+
+		writeConfig(t, dir, "config.test.yaml", "log:\n  level: \"warn\"\n")
+		require.NoError(t, watcher.Reload())
+		assert.False(t, called)
+	})
+
+	t.Run("File change triggers reload", func(t *testing.T) {
+		dir := writeConfig(t, t.TempDir(), "config.yaml", baseConfig)
+		watcher, err := NewWatcher(LoadOptions{Paths: []string{dir}, Env: "test"})
+		require.NoError(t, err)
+
+		changes := make(chan Change[int], 1)
+		Subscribe(watcher, func(c *Config) int { return c.Router.RateLimitConfig.Burst }, func(change Change[int]) {
+			changes <- change
+		})
+		require.NoError(t, watcher.Start())
+		defer func() { require.NoError(t, watcher.Stop()) }()
+
+		writeConfig(t, dir, "config.test.yaml", "router:\n  rateLimitConfig:\n    burst: 10\n")
+
+		select {
+		case change := <-changes:
+			assert.Equal(t, Change[int]{Old: 200, New: 10}, change)
+		case <-time.After(5 * time.Second):
+			t.Fatal("config was not reloaded")
+		}
+	})
+}
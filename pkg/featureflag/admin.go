@@ -0,0 +1,109 @@
+package featureflag
+
+import (
+	"net/http"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionManage 管理功能旗標所需的權限
+const PermissionManage = "feature_flag:manage"
+
+// FlagRequest 新增或更新旗標的請求
+type FlagRequest struct {
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Rollout     int      `json:"rollout" binding:"min=0,max=100"`
+	Roles       []string `json:"roles"`
+	Workspaces  []string `json:"workspaces"`
+	Users       []uint   `json:"users"`
+}
+
+// AdminHandler 執行期間管理功能旗標的 API，權限檢查由呼叫端的路由群組負責
+type AdminHandler struct {
+	store Store
+}
+
+// NewAdminHandler 創建新的旗標管理處理器實例
+func NewAdminHandler(store Store) *AdminHandler {
+	return &AdminHandler{store: store}
+}
+
+// RegisterRoutes 在 RouterGroup 下註冊 /feature-flags 管理路由
+func (h *AdminHandler) RegisterRoutes(e *gin.RouterGroup) {
+	flagGroup := e.Group("/feature-flags")
+	{
+		flagGroup.GET("", h.ListFlags)
+		flagGroup.GET("/:key", h.GetFlag)
+		flagGroup.PUT("/:key", h.SaveFlag)
+		flagGroup.DELETE("/:key", h.DeleteFlag)
+	}
+}
+
+// ListFlags 列出所有旗標
+func (h *AdminHandler) ListFlags(c *gin.Context) {
+	flags, err := h.store.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
+
+// GetFlag 獲取單一旗標
+func (h *AdminHandler) GetFlag(c *gin.Context) {
+	flag, err := h.store.Get(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// SaveFlag 新增或整筆取代旗標，立即生效
+func (h *AdminHandler) SaveFlag(c *gin.Context) {
+	var req FlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	flag := &Flag{
+		Key:         c.Param("key"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Rollout:     req.Rollout,
+		Roles:       req.Roles,
+		Workspaces:  req.Workspaces,
+		Users:       req.Users,
+	}
+	if err := h.store.Save(c.Request.Context(), flag); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	logger.Info("feature flag updated",
+		logger.String("key", flag.Key),
+		logger.Int("rollout", flag.Rollout),
+		logger.Int("updated_by", int(c.GetUint("user_id"))),
+	)
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag 刪除旗標，刪除後視為關閉
+func (h *AdminHandler) DeleteFlag(c *gin.Context) {
+	key := c.Param("key")
+	if err := h.store.Delete(c.Request.Context(), key); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	logger.Info("feature flag deleted",
+		logger.String("key", key),
+		logger.Int("updated_by", int(c.GetUint("user_id"))),
+	)
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,54 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+)
+
+// Evaluator 供 service 層評估旗標
+type Evaluator struct {
+	store Store
+}
+
+// NewEvaluator 創建新的旗標評估器
+func NewEvaluator(store Store) *Evaluator {
+	return &Evaluator{store: store}
+}
+
+// Evaluate 評估旗標是否對 subject 啟用，未定義的旗標視為關閉
+func (e *Evaluator) Evaluate(ctx context.Context, key string, subject Subject) (bool, error) {
+	flag, err := e.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return flag.Evaluate(subject), nil
+}
+
+// IsEnabled 評估旗標是否對 subject 啟用，無法讀取旗標時記錄錯誤並視為關閉
+func (e *Evaluator) IsEnabled(ctx context.Context, key string, subject Subject) bool {
+	enabled, err := e.Evaluate(ctx, key, subject)
+	if err != nil {
+		logger.Error("failed to evaluate feature flag", logger.String("key", key), logger.Err(err))
+		return false
+	}
+	return enabled
+}
+
+// EvaluateAll 評估所有旗標，供前端決定要顯示的功能
+func (e *Evaluator) EvaluateAll(ctx context.Context, subject Subject) (map[string]bool, error) {
+	flags, err := e.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		result[flag.Key] = flag.Evaluate(subject)
+	}
+	return result, nil
+}
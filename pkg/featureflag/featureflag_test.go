@@ -0,0 +1,91 @@
+package featureflag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     Flag
+		subject  Subject
+		expected bool
+	}{
+		{"Disabled flag", Flag{Key: "threads", Rollout: 100}, Subject{UserID: 1}, false},
+		{"Fully rolled out", Flag{Key: "threads", Enabled: true, Rollout: 100}, Subject{UserID: 1}, true},
+		{"Zero rollout", Flag{Key: "threads", Enabled: true}, Subject{UserID: 1}, false},
+		{"Allowed user bypasses rollout", Flag{Key: "threads", Enabled: true, Users: []uint{1}}, Subject{UserID: 1}, true},
+		{"Allowed user ignored when disabled", Flag{Key: "threads", Users: []uint{1}}, Subject{UserID: 1}, false},
+		{"Matching role", Flag{Key: "threads", Enabled: true, Rollout: 100, Roles: []string{"admin"}}, Subject{UserID: 1, Role: "admin"}, true},
+		{"Other role", Flag{Key: "threads", Enabled: true, Rollout: 100, Roles: []string{"admin"}}, Subject{UserID: 1, Role: "user"}, false},
+		{"Matching workspace", Flag{Key: "threads", Enabled: true, Rollout: 100, Workspaces: []string{"acme"}}, Subject{UserID: 1, WorkspaceID: "acme"}, true},
+		{"Other workspace", Flag{Key: "threads", Enabled: true, Rollout: 100, Workspaces: []string{"acme"}}, Subject{UserID: 1, WorkspaceID: "other"}, false},
+		{"Anonymous with partial rollout", Flag{Key: "threads", Enabled: true, Rollout: 99}, Subject{}, false},
+		{"Anonymous with full rollout", Flag{Key: "threads", Enabled: true, Rollout: 100}, Subject{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.flag.Evaluate(tt.subject))
+		})
+	}
+}
+
+func TestRollout(t *testing.T) {
+	t.Run("Stable per user", func(t *testing.T) {
+		flag := Flag{Key: "passkeys", Enabled: true, Rollout: 50}
+		for userID := uint(1); userID <= 100; userID++ {
+			first := flag.Evaluate(Subject{UserID: userID})
+			for i := 0; i < 3; i++ {
+				assert.Equal(t, first, flag.Evaluate(Subject{UserID: userID}))
+			}
+		}
+	})
+
+	t.Run("Increasing rollout keeps enabled users", func(t *testing.T) {
+		for userID := uint(1); userID <= 1000; userID++ {
+			small := Flag{Key: "passkeys", Enabled: true, Rollout: 10}
+			large := Flag{Key: "passkeys", Enabled: true, Rollout: 30}
+			if small.Evaluate(Subject{UserID: userID}) {
+				assert.True(t, large.Evaluate(Subject{UserID: userID}))
+			}
+		}
+	})
+
+	t.Run("Roughly matches percentage", func(t *testing.T) {
+		flag := Flag{Key: "passkeys", Enabled: true, Rollout: 20}
+		enabled := 0
+		for userID := uint(1); userID <= 10000; userID++ {
+			if flag.Evaluate(Subject{UserID: userID}) {
+				enabled++
+			}
+		}
+		assert.InDelta(t, 2000, enabled, 300)
+	})
+}
+
+func TestEvaluator(t *testing.T) {
+	ctx := context.Background()
+	evaluator := NewEvaluator(NewMemoryStore(
+		&Flag{Key: "threads", Enabled: true, Rollout: 100},
+		&Flag{Key: "passkeys", Enabled: true, Roles: []string{"admin"}, Rollout: 100},
+	))
+
+	enabled, err := evaluator.Evaluate(ctx, "threads", Subject{UserID: 1})
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = evaluator.Evaluate(ctx, "undefined", Subject{UserID: 1})
+	require.NoError(t, err)
+	assert.False(t, enabled, "undefined flags are off")
+
+	assert.False(t, evaluator.IsEnabled(ctx, "passkeys", Subject{UserID: 1, Role: "user"}))
+
+	all, err := evaluator.EvaluateAll(ctx, Subject{UserID: 1, Role: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"threads": true, "passkeys": true}, all)
+}
@@ -0,0 +1,33 @@
+package featureflag
+
+import (
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SubjectFromContext 從 JWT 中間件設定的 user_id、role 與目前的工作區建立評估對象
+// 工作區只取自已確認成員身分的中間件所設定的 workspace_id，不信任用戶端傳入的 header
+func SubjectFromContext(c *gin.Context) Subject {
+	return Subject{
+		UserID:      c.GetUint("user_id"),
+		Role:        c.GetString("role"),
+		WorkspaceID: c.GetString("workspace_id"),
+	}
+}
+
+// RequireFlag 旗標未對目前使用者啟用時回傳 404，尚未公開的功能不洩漏其存在
+// 需放在 JWT 中間件之後
+func RequireFlag(evaluator *Evaluator, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enabled, err := evaluator.Evaluate(c.Request.Context(), key, SubjectFromContext(c))
+		if err != nil {
+			middleware.AbortWithError(c, ErrUnavailable.Wrap(err))
+			return
+		}
+		if !enabled {
+			middleware.AbortWithError(c, ErrDisabled)
+			return
+		}
+		c.Next()
+	}
+}
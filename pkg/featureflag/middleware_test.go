@@ -0,0 +1,108 @@
+package featureflag
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setUser simulates the JWT middleware.
+func setUser(userID uint, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("role", role)
+	}
+}
+
+func TestRequireFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	evaluator := NewEvaluator(NewMemoryStore(
+		&Flag{Key: "threads", Enabled: true, Rollout: 100, Workspaces: []string{"acme"}},
+	))
+
+	perform := func(role, workspace, header string) int {
+		router := gin.New()
+		// Simulates a membership-checked middleware setting the workspace.
+		setWorkspace := func(c *gin.Context) {
+			if workspace != "" {
+				c.Set("workspace_id", workspace)
+			}
+		}
+		router.GET("/threads", setUser(1, role), setWorkspace, RequireFlag(evaluator, "threads"), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/threads", nil)
+		if header != "" {
+			req.Header.Set("X-Workspace-ID", header)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, perform("user", "acme", ""))
+	assert.Equal(t, http.StatusNotFound, perform("user", "other", ""))
+	assert.Equal(t, http.StatusNotFound, perform("user", "", ""))
+	assert.Equal(t, http.StatusNotFound, perform("user", "", "acme"), "the client header is not trusted")
+}
+
+func TestAdminHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryStore()
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	NewAdminHandler(store).RegisterRoutes(router.Group("/admin", setUser(1, "admin")))
+
+	perform := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&payload).Encode(body))
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, &payload)
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Save and read", func(t *testing.T) {
+		w := perform(http.MethodPut, "/admin/feature-flags/threads", FlagRequest{Enabled: true, Rollout: 25})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = perform(http.MethodGet, "/admin/feature-flags/threads", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var flag Flag
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &flag))
+		assert.Equal(t, "threads", flag.Key)
+		assert.Equal(t, 25, flag.Rollout)
+
+		w = perform(http.MethodGet, "/admin/feature-flags", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var flags []Flag
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &flags))
+		assert.Len(t, flags, 1)
+	})
+
+	t.Run("Invalid rollout", func(t *testing.T) {
+		w := perform(http.MethodPut, "/admin/feature-flags/threads", FlagRequest{Rollout: 101})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		w := perform(http.MethodDelete, "/admin/feature-flags/threads", nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = perform(http.MethodGet, "/admin/feature-flags/threads", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = perform(http.MethodDelete, "/admin/feature-flags/threads", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
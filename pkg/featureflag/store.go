@@ -0,0 +1,278 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/go-redis/redis/v8"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	// cacheKeyPrefix 單一旗標的 Redis key 前綴
+	cacheKeyPrefix = "featureflag:flag:"
+	// cacheKeyList 旗標列表的 Redis key
+	cacheKeyList = "featureflag:list"
+	// cacheKeyVersion 快取版本，每次變更遞增，讀取期間版本改變時不寫回快取
+	cacheKeyVersion = "featureflag:version"
+)
+
+// writeCacheScript 只有在版本與讀取底層儲存前相同時才寫入快取，避免讀取期間的變更被舊值覆蓋
+var writeCacheScript = redis.NewScript(`
+local version = redis.call('GET', KEYS[1]) or ''
+if version ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// MemoryStore 以記憶體儲存旗標，適用於單一實例或測試
+type MemoryStore struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// NewMemoryStore 創建新的記憶體旗標儲存
+func NewMemoryStore(flags ...*Flag) *MemoryStore {
+	s := &MemoryStore{flags: make(map[string]Flag, len(flags))}
+	for _, flag := range flags {
+		s.flags[flag.Key] = *flag
+	}
+	return s
+}
+
+// NewFileStore 從 YAML 檔案載入旗標，執行期間的變更不會寫回檔案
+func NewFileStore(path string) (*MemoryStore, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feature flags: %w", err)
+	}
+
+	var file struct {
+		Flags []*Flag `yaml:"flags"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse feature flags: %w", err)
+	}
+	return NewMemoryStore(file.Flags...), nil
+}
+
+// Get 獲取旗標
+func (s *MemoryStore) Get(_ context.Context, key string) (*Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flag, ok := s.flags[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &flag, nil
+}
+
+// List 列出所有旗標，依名稱排序
+func (s *MemoryStore) List(_ context.Context) ([]*Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make([]*Flag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		flag := flag
+		flags = append(flags, &flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
+// Save 新增或更新旗標
+func (s *MemoryStore) Save(_ context.Context, flag *Flag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flag.UpdatedAt = time.Now()
+	s.flags[flag.Key] = *flag
+	return nil
+}
+
+// Delete 刪除旗標
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.flags[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.flags, key)
+	return nil
+}
+
+// PostgresStore 以 PostgreSQL 儲存旗標
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore 創建新的 PostgreSQL 旗標儲存
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Get 獲取旗標
+func (s *PostgresStore) Get(ctx context.Context, key string) (*Flag, error) {
+	var flag Flag
+	if err := s.db.WithContext(ctx).First(&flag, "key = ?", key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List 列出所有旗標，依名稱排序
+func (s *PostgresStore) List(ctx context.Context) ([]*Flag, error) {
+	var flags []*Flag
+	if err := s.db.WithContext(ctx).Order("key").Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Save 新增或更新旗標
+func (s *PostgresStore) Save(ctx context.Context, flag *Flag) error {
+	return s.db.WithContext(ctx).Save(flag).Error
+}
+
+// Delete 刪除旗標
+func (s *PostgresStore) Delete(ctx context.Context, key string) error {
+	result := s.db.WithContext(ctx).Delete(&Flag{}, "key = ?", key)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CachedStore 以 Redis 快取其他儲存的旗標，多個實例之間共享
+// 變更時遞增版本並清除快取，其他實例下次讀取即取得新值；變更前開始的讀取因版本不符不會寫回舊值
+// Redis 無法使用時直接讀取底層儲存
+type CachedStore struct {
+	store  Store
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewCachedStore 創建新的 Redis 快取旗標儲存
+func NewCachedStore(store Store, client *redis.Client, ttl time.Duration) *CachedStore {
+	return &CachedStore{store: store, client: client, ttl: ttl}
+}
+
+// Get 獲取旗標，優先讀取快取
+func (s *CachedStore) Get(ctx context.Context, key string) (*Flag, error) {
+	var flag *Flag
+	version, hit := s.readCache(ctx, cacheKeyPrefix+key, &flag)
+	if hit {
+		if flag == nil {
+			return nil, ErrNotFound
+		}
+		return flag, nil
+	}
+
+	flag, err := s.store.Get(ctx, key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	s.writeCache(ctx, cacheKeyPrefix+key, version, flag)
+	if flag == nil {
+		return nil, ErrNotFound
+	}
+	return flag, nil
+}
+
+// List 列出所有旗標，優先讀取快取
+func (s *CachedStore) List(ctx context.Context) ([]*Flag, error) {
+	var flags []*Flag
+	version, hit := s.readCache(ctx, cacheKeyList, &flags)
+	if hit {
+		return flags, nil
+	}
+
+	flags, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.writeCache(ctx, cacheKeyList, version, flags)
+	return flags, nil
+}
+
+// Save 新增或更新旗標並清除快取，底層儲存寫入成功後即回傳成功
+func (s *CachedStore) Save(ctx context.Context, flag *Flag) error {
+	if err := s.store.Save(ctx, flag); err != nil {
+		return err
+	}
+	s.invalidate(ctx, flag.Key)
+	return nil
+}
+
+// Delete 刪除旗標並清除快取，底層儲存刪除成功後即回傳成功
+func (s *CachedStore) Delete(ctx context.Context, key string) error {
+	if err := s.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.invalidate(ctx, key)
+	return nil
+}
+
+// readCache 讀取快取與目前的版本，命中時回傳 true；Redis 無法使用時版本為 nil，不寫回快取
+func (s *CachedStore) readCache(ctx context.Context, key string, dest interface{}) (*string, bool) {
+	values, err := s.client.MGet(ctx, key, cacheKeyVersion).Result()
+	if err != nil {
+		logger.Warn("feature flag cache unavailable", logger.String("key", key), logger.Err(err))
+		return nil, false
+	}
+	version, _ := values[1].(string)
+	value, ok := values[0].(string)
+	if !ok {
+		return &version, false
+	}
+	return &version, json.Unmarshal([]byte(value), dest) == nil
+}
+
+// writeCache 版本未變動時寫入快取，不存在的旗標記錄為 null，避免未定義的旗標每次都查詢底層儲存
+func (s *CachedStore) writeCache(ctx context.Context, key string, version *string, value interface{}) {
+	if version == nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	err = writeCacheScript.Run(ctx, s.client, []string{cacheKeyVersion, key}, *version, data, s.ttl.Milliseconds()).Err()
+	if err != nil {
+		logger.Warn("feature flag cache unavailable", logger.String("key", key), logger.Err(err))
+	}
+}
+
+// invalidate 遞增版本並清除旗標與列表的快取，失敗時舊值最多保留一個 TTL
+func (s *CachedStore) invalidate(ctx context.Context, key string) {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, cacheKeyVersion)
+		pipe.Del(ctx, cacheKeyPrefix+key, cacheKeyList)
+		return nil
+	})
+	if err != nil {
+		logger.Error("feature flag cache invalidation failed, stale value may be served until the cache expires",
+			logger.String("key", key), logger.Err(err))
+	}
+}
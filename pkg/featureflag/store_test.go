@@ -0,0 +1,204 @@
+package featureflag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore wraps a Store and counts reads that reach it.
+type countingStore struct {
+	Store
+	gets  int
+	lists int
+}
+
+func (s *countingStore) Get(ctx context.Context, key string) (*Flag, error) {
+	s.gets++
+	return s.Store.Get(ctx, key)
+}
+
+func (s *countingStore) List(ctx context.Context) ([]*Flag, error) {
+	s.lists++
+	return s.Store.List(ctx)
+}
+
+// racingStore wraps a Store and runs afterGet once a read has returned, before the result is cached.
+type racingStore struct {
+	Store
+	afterGet func()
+}
+
+func (s *racingStore) Get(ctx context.Context, key string) (*Flag, error) {
+	flag, err := s.Store.Get(ctx, key)
+	if s.afterGet != nil {
+		afterGet := s.afterGet
+		s.afterGet = nil
+		afterGet()
+	}
+	return flag, err
+}
+
+// failingDelStore wraps a Store and makes Redis unavailable after each write reaches it.
+type failingDelStore struct {
+	Store
+	mr *miniredis.Miniredis
+}
+
+func (s *failingDelStore) Save(ctx context.Context, flag *Flag) error {
+	err := s.Store.Save(ctx, flag)
+	s.mr.SetError("LOADING")
+	return err
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	_, err := store.Get(ctx, "threads")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Save(ctx, &Flag{Key: "threads", Enabled: true}))
+	require.NoError(t, store.Save(ctx, &Flag{Key: "passkeys"}))
+
+	flag, err := store.Get(ctx, "threads")
+	require.NoError(t, err)
+	assert.True(t, flag.Enabled)
+	assert.False(t, flag.UpdatedAt.IsZero())
+
+	flags, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "passkeys", flags[0].Key)
+
+	require.NoError(t, store.Delete(ctx, "threads"))
+	assert.ErrorIs(t, store.Delete(ctx, "threads"), ErrNotFound)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+flags:
+  - key: passkeys
+    description: "Sign in with passkeys"
+    enabled: true
+    rollout: 10
+    roles: ["admin"]
+    workspaces: ["acme"]
+    users: [42]
+`), 0o600))
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	flag, err := store.Get(context.Background(), "passkeys")
+	require.NoError(t, err)
+	assert.Equal(t, &Flag{
+		Key:         "passkeys",
+		Description: "Sign in with passkeys",
+		Enabled:     true,
+		Rollout:     10,
+		Roles:       []string{"admin"},
+		Workspaces:  []string{"acme"},
+		Users:       []uint{42},
+	}, flag)
+
+	_, err = NewFileStore(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	backend := &countingStore{Store: NewMemoryStore(&Flag{Key: "threads", Enabled: true})}
+	store := NewCachedStore(backend, client, time.Minute)
+
+	t.Run("Reads are cached", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			flag, err := store.Get(ctx, "threads")
+			require.NoError(t, err)
+			assert.True(t, flag.Enabled)
+
+			_, err = store.Get(ctx, "undefined")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			_, err = store.List(ctx)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, backend.gets, "one read per key, including missing flags")
+		assert.Equal(t, 1, backend.lists)
+	})
+
+	t.Run("Writes invalidate the cache", func(t *testing.T) {
+		require.NoError(t, store.Save(ctx, &Flag{Key: "threads", Enabled: false}))
+
+		flag, err := store.Get(ctx, "threads")
+		require.NoError(t, err)
+		assert.False(t, flag.Enabled)
+
+		require.NoError(t, store.Delete(ctx, "threads"))
+		_, err = store.Get(ctx, "threads")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		flags, err := store.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, flags)
+	})
+
+	t.Run("Stale read is not written back", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		backend := &racingStore{Store: NewMemoryStore(&Flag{Key: "threads", Enabled: true})}
+		store := NewCachedStore(backend, client, time.Minute)
+		// 讀取到舊值後、寫入快取前，另一個請求更新了旗標
+		backend.afterGet = func() {
+			require.NoError(t, store.Save(ctx, &Flag{Key: "threads", Enabled: false}))
+		}
+
+		flag, err := store.Get(ctx, "threads")
+		require.NoError(t, err)
+		assert.True(t, flag.Enabled)
+		assert.False(t, mr.Exists(cacheKeyPrefix+"threads"))
+
+		flag, err = store.Get(ctx, "threads")
+		require.NoError(t, err)
+		assert.False(t, flag.Enabled)
+		assert.True(t, mr.Exists(cacheKeyPrefix+"threads"))
+	})
+
+	t.Run("Invalidation failure does not fail the write", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		backend := &failingDelStore{Store: NewMemoryStore(), mr: mr}
+		store := NewCachedStore(backend, client, time.Minute)
+		require.NoError(t, store.Save(ctx, &Flag{Key: "threads", Enabled: true}))
+
+		mr.SetError("")
+		flag, err := backend.Get(ctx, "threads")
+		require.NoError(t, err)
+		assert.True(t, flag.Enabled)
+	})
+
+	t.Run("Falls back to store when Redis is down", func(t *testing.T) {
+		require.NoError(t, backend.Save(ctx, &Flag{Key: "passkeys", Enabled: true}))
+		mr.Close()
+
+		flag, err := store.Get(ctx, "passkeys")
+		require.NoError(t, err)
+		assert.True(t, flag.Enabled)
+	})
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Pinger 可以 ping 的連線，如 *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingChecker 以 PingContext 檢查資料庫連線
+func PingChecker(pinger Pinger) Checker {
+	return CheckerFunc(pinger.PingContext)
+}
+
+// RedisChecker 以 PING 檢查 Redis 連線
+func RedisChecker(client *redis.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// HTTPChecker 檢查下游服務，GET url 回傳 2xx 時為正常，通常指向下游的就緒探針
+func HTTPChecker(client *http.Client, url string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,202 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 檢查狀態
+const (
+	// StatusUp 服務或依賴正常
+	StatusUp = "up"
+	// StatusDown 服務或依賴異常
+	StatusDown = "down"
+	// StatusDraining 服務正在關閉，不再接收新流量
+	StatusDraining = "draining"
+)
+
+// Config 健康檢查配置
+type Config struct {
+	// 存活探針路徑，只確認行程可以處理請求，不檢查依賴
+	LivenessPath string `validate:"required"`
+	// 就緒探針路徑，依賴全部正常時才回傳 200
+	ReadinessPath string `validate:"required"`
+	// 單一依賴檢查的逾時（秒）
+	Timeout int `validate:"min=1"`
+	// 檢查結果快取多久（秒），避免頻繁的探針壓垮依賴，0 表示不快取
+	CacheTTL int `validate:"min=0"`
+	// 關閉時就緒探針改回 503 後，等待多久（秒）讓負載平衡器移除此實例
+	DrainDelay int `validate:"min=0"`
+}
+
+// Checker 依賴檢查，需遵守 ctx 的逾時
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 以函式實作 Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check 呼叫 f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result 單一依賴的檢查結果
+type Result struct {
+	Status string `json:"status"`
+	// 失敗原因，探針回應公開可見，只標示 timeout，詳細錯誤寫入日誌
+	Error string `json:"error,omitempty"`
+	// 檢查耗時（毫秒）
+	Duration int64 `json:"duration_ms"`
+	// 檢查時間，快取的結果會早於回應時間
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report 就緒檢查結果
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// check 已註冊的依賴檢查與其快取結果
+type check struct {
+	name    string
+	checker Checker
+
+	// mu 同一時間只執行一次檢查，同時到達的探針共用結果
+	mu     sync.Mutex
+	result Result
+}
+
+// Registry 依賴檢查註冊表，提供存活與就緒探針
+type Registry struct {
+	config Config
+
+	mu     sync.RWMutex
+	checks []*check
+
+	draining atomic.Bool
+}
+
+// NewRegistry 創建健康檢查註冊表
+func NewRegistry(config Config) *Registry {
+	return &Registry{config: config}
+}
+
+// Register 註冊依賴檢查，名稱會出現在就緒探針的回應中，不可重複
+func (r *Registry) Register(name string, checker Checker) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.checks {
+		if c.name == name {
+			return fmt.Errorf("health check %q already registered", name)
+		}
+	}
+	r.checks = append(r.checks, &check{name: name, checker: checker})
+	return nil
+}
+
+// Check 同時執行所有依賴檢查，任一依賴異常時狀態為 StatusDown，關閉中則直接回傳 StatusDraining
+func (r *Registry) Check(ctx context.Context) Report {
+	if r.Draining() {
+		return Report{Status: StatusDraining}
+	}
+
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run 執行單一檢查，快取未過期時直接回傳上次的結果
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := time.Duration(r.config.CacheTTL) * time.Second
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < ttl {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.config.Timeout)*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	result := Result{Status: StatusUp, Duration: time.Since(start).Milliseconds(), CheckedAt: start}
+	if err != nil {
+		result.Status = StatusDown
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "timeout"
+		}
+		logger.Warn("health check failed", logger.String("check", c.name), logger.Err(err))
+	}
+	c.result = result
+	return result
+}
+
+// Drain 將就緒探針改為失敗，並等待 DrainDelay 讓負載平衡器停止導入流量，應在關閉 HTTP 服務前呼叫
+func (r *Registry) Drain(ctx context.Context) error {
+	r.draining.Store(true)
+	timer := time.NewTimer(time.Duration(r.config.DrainDelay) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Draining 服務是否正在關閉
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// LivenessHandler 存活探針，行程能回應即為正常；關閉中仍回傳 200，避免正在排空的實例被重啟
+func (r *Registry) LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, Report{Status: StatusUp})
+	}
+}
+
+// ReadinessHandler 就緒探針，依賴全部正常時回傳 200，否則回傳 503 與各依賴的狀態
+func (r *Registry) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Check(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, report)
+	}
+}
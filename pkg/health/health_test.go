@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{LivenessPath: "/healthz", ReadinessPath: "/readyz", Timeout: 1}
+}
+
+func setupRouter(registry *Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/healthz", registry.LivenessHandler())
+	router.GET("/readyz", registry.ReadinessHandler())
+	return router
+}
+
+func probe(t *testing.T, router *gin.Engine, path string) (int, Report) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	t.Run("All dependencies up", func(t *testing.T) {
+		registry := NewRegistry(testConfig())
+		require.NoError(t, registry.Register("postgresql", CheckerFunc(func(context.Context) error { return nil })))
+		require.NoError(t, registry.Register("redis", CheckerFunc(func(context.Context) error { return nil })))
+
+		code, report := probe(t, setupRouter(registry), "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, StatusUp, report.Status)
+		assert.Equal(t, StatusUp, report.Checks["postgresql"].Status)
+		assert.Equal(t, StatusUp, report.Checks["redis"].Status)
+	})
+
+	t.Run("One dependency down", func(t *testing.T) {
+		registry := NewRegistry(testConfig())
+		require.NoError(t, registry.Register("postgresql", CheckerFunc(func(context.Context) error { return nil })))
+		require.NoError(t, registry.Register("redis", CheckerFunc(func(context.Context) error { return errors.New("connection refused") })))
+
+		code, report := probe(t, setupRouter(registry), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, StatusUp, report.Checks["postgresql"].Status)
+		assert.Equal(t, StatusDown, report.Checks["redis"].Status)
+		assert.Empty(t, report.Checks["redis"].Error, "dependency errors are only logged")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		registry := NewRegistry(testConfig())
+		require.NoError(t, registry.Register("slow", CheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})))
+
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, "timeout", report.Checks["slow"].Error)
+	})
+
+	t.Run("Duplicate name", func(t *testing.T) {
+		registry := NewRegistry(testConfig())
+		require.NoError(t, registry.Register("redis", CheckerFunc(func(context.Context) error { return nil })))
+		assert.Error(t, registry.Register("redis", CheckerFunc(func(context.Context) error { return nil })))
+	})
+}
+
+func TestReadinessCache(t *testing.T) {
+	var calls atomic.Int32
+	checker := CheckerFunc(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		calls.Store(0)
+		config := testConfig()
+		config.CacheTTL = 60
+		registry := NewRegistry(config)
+		require.NoError(t, registry.Register("postgresql", checker))
+
+		first := registry.Check(context.Background())
+		second := registry.Check(context.Background())
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, first.Checks["postgresql"].CheckedAt, second.Checks["postgresql"].CheckedAt)
+	})
+
+	t.Run("Not cached", func(t *testing.T) {
+		calls.Store(0)
+		registry := NewRegistry(testConfig())
+		require.NoError(t, registry.Register("postgresql", checker))
+
+		registry.Check(context.Background())
+		registry.Check(context.Background())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestDrain(t *testing.T) {
+	registry := NewRegistry(testConfig())
+	require.NoError(t, registry.Register("postgresql", CheckerFunc(func(context.Context) error { return nil })))
+	router := setupRouter(registry)
+
+	code, _ := probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	require.NoError(t, registry.Drain(context.Background()))
+	assert.True(t, registry.Draining())
+
+	code, report := probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDraining, report.Status)
+
+	code, report = probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code, "liveness stays up while draining")
+	assert.Equal(t, StatusUp, report.Status)
+
+	config := testConfig()
+	config.DrainDelay = 60
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, NewRegistry(config).Drain(ctx), context.Canceled, "drain delay is bounded by the shutdown context")
+}
+
+func TestCheckers(t *testing.T) {
+	t.Run("Redis", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		defer client.Close()
+
+		assert.NoError(t, RedisChecker(client).Check(context.Background()))
+		mr.Close()
+		assert.Error(t, RedisChecker(client).Check(context.Background()))
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/readyz" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		assert.NoError(t, HTTPChecker(server.Client(), server.URL+"/readyz").Check(context.Background()))
+		assert.EqualError(t, HTTPChecker(server.Client(), server.URL+"/down").Check(context.Background()), "unexpected status 503")
+	})
+}
@@ -1,31 +1,26 @@
 package logger
 
 import (
+	"context"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 var (
-	// Log 全局日誌實例
-	Log *zap.Logger
+	// Log 全局日誌實例，未初始化前使用 Nop 避免空指標
+	Log = zap.NewNop()
+	// atomicLevel 日誌級別，可在執行期間調整而不需重建 Log
+	atomicLevel = zap.NewAtomicLevel()
 )
 
 // InitLogger 初始化日誌系統
 func InitLogger(level string) error {
 	// 設置日誌級別
-	var logLevel zapcore.Level
-	switch level {
-	case "debug":
-		logLevel = zapcore.DebugLevel
-	case "info":
-		logLevel = zapcore.InfoLevel
-	case "warn":
-		logLevel = zapcore.WarnLevel
-	case "error":
-		logLevel = zapcore.ErrorLevel
-	default:
-		logLevel = zapcore.InfoLevel
-	}
+	SetLevel(level)
 
 	// 配置編碼器
 	encoderConfig := zapcore.EncoderConfig{
@@ -45,8 +40,8 @@ func InitLogger(level string) error {
 	// 創建核心
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(zapcore.NewConsoleEncoder(encoderConfig)),
-		logLevel,
+		zapcore.AddSync(os.Stdout),
+		atomicLevel,
 	)
 
 	// 創建日誌實例
@@ -54,6 +49,46 @@ func InitLogger(level string) error {
 	return nil
 }
 
+// SetLevel 調整日誌級別，未知的級別視為 info
+func SetLevel(level string) {
+	switch level {
+	case "debug":
+		atomicLevel.SetLevel(zapcore.DebugLevel)
+	case "info":
+		atomicLevel.SetLevel(zapcore.InfoLevel)
+	case "warn":
+		atomicLevel.SetLevel(zapcore.WarnLevel)
+	case "error":
+		atomicLevel.SetLevel(zapcore.ErrorLevel)
+	default:
+		atomicLevel.SetLevel(zapcore.InfoLevel)
+	}
+}
+
+// contextKey 請求日誌在 context 中的鍵
+type contextKey struct{}
+
+// NewContext 回傳帶有日誌實例的 context，通常為附加 request_id 等欄位的請求日誌
+func NewContext(ctx context.Context, log *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext 取得 context 中的請求日誌，沒有時回傳全局日誌
+// context 中有追蹤中的 span 時加上 trace_id 與 span_id，讓日誌能與追蹤資料對應
+func FromContext(ctx context.Context) *zap.Logger {
+	log, ok := ctx.Value(contextKey{}).(*zap.Logger)
+	if !ok {
+		log = Log
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		log = log.With(
+			zap.String("trace_id", spanContext.TraceID().String()),
+			zap.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return log
+}
+
 // Debug 輸出調試日誌
 func Debug(msg string, fields ...zap.Field) {
 	Log.Debug(msg, fields...)
@@ -78,3 +113,31 @@ func Error(msg string, fields ...zap.Field) {
 func Fatal(msg string, fields ...zap.Field) {
 	Log.Fatal(msg, fields...)
 }
+
+// Field 日誌欄位，供需要組合欄位的呼叫端使用
+type Field = zap.Field
+
+// String 建立字串欄位
+func String(key, val string) zap.Field {
+	return zap.String(key, val)
+}
+
+// Int 建立整數欄位
+func Int(key string, val int) zap.Field {
+	return zap.Int(key, val)
+}
+
+// Duration 建立時間長度欄位
+func Duration(key string, val time.Duration) zap.Field {
+	return zap.Duration(key, val)
+}
+
+// Any 建立任意型別的欄位
+func Any(key string, val interface{}) zap.Field {
+	return zap.Any(key, val)
+}
+
+// Err 建立錯誤欄位
+func Err(err error) zap.Field {
+	return zap.Error(err)
+}
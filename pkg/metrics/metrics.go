@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 登入結果，作為 auth_login_attempts_total 的 result 標籤
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginDisabled           = "disabled"
+	LoginExpired            = "expired"
+	LoginError              = "error"
+)
+
+// Registry 全域指標註冊表，包含 Go runtime 與行程指標，由 Handler 匯出
+var Registry = prometheus.NewRegistry()
+
+var (
+	// httpRequestsTotal 依路由模板與狀態碼統計的請求數
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by route template and status code.",
+	}, []string{"method", "route", "status"})
+	// httpRequestDuration 依路由模板統計的處理時間
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds by route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	// httpRequestErrorsTotal 回應 5xx 的請求數
+	httpRequestErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_request_errors_total",
+		Help: "Total number of HTTP requests answered with a 5xx status.",
+	}, []string{"method", "route", "status"})
+	// rateLimitRejectionsTotal 依限流策略統計被拒絕的請求數，全域限流的策略為 global
+	rateLimitRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_rejections_total",
+		Help: "Total number of requests rejected by a rate limit policy.",
+	}, []string{"policy"})
+	// circuitBreakerState 熔斷器目前的狀態：0 為關閉、1 為半開、2 為開啟
+	circuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "circuit_breaker_state",
+		Help: "Current circuit breaker state (0 closed, 1 half-open, 2 open).",
+	}, []string{"name"})
+	// circuitBreakerRejectionsTotal 熔斷期間被直接拒絕的呼叫數
+	circuitBreakerRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "circuit_breaker_rejections_total",
+		Help: "Total number of calls rejected by an open circuit breaker.",
+	}, []string{"name"})
+	// retryAttemptsTotal 依依賴統計的重試次數，不含第一次呼叫
+	retryAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "retry_attempts_total",
+		Help: "Total number of retried calls to outbound dependencies.",
+	}, []string{"name"})
+	// loginAttemptsTotal 依結果統計的登入次數
+	loginAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Total number of login attempts by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		httpRequestErrorsTotal,
+		rateLimitRejectionsTotal,
+		circuitBreakerState,
+		circuitBreakerRejectionsTotal,
+		retryAttemptsTotal,
+		loginAttemptsTotal,
+	)
+}
+
+// Handler 以 Prometheus 文字格式匯出 Registry 中的指標
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RecordRateLimitRejection 記錄一次被限流策略拒絕的請求
+func RecordRateLimitRejection(policy string) {
+	rateLimitRejectionsTotal.WithLabelValues(policy).Inc()
+}
+
+// SetCircuitBreakerState 更新熔斷器狀態，state 為 0 關閉、1 半開、2 開啟
+func SetCircuitBreakerState(name string, state int) {
+	circuitBreakerState.WithLabelValues(name).Set(float64(state))
+}
+
+// RecordCircuitBreakerRejection 記錄一次被熔斷器拒絕的呼叫
+func RecordCircuitBreakerRejection(name string) {
+	circuitBreakerRejectionsTotal.WithLabelValues(name).Inc()
+}
+
+// RecordRetry 記錄一次重試
+func RecordRetry(name string) {
+	retryAttemptsTotal.WithLabelValues(name).Inc()
+}
+
+// RecordLogin 記錄一次登入結果，result 為 LoginSuccess 等常數
+func RecordLogin(result string) {
+	loginAttemptsTotal.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/users/:user_id", func(c *gin.Context) {
+		if c.Param("user_id") == "0" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "/users/:user_id", "200")), "paths are grouped by route template")
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "unmatched", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequestErrorsTotal.WithLabelValues("GET", "/users/:user_id", "500")))
+	assert.Equal(t, 1, testutil.CollectAndCount(httpRequestErrorsTotal), "4xx responses are not errors")
+	assert.Equal(t, 2, testutil.CollectAndCount(httpRequestDuration))
+}
+
+func TestRecorders(t *testing.T) {
+	RecordLogin(LoginSuccess)
+	RecordLogin(LoginInvalidCredentials)
+	RecordLogin(LoginInvalidCredentials)
+	RecordRateLimitRejection("login")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(loginAttemptsTotal.WithLabelValues(LoginSuccess)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(loginAttemptsTotal.WithLabelValues(LoginInvalidCredentials)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rateLimitRejectionsTotal.WithLabelValues("login")))
+}
+
+func TestRedisCollector(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	require.NoError(t, client.Ping(client.Context()).Err())
+
+	collector := newRedisCollector(client, "default")
+	expected := `
+# HELP redis_pool_connections Number of connections in the pool.
+# TYPE redis_pool_connections gauge
+redis_pool_connections{client="default"} 1
+# HELP redis_pool_misses_total Number of times a free connection was not found in the pool.
+# TYPE redis_pool_misses_total counter
+redis_pool_misses_total{client="default"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"redis_pool_connections", "redis_pool_misses_total"))
+}
+
+func TestHandler(t *testing.T) {
+	RecordLogin(LoginExpired)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, string(body), `auth_login_attempts_total{result="expired"} 1`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
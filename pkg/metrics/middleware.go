@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware 記錄每個請求的 RED 指標（請求數、錯誤數與處理時間），以路由模板作為標籤避免基數過高
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// 未匹配的路由沒有模板，統一以 unmatched 計算
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequestsTotal.WithLabelValues(method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			httpRequestErrorsTotal.WithLabelValues(method, route, status).Inc()
+		}
+	}
+}
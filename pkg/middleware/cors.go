@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig CORS 配置
+type CORSConfig struct {
+	// 允許的來源，支援完整來源（https://app.example.com）、子網域萬用字元（https://*.example.com）與 *
+	AllowOrigins []string
+	// 允許的方法
+	AllowMethods []string
+	// 允許的請求標頭，未設定時允許預檢請求所列的標頭
+	AllowHeaders []string
+	// 允許前端讀取的回應標頭
+	ExposeHeaders []string
+	// 是否允許攜帶 cookie 與 Authorization，啟用時一律回傳請求的來源而非 *，不可與來源 * 同時使用
+	AllowCredentials bool
+	// 預檢結果的快取時間（秒），0 表示不快取
+	MaxAge int `validate:"min=0"`
+}
+
+// CORS 跨來源資源共享中間件，預檢請求於此直接回應，不會進入後續處理
+// 不允許的來源不會收到 CORS 標頭，由瀏覽器阻擋；不允許的預檢請求回傳 403
+func CORS(config CORSConfig) gin.HandlerFunc {
+	matcher := newOriginMatcher(config.AllowOrigins)
+	allowMethods := strings.Join(config.AllowMethods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(config.MaxAge)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		// 回應內容依來源而異，避免快取將某個來源的回應提供給其他來源
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !matcher.match(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if matcher.any && !config.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		if !containsFold(config.AllowMethods, c.GetHeader("Access-Control-Request-Method")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// originMatcher 比對請求來源，不區分大小寫
+type originMatcher struct {
+	any       bool
+	exact     map[string]struct{}
+	wildcards []wildcardOrigin
+}
+
+// wildcardOrigin 子網域萬用字元，如 https://*.example.com 拆為 https:// 與 .example.com
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+// newOriginMatcher 解析允許的來源
+func newOriginMatcher(origins []string) *originMatcher {
+	m := &originMatcher{exact: make(map[string]struct{}, len(origins))}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimRight(origin, "/"))
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "://*."):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			m.wildcards = append(m.wildcards, wildcardOrigin{prefix: prefix, suffix: suffix})
+		default:
+			m.exact[origin] = struct{}{}
+		}
+	}
+	return m
+}
+
+// match 檢查來源是否允許
+func (m *originMatcher) match(origin string) bool {
+	if m.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := m.exact[origin]; ok {
+		return true
+	}
+	for _, wildcard := range m.wildcards {
+		if len(origin) <= len(wildcard.prefix)+len(wildcard.suffix) ||
+			!strings.HasPrefix(origin, wildcard.prefix) || !strings.HasSuffix(origin, wildcard.suffix) {
+			continue
+		}
+		// 萬用字元只涵蓋子網域，不涵蓋根網域，也不能夾帶路徑或連接埠
+		if isHostLabels(origin[len(wildcard.prefix) : len(origin)-len(wildcard.suffix)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostLabels 檢查是否只包含網域名稱允許的字元
+func isHostLabels(s string) bool {
+	if s == "" || strings.HasPrefix(s, ".") || strings.HasSuffix(s, ".") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold 不區分大小寫檢查字串是否在列表中
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
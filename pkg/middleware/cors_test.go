@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCORSRouter registers GET and POST handlers behind the CORS middleware.
+func setupCORSRouter(config CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(config))
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+// performCORSRequest sends a request with the given origin and extra headers.
+func performCORSRequest(router *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// preflight returns the headers of a preflight request for POST.
+func preflight(requestHeaders string) map[string]string {
+	headers := map[string]string{"Access-Control-Request-Method": "POST"}
+	if requestHeaders != "" {
+		headers["Access-Control-Request-Headers"] = requestHeaders
+	}
+	return headers
+}
+
+func TestCORSOrigins(t *testing.T) {
+	router := setupCORSRouter(CORSConfig{
+		AllowOrigins: []string{"https://app.example.com", "https://*.slack-clone.dev"},
+		AllowMethods: []string{"GET", "POST"},
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"https://evil.example.com", false},
+		{"http://app.example.com", false},
+		{"https://team.slack-clone.dev", true},
+		{"https://a.b.slack-clone.dev", true},
+		{"https://slack-clone.dev", false},
+		{"https://.slack-clone.dev", false},
+		{"https://evil.com/.slack-clone.dev", false},
+		{"https://evilslack-clone.dev", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := performCORSRequest(router, http.MethodGet, tt.origin, nil)
+			assert.Equal(t, http.StatusOK, w.Code, "disallowed simple requests still reach the handler")
+			assert.Contains(t, w.Header().Values("Vary"), "Origin")
+			if tt.allowed {
+				assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+
+	t.Run("No origin", func(t *testing.T) {
+		w := performCORSRequest(router, http.MethodGet, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCORSPreflight(t *testing.T) {
+	config := CORSConfig{
+		AllowOrigins:  []string{"https://app.example.com"},
+		AllowMethods:  []string{"GET", "POST"},
+		AllowHeaders:  []string{"Authorization", "Content-Type"},
+		ExposeHeaders: []string{"X-Request-ID"},
+		MaxAge:        600,
+	}
+
+	t.Run("Allowed preflight short-circuits", func(t *testing.T) {
+		w := performCORSRequest(setupCORSRouter(config), http.MethodOptions, "https://app.example.com", preflight("Authorization"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Disallowed origin", func(t *testing.T) {
+		w := performCORSRequest(setupCORSRouter(config), http.MethodOptions, "https://evil.com", preflight(""))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Disallowed method", func(t *testing.T) {
+		headers := map[string]string{"Access-Control-Request-Method": "DELETE"}
+		w := performCORSRequest(setupCORSRouter(config), http.MethodOptions, "https://app.example.com", headers)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Requested headers echoed when not configured", func(t *testing.T) {
+		config := config
+		config.AllowHeaders = nil
+		w := performCORSRequest(setupCORSRouter(config), http.MethodOptions, "https://app.example.com", preflight("X-Workspace-ID"))
+		assert.Equal(t, "X-Workspace-ID", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("Actual request exposes headers", func(t *testing.T) {
+		w := performCORSRequest(setupCORSRouter(config), http.MethodPost, "https://app.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	t.Run("Without credentials", func(t *testing.T) {
+		router := setupCORSRouter(CORSConfig{AllowOrigins: []string{"*"}, AllowMethods: []string{"GET"}})
+		w := performCORSRequest(router, http.MethodGet, "https://any.example.com", nil)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("With credentials echoes origin", func(t *testing.T) {
+		router := setupCORSRouter(CORSConfig{AllowOrigins: []string{"*"}, AllowMethods: []string{"GET"}, AllowCredentials: true})
+		w := performCORSRequest(router, http.MethodGet, "https://any.example.com", nil)
+		assert.Equal(t, "https://any.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
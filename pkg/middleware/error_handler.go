@@ -1,53 +1,85 @@
 package middleware
 
 import (
-	"net/http"
-
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
 	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorResponse 錯誤響應結構
-type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
+// ErrInvalidRequest 請求參數無法解析或未通過 binding 驗證
+var ErrInvalidRequest = apperror.InvalidArgument("invalid request parameters")
+
+// ErrorResponse 錯誤響應格式（RFC 7807），供 swagger 註解引用
+type ErrorResponse = apperror.Problem
 
-// ErrorHandler 全局錯誤處理中間件
+// ErrorHandler 全局錯誤處理中間件，將 c.Errors 中最後一個錯誤以 application/problem+json 回應並記錄原因
+// 錯誤依序對應：*apperror.Error 依其代碼、ErrorTypeBind 為 400 並在 details.errors 列出不合法的欄位、ErrorTypePublic 為 500 並公開訊息、context 期限已過為 504、其餘為 500
+// 已經回應過的請求（如 AbortWithError）只記錄日誌，不會重複寫入
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
 		// 檢查是否有錯誤
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-
-			// 記錄錯誤
-			logger.Error("request error",
-				logger.String("path", c.Request.URL.Path),
-				logger.String("method", c.Request.Method),
-				logger.Error(err),
-			)
-
-			// 根據錯誤類型返回適當的響應
-			switch err.Type {
-			case gin.ErrorTypeBind:
-				c.JSON(http.StatusBadRequest, ErrorResponse{
-					Code:    http.StatusBadRequest,
-					Message: "invalid request parameters",
-				})
-			case gin.ErrorTypePrivate:
-				c.JSON(http.StatusInternalServerError, ErrorResponse{
-					Code:    http.StatusInternalServerError,
-					Message: "internal server error",
-				})
-			default:
-				c.JSON(http.StatusInternalServerError, ErrorResponse{
-					Code:    http.StatusInternalServerError,
-					Message: err.Error(),
-				})
-			}
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := toAppError(c, c.Errors.Last())
+
+		// 記錄錯誤，用戶端錯誤只記錄警告；使用請求日誌以帶上 request_id
+		log := logger.FromContext(c.Request.Context())
+		fields := []logger.Field{
+			logger.String("path", c.Request.URL.Path),
+			logger.String("method", c.Request.Method),
+			logger.String("code", string(err.Code)),
+			logger.Int("status", err.Status()),
+			logger.Err(err),
+		}
+		if err.Status() >= 500 {
+			log.Error("request error", fields...)
+		} else {
+			log.Warn("request error", fields...)
+		}
+
+		if c.Writer.Written() {
+			return
+		}
+		WriteProblem(c, err)
+	}
+}
+
+// WriteProblem 以 application/problem+json 回應錯誤，非 *apperror.Error 的錯誤一律視為內部錯誤
+func WriteProblem(c *gin.Context, err error) {
+	appErr := apperror.From(err)
+	c.Header("Content-Type", apperror.ContentType)
+	c.JSON(appErr.Status(), appErr.Problem(c.Request.URL.Path))
+}
+
+// AbortWithError 立即回應錯誤並中止後續處理，錯誤同時記錄於 c.Errors 供 ErrorHandler 寫入日誌
+// 中間件應使用此函式，未安裝 ErrorHandler 時也能正確回應
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+	WriteProblem(c, err)
+	c.Abort()
+}
+
+// toAppError 依 gin 錯誤類型轉為應用層錯誤，欄位錯誤訊息依 Accept-Language 翻譯
+func toAppError(c *gin.Context, ginErr *gin.Error) *apperror.Error {
+	if appErr, ok := apperror.As(ginErr.Err); ok {
+		return appErr
+	}
+
+	switch ginErr.Type {
+	case gin.ErrorTypeBind:
+		appErr := ErrInvalidRequest.Wrap(ginErr.Err)
+		if fields, ok := validation.Translate(ginErr.Err, c.GetHeader("Accept-Language")); ok {
+			appErr = appErr.WithDetail("errors", fields)
 		}
+		return appErr
+	case gin.ErrorTypePublic:
+		return apperror.New(apperror.CodeInternal, ginErr.Err.Error())
+	default:
+		return apperror.From(ginErr.Err)
 	}
 }
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+	"github.com/POABOB/slack-clone-back-end/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveWithErrorHandler runs handlers behind ErrorHandler and decodes the problem response.
+func serveWithErrorHandler(t *testing.T, handlers ...gin.HandlerFunc) (*httptest.ResponseRecorder, apperror.Problem) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/test", handlers...)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	var problem apperror.Problem
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	}
+	return w, problem
+}
+
+func TestErrorHandler(t *testing.T) {
+	t.Run("Application error", func(t *testing.T) {
+		w, problem := serveWithErrorHandler(t, func(c *gin.Context) {
+			_ = c.Error(apperror.NotFound("user not found").Wrap(errors.New("record not found"))).SetType(gin.ErrorTypePrivate)
+		})
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, apperror.ContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, apperror.CodeNotFound, problem.Code)
+		assert.Equal(t, "user not found", problem.Detail, "the cause is not exposed")
+		assert.Equal(t, "/test", problem.Instance)
+	})
+
+	t.Run("Bind error", func(t *testing.T) {
+		w, problem := serveWithErrorHandler(t, func(c *gin.Context) {
+			_ = c.Error(errors.New("Key: 'Email' Error:Field validation")).SetType(gin.ErrorTypeBind)
+		})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, apperror.CodeInvalidArgument, problem.Code)
+	})
+
+	t.Run("Field errors", func(t *testing.T) {
+		type request struct {
+			Email string `json:"email" binding:"required,email"`
+		}
+		// Field names come from json tags once the validator is registered.
+		validation.Default()
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.POST("/test", func(c *gin.Context) {
+			var req request
+			if err := c.ShouldBindJSON(&req); err != nil {
+				_ = c.Error(err).SetType(gin.ErrorTypeBind)
+			}
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"email": "alice"}`))
+		req.Header.Set("Accept-Language", "zh-TW")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var body struct {
+			Details struct {
+				Errors []validation.FieldError `json:"errors"`
+			} `json:"details"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, []validation.FieldError{{Field: "email", Rule: "email", Message: "email必須是一個有效的信箱"}}, body.Details.Errors)
+	})
+
+	t.Run("Unknown error", func(t *testing.T) {
+		w, problem := serveWithErrorHandler(t, func(c *gin.Context) {
+			_ = c.Error(errors.New("connection refused")).SetType(gin.ErrorTypePrivate)
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal server error", problem.Detail)
+	})
+
+	t.Run("No double write after abort", func(t *testing.T) {
+		w, problem := serveWithErrorHandler(t, func(c *gin.Context) {
+			AbortWithError(c, apperror.PermissionDenied("forbidden"))
+		}, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, apperror.CodePermissionDenied, problem.Code)
+	})
+
+	t.Run("No error", func(t *testing.T) {
+		w, _ := serveWithErrorHandler(t, func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
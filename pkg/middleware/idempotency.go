@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// IdempotencyKeyHeader 用戶端為每個操作產生的唯一鍵，重試時沿用
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 回應為先前保存的結果時設為 true
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// redisIdempotencyPrefix Redis 冪等鍵的前綴
+	redisIdempotencyPrefix = "idempotency:"
+	// maxIdempotencyKeyLength 冪等鍵的長度上限
+	maxIdempotencyKeyLength = 255
+)
+
+// 冪等紀錄狀態
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+)
+
+var (
+	// ErrInvalidIdempotencyKey 冪等鍵格式不正確
+	ErrInvalidIdempotencyKey = apperror.InvalidArgument("Idempotency-Key must be 1-255 printable ASCII characters")
+	// ErrIdempotencyKeyInUse 相同冪等鍵的請求仍在處理中
+	ErrIdempotencyKeyInUse = apperror.Conflict("a request with the same Idempotency-Key is still being processed")
+	// ErrIdempotencyKeyReused 冪等鍵已用於內容不同的請求
+	ErrIdempotencyKeyReused = apperror.InvalidArgument("Idempotency-Key was already used with a different request body")
+	// ErrIdempotentBodyTooLarge 帶有冪等鍵的請求 body 超過上限
+	ErrIdempotentBodyTooLarge = apperror.InvalidArgument("request body with Idempotency-Key is too large")
+)
+
+// IdempotencyConfig 冪等請求配置
+type IdempotencyConfig struct {
+	// 保存回應的時間（秒），期間內以相同冪等鍵重試會取得相同的回應
+	TTL int `validate:"min=1"`
+	// 處理中紀錄的保留時間（秒），需大於請求期限，行程在處理途中終止時鎖會在此之後釋放
+	LockTimeout int `validate:"min=1"`
+	// 計算請求指紋的 HMAC 金鑰，所有實例需相同；未持有金鑰時無法由 Redis 中的指紋比對出請求內容
+	FingerprintKey string `validate:"required" secret:"true"`
+	// 帶有冪等鍵的請求 body 上限（bytes），計算指紋時需完整讀入記憶體
+	MaxBodyBytes int64 `validate:"min=1"`
+}
+
+// idempotencyRecord 存放於 Redis 的冪等紀錄
+type idempotencyRecord struct {
+	State       string      `json:"state"`
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Idempotency 以 Idempotency-Key 標頭讓用戶端安全地重試非冪等請求，紀錄存放於 Redis，所有實例共用
+type Idempotency struct {
+	config IdempotencyConfig
+	client *redis.Client
+}
+
+// NewIdempotency 創建冪等請求中間件
+func NewIdempotency(config IdempotencyConfig, client *redis.Client) *Idempotency {
+	return &Idempotency{config: config, client: client}
+}
+
+// Middleware 掛在單一路由上，需放在 JWT 中間件之後，冪等鍵依 user_id（未登入時為 IP）、方法與路由區隔
+// 沒有 Idempotency-Key 的請求照常處理；帶有冪等鍵的 body 超過 MaxBodyBytes 時回傳 400；第一次的回應保存 TTL 秒，重試時直接回放並帶上 Idempotent-Replayed: true
+// 相同鍵的請求仍在處理中回傳 409，請求內容不同回傳 400；5xx 與交由錯誤處理的失敗不保存，重試會重新執行
+// 回應會存放於 Redis，不應掛在回傳 token 等機密資訊的路由上；Redis 無法使用時略過冪等檢查並照常處理
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			AbortWithError(c, ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, i.config.MaxBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				AbortWithError(c, ErrIdempotentBodyTooLarge)
+				return
+			}
+			AbortWithError(c, ErrInvalidRequest.Wrap(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := i.fingerprint(body)
+
+		ctx := c.Request.Context()
+		redisKey := redisIdempotencyPrefix + sha256Hex([]byte(UserKey(c)+"\n"+c.Request.Method+" "+c.FullPath()+"\n"+key))
+		record, acquired, err := i.acquire(ctx, redisKey, fingerprint)
+		if err != nil {
+			logger.FromContext(ctx).Warn("idempotency store unavailable, processing without idempotency", logger.Err(err))
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			switch {
+			case record.Fingerprint != fingerprint:
+				AbortWithError(c, ErrIdempotencyKeyReused)
+			case record.State == idempotencyProcessing:
+				AbortWithError(c, ErrIdempotencyKeyInUse)
+			default:
+				replay(c, record)
+			}
+			return
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		// 處理完成後請求的 context 可能已取消，仍需更新紀錄
+		ctx = context.WithoutCancel(ctx)
+		if !writer.Written() || writer.Status() >= http.StatusInternalServerError {
+			if err := i.client.Del(ctx, redisKey).Err(); err != nil {
+				logger.FromContext(ctx).Warn("failed to release idempotency key", logger.Err(err))
+			}
+			return
+		}
+		i.complete(ctx, redisKey, idempotencyRecord{
+			State:       idempotencyCompleted,
+			Fingerprint: fingerprint,
+			Status:      writer.Status(),
+			Header:      replayHeader(writer.Header()),
+			Body:        writer.body.Bytes(),
+		})
+	}
+}
+
+// fingerprint 以 HMAC-SHA256 計算請求 body 的指紋
+func (i *Idempotency) fingerprint(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(i.config.FingerprintKey))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// acquire 以 SETNX 建立處理中紀錄，已存在時回傳既有的紀錄
+// 既有紀錄在讀取前過期時再嘗試建立一次
+func (i *Idempotency) acquire(ctx context.Context, redisKey, fingerprint string) (idempotencyRecord, bool, error) {
+	processing, _ := json.Marshal(idempotencyRecord{State: idempotencyProcessing, Fingerprint: fingerprint})
+	lockTimeout := time.Duration(i.config.LockTimeout) * time.Second
+
+	for attempt := 0; attempt < 2; attempt++ {
+		acquired, err := i.client.SetNX(ctx, redisKey, processing, lockTimeout).Result()
+		if err != nil {
+			return idempotencyRecord{}, false, err
+		}
+		if acquired {
+			return idempotencyRecord{}, true, nil
+		}
+
+		value, err := i.client.Get(ctx, redisKey).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return idempotencyRecord{}, false, err
+		}
+		var record idempotencyRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return idempotencyRecord{}, false, err
+		}
+		return record, false, nil
+	}
+	return idempotencyRecord{State: idempotencyProcessing, Fingerprint: fingerprint}, false, nil
+}
+
+// complete 保存完成的回應
+func (i *Idempotency) complete(ctx context.Context, redisKey string, record idempotencyRecord) {
+	value, err := json.Marshal(record)
+	if err == nil {
+		err = i.client.Set(ctx, redisKey, value, time.Duration(i.config.TTL)*time.Second).Err()
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warn("failed to store idempotent response", logger.Err(err))
+	}
+}
+
+// replay 回放保存的回應
+func replay(c *gin.Context, record idempotencyRecord) {
+	for name, values := range record.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(record.Status)
+	_, _ = c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+// replayHeader 回放時沿用的回應標頭，其餘（如 X-Request-ID、RateLimit-*）屬於各次請求
+func replayHeader(header http.Header) http.Header {
+	result := make(http.Header)
+	for _, name := range []string{"Content-Type", "Location"} {
+		if values := header.Values(name); len(values) > 0 {
+			result[name] = values
+		}
+	}
+	return result
+}
+
+// validIdempotencyKey 冪等鍵為 1 到 255 個可列印的 ASCII 字元
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// sha256Hex 回傳 SHA-256 的十六進位字串
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// captureWriter 寫出回應的同時保留 body 供保存
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 實作 io.Writer
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 實作 io.StringWriter
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupIdempotencyRouter registers POST /users behind the idempotency middleware and counts handler calls.
+func setupIdempotencyRouter(t *testing.T) (*gin.Engine, *miniredis.Miniredis, *atomic.Int32, chan struct{}) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	idempotency := NewIdempotency(IdempotencyConfig{
+		TTL: 3600, LockTimeout: 60, FingerprintKey: "test-fingerprint-key", MaxBodyBytes: 64,
+	}, client)
+
+	var calls atomic.Int32
+	block := make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.POST("/users", func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("user_id", user)
+		}
+	}, idempotency.Middleware(), func(c *gin.Context) {
+		n := calls.Add(1)
+		switch c.Query("mode") {
+		case "block":
+			<-block
+		case "error":
+			_ = c.Error(errors.New("db down"))
+			return
+		case "conflict":
+			c.JSON(http.StatusConflict, gin.H{"call": n})
+			return
+		}
+		c.Header("Location", "/users/1")
+		c.Header("X-Call", "first")
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+	return router, mr, &calls, block
+}
+
+func idempotentPost(router *gin.Engine, path, key, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("Replays the first response", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		first := idempotentPost(router, "/users", "key-1", `{"email":"a@example.com"}`)
+		assert.Equal(t, http.StatusCreated, first.Code)
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+		second := idempotentPost(router, "/users", "key-1", `{"email":"a@example.com"}`)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, "/users/1", second.Header().Get("Location"))
+		assert.Equal(t, "application/json; charset=utf-8", second.Header().Get("Content-Type"))
+		assert.Empty(t, second.Header().Get("X-Call"), "only Content-Type and Location are replayed")
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Keys are scoped by user", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		idempotentPost(router, "/users", "key-1", `{}`, "X-User", "1")
+		w := idempotentPost(router, "/users", "key-1", `{}`, "X-User", "2")
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Without key", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		idempotentPost(router, "/users", "", `{}`)
+		idempotentPost(router, "/users", "", `{}`)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Different body", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		idempotentPost(router, "/users", "key-1", `{"email":"a@example.com"}`)
+		w := idempotentPost(router, "/users", "key-1", `{"email":"b@example.com"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "different request body")
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Fingerprint is keyed", func(t *testing.T) {
+		router, mr, _, _ := setupIdempotencyRouter(t)
+
+		body := `{"email":"a@example.com"}`
+		idempotentPost(router, "/users", "key-1", body)
+		keys := mr.Keys()
+		require.Len(t, keys, 1)
+		stored, err := mr.Get(keys[0])
+		require.NoError(t, err)
+		assert.NotContains(t, stored, sha256Hex([]byte(body)))
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		router, mr, calls, _ := setupIdempotencyRouter(t)
+
+		w := idempotentPost(router, "/users", "key-1", `{"email":"`+strings.Repeat("a", 64)+`@example.com"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "too large")
+		assert.Zero(t, calls.Load())
+		assert.Empty(t, mr.Keys())
+	})
+
+	t.Run("Concurrent duplicate", func(t *testing.T) {
+		router, _, calls, block := setupIdempotencyRouter(t)
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- idempotentPost(router, "/users?mode=block", "key-1", `{}`) }()
+		require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+		w := idempotentPost(router, "/users?mode=block", "key-1", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"conflict"`)
+
+		close(block)
+		assert.Equal(t, http.StatusCreated, (<-done).Code)
+	})
+
+	t.Run("Failures are not stored", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		w := idempotentPost(router, "/users?mode=error", "key-1", `{}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		w = idempotentPost(router, "/users?mode=error", "key-1", `{}`)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, int32(2), calls.Load(), "the retry is processed again")
+	})
+
+	t.Run("Client errors are stored", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		idempotentPost(router, "/users?mode=conflict", "key-1", `{}`)
+		w := idempotentPost(router, "/users?mode=conflict", "key-1", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		router, _, calls, _ := setupIdempotencyRouter(t)
+
+		w := idempotentPost(router, "/users", strings.Repeat("k", 256), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+
+	t.Run("Records expire", func(t *testing.T) {
+		router, mr, calls, _ := setupIdempotencyRouter(t)
+
+		idempotentPost(router, "/users", "key-1", `{}`)
+		mr.FastForward(time.Hour)
+		idempotentPost(router, "/users", "key-1", `{}`)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Redis unavailable", func(t *testing.T) {
+		router, mr, calls, _ := setupIdempotencyRouter(t)
+		mr.Close()
+
+		w := idempotentPost(router, "/users", "key-1", `{}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "requests are processed without idempotency")
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPolicies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := NewRateLimitPolicies(map[string]RateLimitConfig{
+		"login":   {Rate: "1/min"},
+		"default": {Rate: "2/min"},
+	}, nil)
+
+	router := gin.New()
+	router.POST("/login", policies.Middleware("login"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/users", policies.Middleware("default"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/search", policies.Middleware("search"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	perform := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	t.Run("Each policy has its own budget", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, perform(http.MethodPost, "/login").Code)
+		w := perform(http.MethodPost, "/login")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+		w = perform(http.MethodGet, "/users")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Unknown policy is not limited", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			w := perform(http.MethodGet, "/search")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("Update keeps state, adds and removes policies", func(t *testing.T) {
+		policies.Update(map[string]RateLimitConfig{
+			"login":  {Rate: "1/min"},
+			"search": {Rate: "1/min"},
+		})
+
+		assert.Equal(t, http.StatusTooManyRequests, perform(http.MethodPost, "/login").Code, "login budget is kept")
+		assert.Equal(t, http.StatusOK, perform(http.MethodGet, "/search").Code)
+		assert.Equal(t, http.StatusTooManyRequests, perform(http.MethodGet, "/search").Code)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, perform(http.MethodGet, "/users").Code, "removed policy no longer limits")
+		}
+	})
+}
+
+func TestRateLimitPoliciesBackendChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	policies := NewRateLimitPolicies(map[string]RateLimitConfig{"login": {Rate: "1/min", Backend: BackendLocal}}, client)
+	router := gin.New()
+	router.POST("/login", policies.Middleware("login"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	perform := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+		return w.Code
+	}
+	redisKeys := func() int {
+		count := 0
+		for _, key := range mr.Keys() {
+			if strings.HasPrefix(key, redisRateLimitPrefix+"login:") {
+				count++
+			}
+		}
+		return count
+	}
+
+	assert.Equal(t, http.StatusOK, perform())
+	assert.Equal(t, http.StatusTooManyRequests, perform())
+	assert.Zero(t, redisKeys())
+
+	// 切換為 redis 後改由 Redis 計算，從空的狀態開始
+	policies.Update(map[string]RateLimitConfig{"login": {Rate: "1/min", Backend: BackendRedis}})
+	assert.Equal(t, http.StatusOK, perform())
+	assert.Equal(t, http.StatusTooManyRequests, perform())
+	assert.Equal(t, 1, redisKeys())
+
+	// 只調整速率時保留 Redis 中的狀態
+	policies.Update(map[string]RateLimitConfig{"login": {Rate: "2/min", Backend: BackendRedis}})
+	assert.Equal(t, http.StatusTooManyRequests, perform())
+
+	mr.FlushAll()
+	policies.Update(map[string]RateLimitConfig{"login": {Rate: "1/min", Backend: BackendLocal}})
+	assert.Equal(t, http.StatusOK, perform())
+	assert.Equal(t, http.StatusTooManyRequests, perform())
+	assert.Zero(t, redisKeys())
+}
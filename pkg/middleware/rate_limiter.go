@@ -1,74 +1,382 @@
 package middleware
 
 import (
-	"net/http"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
 	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// KeyByIP 依用戶端 IP 限流
+	KeyByIP = "ip"
+	// KeyByUser 依 JWT 中間件設定的 user_id 限流，未登入時改用 IP
+	KeyByUser = "user"
+	// KeyByAPIKey 依 API key 標頭限流，未帶標頭時改用 IP
+	KeyByAPIKey = "api_key"
+)
+
+const (
+	// rateLimitShards 令牌桶分片數量，每個分片各自加鎖以降低競爭
+	rateLimitShards = 32
+	// defaultIdleTimeout 未配置時令牌桶的閒置回收時間
+	defaultIdleTimeout = 10 * time.Minute
+	// defaultAPIKeyHeader 未配置時讀取 API key 的標頭
+	defaultAPIKeyHeader = "X-API-Key"
+	// rateLimitPolicyKey 具名策略在 gin.Context 中的鍵，用於指標標籤
+	rateLimitPolicyKey = "rate_limit_policy"
+	// globalRateLimitPolicy 全域限流器在指標中的策略名稱
+	globalRateLimitPolicy = "global"
+)
+
+const (
+	// BackendLocal 各實例各自計算限流
+	BackendLocal = "local"
+	// BackendRedis 所有實例透過 Redis 共用限流狀態
+	BackendRedis = "redis"
+)
+
+const (
+	// FailureModeLocal Redis 無法連線時改用本機限流器
+	FailureModeLocal = "local"
+	// FailureModeOpen Redis 無法連線時放行所有請求
+	FailureModeOpen = "open"
+	// FailureModeClosed Redis 無法連線時拒絕所有請求
+	FailureModeClosed = "closed"
+)
+
 // RateLimitConfig 速率限制配置
 type RateLimitConfig struct {
 	// 每秒請求數
-	RequestsPerSecond int
+	RequestsPerSecond int `validate:"min=0"`
+	// 請求速率，如 5/min、100/s，設定時取代 RequestsPerSecond，未設定 Burst 時突發請求數即為次數
+	Rate string `validate:"omitempty,ratelimit"`
 	// 突發請求數
-	Burst int
+	Burst int `validate:"min=0"`
+	// 限流鍵：ip、user、api_key，預設為 ip
+	KeyBy string `validate:"omitempty,oneof=ip user api_key"`
+	// KeyBy 為 api_key 時讀取的標頭，預設為 X-API-Key
+	APIKeyHeader string
+	// 令牌桶閒置多久（秒）後回收，小於補滿所需時間時以補滿所需時間為準，避免回收後提早補滿；
+	// 速率為 0 的令牌桶不會補充，回收後即重新補滿
+	IdleTimeout int `validate:"min=0"`
+	// 限流狀態的存放位置：local 為各實例各自計算，redis 為所有實例共用，預設為 local
+	Backend string `validate:"omitempty,oneof=local redis"`
+	// Redis 無法連線時的處理方式：local 改用本機限流器，open 全部放行，closed 全部拒絕，預設為 local
+	FailureMode string `validate:"omitempty,oneof=local open closed"`
+}
+
+// ErrRateLimited 超過速率限制
+var ErrRateLimited = apperror.RateLimited("rate limit exceeded")
+
+// KeyFunc 從請求取得限流鍵，相同鍵的請求共用一個令牌桶
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitResult 單次限流判斷的結果，用於回應 RateLimit-* 標頭
+type RateLimitResult struct {
+	// 是否放行
+	Allowed bool
+	// 突發請求數，為 0 時不回應標頭
+	Limit int
+	// 剩餘可立即發出的請求數
+	Remaining int
+	// 令牌桶補滿所需時間
+	ResetAfter time.Duration
+	// 被拒絕時，至少需等待多久才能再次請求
+	RetryAfter time.Duration
+}
+
+// Limiter 可熱更新配置的限流器
+type Limiter interface {
+	// Update 套用新的配置
+	Update(config RateLimitConfig)
+	// Middleware 限制請求速率的中間件
+	Middleware() gin.HandlerFunc
 }
 
-// TODO Each IP
-// RateLimiter 速率限制中間件
+// RateLimiter 速率限制中間件，依 config.KeyBy 為每個用戶端分配令牌桶
 func RateLimiter(config RateLimitConfig) gin.HandlerFunc {
-	// 使用令牌桶算法實現速率限制
-	var (
-		tokens     = config.Burst
-		lastUpdate = time.Now()
-		mu         sync.Mutex
-	)
+	return NewKeyedLimiter(config, nil).Middleware()
+}
 
-	return func(c *gin.Context) {
-		mu.Lock()
-		defer mu.Unlock()
-
-		// 計算時間差並更新令牌
-		now := time.Now()
-		elapsed := now.Sub(lastUpdate)
-		lastUpdate = now
-
-		// 根據時間差添加令牌
-		newTokens := int(elapsed.Seconds() * float64(config.RequestsPerSecond))
-		if newTokens > 0 {
-			tokens = min(tokens+newTokens, config.Burst)
+// ParseRate 解析 5/min、100/s 格式的請求速率，單位可為 s、sec、second、m、min、minute、h、hour
+func ParseRate(rate string) (int, time.Duration, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(rate), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid rate %q: expected <count>/<unit>", rate)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || limit < 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q: count must be a non-negative integer", rate)
+	}
+
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	default:
+		return 0, 0, fmt.Errorf("invalid rate %q: unknown unit %q", rate, unit)
+	}
+	return limit, period, nil
+}
+
+// ClientIPKey 以用戶端 IP 作為限流鍵
+func ClientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// UserKey 以 user_id 作為限流鍵，需放在 JWT 中間件之後，未登入時改用 IP
+func UserKey(c *gin.Context) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return ClientIPKey(c)
+}
+
+// APIKeyKey 以 API key 的雜湊作為限流鍵，避免 key 本身留在記憶體與日誌中，未帶標頭時改用 IP
+func APIKeyKey(header string) KeyFunc {
+	return func(c *gin.Context) string {
+		apiKey := c.GetHeader(header)
+		if apiKey == "" {
+			return ClientIPKey(c)
 		}
+		sum := sha256.Sum256([]byte(apiKey))
+		return "api_key:" + hex.EncodeToString(sum[:8])
+	}
+}
 
-		// 檢查是否有足夠的令牌
-		if tokens <= 0 {
-			logger.Warn("rate limit exceeded",
-				logger.String("path", c.Request.URL.Path),
-				logger.String("method", c.Request.Method),
-				logger.String("ip", c.ClientIP()),
-			)
-
-			c.JSON(http.StatusTooManyRequests, ErrorResponse{
-				Code:    http.StatusTooManyRequests,
-				Message: "rate limit exceeded",
-			})
-			c.Abort()
-			return
+// KeyFuncFor 依 config.KeyBy 選擇限流鍵
+func KeyFuncFor(config RateLimitConfig) KeyFunc {
+	switch config.KeyBy {
+	case KeyByUser:
+		return UserKey
+	case KeyByAPIKey:
+		header := config.APIKeyHeader
+		if header == "" {
+			header = defaultAPIKeyHeader
 		}
+		return APIKeyKey(header)
+	default:
+		return ClientIPKey
+	}
+}
+
+// KeyedLimiter 為每個限流鍵維護獨立的令牌桶，配置可在執行期間更新
+type KeyedLimiter struct {
+	settings atomic.Pointer[limiterSettings]
+	// custom 自訂的限流鍵，設定時不隨配置的 KeyBy 變動
+	custom KeyFunc
+	shards [rateLimitShards]limiterShard
+	now    func() time.Time
+}
+
+// limiterSettings 目前生效的配置，更新時整個替換
+type limiterSettings struct {
+	config  RateLimitConfig
+	keyFunc KeyFunc
+	// rate 每秒補充的令牌數
+	rate        float64
+	burst       int
+	idleTimeout time.Duration
+}
+
+// limiterShard 令牌桶分片
+type limiterShard struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket 單一限流鍵的令牌桶，由所屬分片的鎖保護
+type tokenBucket struct {
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// NewKeyedLimiter 創建新的分鍵限流器，keyFunc 為 nil 時依 config.KeyBy 選擇
+func NewKeyedLimiter(config RateLimitConfig, keyFunc KeyFunc) *KeyedLimiter {
+	l := &KeyedLimiter{custom: keyFunc, now: time.Now}
+	for i := range l.shards {
+		l.shards[i].buckets = make(map[string]*tokenBucket)
+	}
+	l.Update(config)
+	return l
+}
 
-		// 消耗一個令牌
-		tokens--
+// Update 套用新的配置，既有令牌桶保留目前的令牌數，下次請求時以新的速率補充並以新的突發上限截斷
+func (l *KeyedLimiter) Update(config RateLimitConfig) {
+	l.settings.Store(newLimiterSettings(config, l.custom))
+}
+
+// Allow 嘗試消耗 key 的一個令牌
+func (l *KeyedLimiter) Allow(key string) RateLimitResult {
+	settings := l.settings.Load()
+	now := l.now()
+
+	shard := l.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.Sub(shard.lastSweep) >= settings.idleTimeout {
+		shard.sweep(now, settings.idleTimeout)
+	}
+
+	burst := float64(settings.burst)
+	bucket, ok := shard.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, lastUpdate: now}
+		shard.buckets[key] = bucket
+	} else {
+		bucket.refill(now, settings.rate, burst)
+	}
+
+	result := RateLimitResult{Limit: settings.burst}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = settings.durationFor(1 - bucket.tokens)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.ResetAfter = settings.durationFor(burst - bucket.tokens)
+	return result
+}
+
+// Len 目前追蹤的限流鍵數量
+func (l *KeyedLimiter) Len() int {
+	total := 0
+	for i := range l.shards {
+		l.shards[i].mu.Lock()
+		total += len(l.shards[i].buckets)
+		l.shards[i].mu.Unlock()
+	}
+	return total
+}
+
+// Middleware 以分鍵令牌桶限制請求速率的中間件
+func (l *KeyedLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := l.settings.Load().keyFunc(c)
+		result := l.Allow(key)
+		setRateLimitHeaders(c, result)
+		if !result.Allowed {
+			abortRateLimited(c, key, result)
+			return
+		}
 		c.Next()
 	}
 }
 
-// min 返回兩個整數中的較小值
-func min(a, b int) int {
-	if a < b {
-		return a
+// newLimiterSettings 套用預設值並選擇限流鍵，custom 不為 nil 時優先使用
+func newLimiterSettings(config RateLimitConfig, custom KeyFunc) *limiterSettings {
+	settings := &limiterSettings{
+		config:      config,
+		keyFunc:     custom,
+		rate:        float64(config.RequestsPerSecond),
+		burst:       config.Burst,
+		idleTimeout: time.Duration(config.IdleTimeout) * time.Second,
+	}
+	if config.Rate != "" {
+		limit, period, err := ParseRate(config.Rate)
+		if err != nil {
+			// 經過配置驗證時不會發生，直接建構的配置則沿用 RequestsPerSecond
+			logger.Warn("invalid rate limit rate", logger.String("rate", config.Rate), logger.Err(err))
+		} else {
+			settings.rate = float64(limit) / period.Seconds()
+			if settings.burst == 0 {
+				settings.burst = limit
+			}
+		}
+	}
+	if settings.keyFunc == nil {
+		settings.keyFunc = KeyFuncFor(config)
+	}
+	if settings.idleTimeout <= 0 {
+		settings.idleTimeout = defaultIdleTimeout
+	}
+	// 閒置時間至少要等於補滿所需時間，回收的令牌桶才與新建的相同
+	if settings.rate > 0 {
+		settings.idleTimeout = max(settings.idleTimeout, settings.durationFor(float64(settings.burst)))
 	}
-	return b
+	return settings
+}
+
+// durationFor 補充指定數量的令牌所需時間，不會補充令牌時以閒置回收時間代替
+func (s *limiterSettings) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if s.rate <= 0 {
+		return s.idleTimeout
+	}
+	return time.Duration(tokens / s.rate * float64(time.Second))
+}
+
+// setRateLimitHeaders 回應 RateLimit-Limit、RateLimit-Remaining 與 RateLimit-Reset（秒）標頭
+func setRateLimitHeaders(c *gin.Context, result RateLimitResult) {
+	if result.Limit <= 0 {
+		return
+	}
+	header := c.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds 將時間無條件進位為秒
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// abortRateLimited 以 429 拒絕超過速率的請求，Retry-After 至少為 1 秒，限流鍵只寫入日誌
+// 並依策略名稱記錄 rate_limit_rejections_total，全域限流器的策略名稱為 global
+func abortRateLimited(c *gin.Context, key string, result RateLimitResult) {
+	policy := c.GetString(rateLimitPolicyKey)
+	if policy == "" {
+		policy = globalRateLimitPolicy
+	}
+	metrics.RecordRateLimitRejection(policy)
+
+	c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+	AbortWithError(c, ErrRateLimited.Wrap(fmt.Errorf("key %s", key)))
+}
+
+// shard 依限流鍵的雜湊選擇分片
+func (l *KeyedLimiter) shard(key string) *limiterShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &l.shards[h.Sum32()%rateLimitShards]
+}
+
+// sweep 回收閒置的令牌桶，每個分片每隔 idleTimeout 才檢查一次
+func (s *limiterShard) sweep(now time.Time, idleTimeout time.Duration) {
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.lastUpdate) >= idleTimeout {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// refill 根據時間差添加令牌，以浮點數累積避免高頻請求時令牌永遠無法補充
+func (b *tokenBucket) refill(now time.Time, rate, burst float64) {
+	elapsed := now.Sub(b.lastUpdate).Seconds()
+	b.lastUpdate = now
+	b.tokens = math.Min(b.tokens+elapsed*rate, burst)
 }
@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced clock for the limiter.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestLimiter creates a limiter driven by a fake clock.
+func newTestLimiter(config RateLimitConfig, keyFunc KeyFunc) (*KeyedLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	limiter := NewKeyedLimiter(config, keyFunc)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestKeyedLimiterPerKey(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 2}, nil)
+
+	assert.True(t, limiter.Allow("a").Allowed)
+	assert.True(t, limiter.Allow("a").Allowed)
+	assert.False(t, limiter.Allow("a").Allowed)
+
+	// Another client has its own bucket.
+	assert.True(t, limiter.Allow("b").Allowed)
+
+	clock.Advance(500 * time.Millisecond)
+	assert.False(t, limiter.Allow("a").Allowed, "half a token is not enough")
+	clock.Advance(500 * time.Millisecond)
+	assert.True(t, limiter.Allow("a").Allowed)
+	assert.False(t, limiter.Allow("a").Allowed)
+}
+
+func TestKeyedLimiterUpdate(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 1}, nil)
+
+	assert.True(t, limiter.Allow("a").Allowed)
+	assert.False(t, limiter.Allow("a").Allowed)
+
+	limiter.Update(RateLimitConfig{RequestsPerSecond: 10, Burst: 3})
+	clock.Advance(time.Second)
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("a").Allowed, "refilled up to the new burst")
+	}
+	assert.False(t, limiter.Allow("a").Allowed)
+}
+
+func TestKeyedLimiterEviction(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 1, IdleTimeout: 60}, nil)
+
+	for i := 0; i < 100; i++ {
+		limiter.Allow(fmt.Sprintf("client-%d", i))
+	}
+	assert.Equal(t, 100, limiter.Len())
+
+	clock.Advance(30 * time.Second)
+	limiter.Allow("client-0")
+	clock.Advance(31 * time.Second)
+	for i := 0; i < rateLimitShards*4; i++ {
+		limiter.Allow(fmt.Sprintf("fresh-%d", i))
+	}
+
+	// Idle buckets are swept from every shard touched; client-0 was seen recently.
+	assert.Less(t, limiter.Len(), 100+rateLimitShards*4)
+	limiter.shard("client-0").mu.Lock()
+	_, ok := limiter.shard("client-0").buckets["client-0"]
+	limiter.shard("client-0").mu.Unlock()
+	assert.True(t, ok)
+}
+
+func TestKeyedLimiterEvictionSlowPolicy(t *testing.T) {
+	// 5/hour refills in an hour, so a bucket idle for longer than IdleTimeout must not be swept early.
+	limiter, clock := newTestLimiter(RateLimitConfig{Rate: "5/hour", IdleTimeout: 60}, nil)
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, limiter.Allow("a").Allowed)
+	}
+	assert.False(t, limiter.Allow("a").Allowed)
+
+	clock.Advance(2 * time.Minute)
+	assert.False(t, limiter.Allow("a").Allowed, "eviction must not refill the bucket")
+
+	clock.Advance(time.Hour)
+	for i := 0; i < 5; i++ {
+		assert.True(t, limiter.Allow("a").Allowed)
+	}
+	assert.False(t, limiter.Allow("a").Allowed)
+}
+
+func TestKeyedLimiterConcurrent(t *testing.T) {
+	limiter, _ := newTestLimiter(RateLimitConfig{RequestsPerSecond: 0, Burst: 10}, nil)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := map[string]int{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				key := fmt.Sprintf("client-%d", j%5)
+				if limiter.Allow(key).Allowed {
+					mu.Lock()
+					allowed[key]++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for key, count := range allowed {
+		assert.Equal(t, 10, count, key)
+	}
+	assert.Len(t, allowed, 5)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newContext := func(header string, value string, userID interface{}) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		if header != "" {
+			c.Request.Header.Set(header, value)
+		}
+		if userID != nil {
+			c.Set("user_id", userID)
+		}
+		return c
+	}
+
+	assert.Equal(t, "ip:10.0.0.1", ClientIPKey(newContext("", "", nil)))
+	assert.Equal(t, "user:7", UserKey(newContext("", "", uint(7))))
+	assert.Equal(t, "ip:10.0.0.1", UserKey(newContext("", "", nil)))
+
+	apiKey := KeyFuncFor(RateLimitConfig{KeyBy: KeyByAPIKey})
+	key := apiKey(newContext("X-API-Key", "secret-key", nil))
+	assert.Regexp(t, `^api_key:[0-9a-f]{16}$`, key)
+	assert.NotContains(t, key, "secret-key")
+	assert.Equal(t, key, apiKey(newContext("X-API-Key", "secret-key", nil)))
+	assert.NotEqual(t, key, apiKey(newContext("X-API-Key", "other-key", nil)))
+	assert.Equal(t, "ip:10.0.0.1", apiKey(newContext("", "", nil)))
+
+	custom := KeyFuncFor(RateLimitConfig{KeyBy: KeyByAPIKey, APIKeyHeader: "X-Token"})
+	assert.Equal(t, key, custom(newContext("X-Token", "secret-key", nil)))
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewKeyedLimiter(RateLimitConfig{RequestsPerSecond: 0, Burst: 1}, func(c *gin.Context) string {
+		return c.GetHeader("X-Tenant")
+	})
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	perform := func(tenant string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, perform("acme"))
+	assert.Equal(t, http.StatusTooManyRequests, perform("acme"))
+	assert.Equal(t, http.StatusOK, perform("globex"))
+
+	// A custom key function survives configuration updates.
+	limiter.Update(RateLimitConfig{RequestsPerSecond: 0, Burst: 1, KeyBy: KeyByIP})
+	assert.Equal(t, http.StatusOK, perform("initech"))
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate   string
+		limit  int
+		period time.Duration
+		valid  bool
+	}{
+		{"5/min", 5, time.Minute, true},
+		{"100/s", 100, time.Second, true},
+		{" 1000 / hour ", 1000, time.Hour, true},
+		{"0/s", 0, time.Second, true},
+		{"5", 0, 0, false},
+		{"-1/s", 0, 0, false},
+		{"5/fortnight", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			limit, period, err := ParseRate(tt.rate)
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.limit, limit)
+			assert.Equal(t, tt.period, period)
+		})
+	}
+}
+
+func TestKeyedLimiterResult(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{Rate: "6/min"}, nil)
+
+	result := limiter.Allow("a")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 6, result.Limit, "burst defaults to the rate count")
+	assert.Equal(t, 5, result.Remaining)
+	assert.Equal(t, 10*time.Second, result.ResetAfter)
+
+	for i := 0; i < 5; i++ {
+		limiter.Allow("a")
+	}
+	result = limiter.Allow("a")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 10*time.Second, result.RetryAfter)
+	assert.Equal(t, time.Minute, result.ResetAfter)
+
+	clock.Advance(4 * time.Second)
+	result = limiter.Allow("a")
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, 6*time.Second, result.RetryAfter, float64(time.Millisecond))
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimiter(RateLimitConfig{Rate: "2/min"}))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	perform := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	w := perform()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	perform()
+	w = perform()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// redisRateLimitPrefix Redis 限流鍵的前綴
+	redisRateLimitPrefix = "ratelimit:"
+	// redisRateLimitTimeout 單次限流判斷等待 Redis 的上限，避免 Redis 異常時拖慢所有請求
+	redisRateLimitTimeout = 100 * time.Millisecond
+)
+
+// ErrLimiterUnavailable Redis 無法連線且配置為 closed 時回傳
+var ErrLimiterUnavailable = apperror.Unavailable("rate limiter unavailable")
+
+// gcraScript 以 GCRA 演算法判斷是否放行，Redis 中只保存理論到達時間（TAT，微秒）
+// 時間取自 Redis 的 TIME，各實例的時鐘誤差不影響結果；鍵在令牌桶補滿時過期
+// KEYS[1] 限流鍵，ARGV[1] 產生一個令牌的間隔（微秒），ARGV[2] 突發請求數
+// 回傳 {是否放行, 剩餘請求數, 需等待的微秒數, 補滿所需的微秒數}
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+if new_tat - now > burst * interval then
+	return {0, 0, new_tat - now - burst * interval, tat - now}
+end
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((burst * interval - (new_tat - now)) / interval), 0, new_tat - now}
+`)
+
+// NewLimiter 依 config.Backend 創建限流器，選擇 redis 但未提供客戶端時改用本機限流器
+func NewLimiter(config RateLimitConfig, client *redis.Client) Limiter {
+	if config.Backend != BackendRedis {
+		return NewKeyedLimiter(config, nil)
+	}
+	if client == nil {
+		logger.Warn("rate limit backend is redis but no client is configured, using local limiter")
+		return NewKeyedLimiter(config, nil)
+	}
+	return NewRedisLimiter(client, config, nil)
+}
+
+// ReloadableLimiter 隨配置熱更新的限流器，backend 變更時以 build 重建底層限流器，其餘變更保留限流狀態
+type ReloadableLimiter struct {
+	mu      sync.Mutex
+	build   func(config RateLimitConfig) Limiter
+	backend string
+	current atomic.Pointer[reloadableEntry]
+}
+
+// reloadableEntry 目前使用的限流器與其中間件
+type reloadableEntry struct {
+	limiter Limiter
+	handler gin.HandlerFunc
+}
+
+// NewReloadableLimiter 創建可熱更新的限流器，build 依配置創建底層限流器
+func NewReloadableLimiter(config RateLimitConfig, build func(config RateLimitConfig) Limiter) *ReloadableLimiter {
+	l := &ReloadableLimiter{build: build}
+	l.Update(config)
+	return l
+}
+
+// Update 套用新的配置，backend 改變時（local 與 redis 互換）重建底層限流器，新的限流器從空的狀態開始計算
+func (l *ReloadableLimiter) Update(config RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if current := l.current.Load(); current != nil && config.Backend == l.backend {
+		current.limiter.Update(config)
+		return
+	}
+	limiter := l.build(config)
+	l.backend = config.Backend
+	l.current.Store(&reloadableEntry{limiter: limiter, handler: limiter.Middleware()})
+}
+
+// Middleware 以目前的底層限流器處理請求，重建後不需重新註冊
+func (l *ReloadableLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.current.Load().handler(c)
+	}
+}
+
+// RedisLimiter 透過 Redis 讓所有實例共用限流狀態，Redis 無法連線時依 FailureMode 處理
+type RedisLimiter struct {
+	client   *redis.Client
+	settings atomic.Pointer[limiterSettings]
+	// custom 自訂的限流鍵，設定時不隨配置的 KeyBy 變動
+	custom KeyFunc
+	// local Redis 無法連線時使用的本機限流器
+	local *KeyedLimiter
+	// degraded 目前是否無法連線 Redis，只在狀態轉換時記錄日誌
+	degraded atomic.Bool
+	// prefix Redis 鍵的前綴，具名策略各自使用獨立的前綴
+	prefix string
+}
+
+// NewRedisLimiter 創建新的 Redis 限流器，keyFunc 為 nil 時依 config.KeyBy 選擇
+func NewRedisLimiter(client *redis.Client, config RateLimitConfig, keyFunc KeyFunc) *RedisLimiter {
+	l := &RedisLimiter{
+		client: client,
+		custom: keyFunc,
+		local:  NewKeyedLimiter(config, keyFunc),
+		prefix: redisRateLimitPrefix,
+	}
+	l.settings.Store(newLimiterSettings(config, keyFunc))
+	return l
+}
+
+// Update 套用新的配置，Redis 中的狀態在下次請求時以新的速率計算
+func (l *RedisLimiter) Update(config RateLimitConfig) {
+	l.settings.Store(newLimiterSettings(config, l.custom))
+	l.local.Update(config)
+}
+
+// Allow 嘗試消耗 key 的一個令牌，只有 Redis 無法連線且配置為 closed 時回傳錯誤
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	settings := l.settings.Load()
+
+	result, err := l.allowRedis(ctx, key, settings)
+	if err == nil {
+		if l.degraded.CompareAndSwap(true, false) {
+			logger.Info("rate limiter redis recovered")
+		}
+		return result, nil
+	}
+
+	if l.degraded.CompareAndSwap(false, true) {
+		logger.Warn("rate limiter redis unavailable",
+			logger.String("failure_mode", settings.config.FailureMode),
+			logger.Err(err),
+		)
+	}
+
+	switch settings.config.FailureMode {
+	case FailureModeOpen:
+		return RateLimitResult{Allowed: true}, nil
+	case FailureModeClosed:
+		return RateLimitResult{}, ErrLimiterUnavailable
+	default:
+		return l.local.Allow(key), nil
+	}
+}
+
+// Middleware 以 Redis 限制請求速率的中間件
+func (l *RedisLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := l.settings.Load().keyFunc(c)
+		result, err := l.Allow(c.Request.Context(), key)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		setRateLimitHeaders(c, result)
+		if !result.Allowed {
+			abortRateLimited(c, key, result)
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowRedis 執行 GCRA 腳本
+func (l *RedisLimiter) allowRedis(ctx context.Context, key string, settings *limiterSettings) (RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisRateLimitTimeout)
+	defer cancel()
+
+	// 不會補充令牌時以閒置時間作為間隔，與本機限流器回收令牌桶後重新補滿的行為一致
+	interval := float64(settings.idleTimeout.Microseconds())
+	if settings.rate > 0 {
+		interval = float64(time.Second.Microseconds()) / settings.rate
+	}
+
+	values, err := gcraScript.Run(ctx, l.client, []string{l.prefix + key}, interval, settings.burst).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      settings.burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRedisLimiter creates a limiter backed by miniredis with a fixed clock.
+func setupRedisLimiter(t *testing.T, config RateLimitConfig) (*RedisLimiter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Unix(1700000000, 0))
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisLimiter(client, config, nil), mr
+}
+
+func TestRedisLimiterGCRA(t *testing.T) {
+	ctx := context.Background()
+	limiter, mr := setupRedisLimiter(t, RateLimitConfig{RequestsPerSecond: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed, "request %d within burst", i)
+	}
+	result, err := limiter.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// Another key is independent.
+	result, err = limiter.Allow(ctx, "b")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// One token is emitted every 500ms.
+	mr.SetTime(time.Unix(1700000000, 0).Add(500 * time.Millisecond))
+	result, _ = limiter.Allow(ctx, "a")
+	assert.True(t, result.Allowed)
+	result, _ = limiter.Allow(ctx, "a")
+	assert.False(t, result.Allowed)
+
+	// The key expires once the bucket is full again.
+	assert.True(t, mr.Exists(redisRateLimitPrefix+"a"))
+	mr.FastForward(2 * time.Second)
+	assert.False(t, mr.Exists(redisRateLimitPrefix+"a"))
+}
+
+func TestRedisLimiterResult(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := setupRedisLimiter(t, RateLimitConfig{Rate: "6/min"})
+
+	result, err := limiter.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitResult{Allowed: true, Limit: 6, Remaining: 5, ResetAfter: 10 * time.Second}, result)
+
+	for i := 0; i < 5; i++ {
+		_, _ = limiter.Allow(ctx, "a")
+	}
+	result, err = limiter.Allow(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitResult{Limit: 6, RetryAfter: 10 * time.Second, ResetAfter: time.Minute}, result)
+}
+
+func TestRedisLimiterSharedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	first, mr := setupRedisLimiter(t, RateLimitConfig{RequestsPerSecond: 1, Burst: 2})
+	second := NewRedisLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}), RateLimitConfig{RequestsPerSecond: 1, Burst: 2}, nil)
+
+	result, _ := first.Allow(ctx, "a")
+	assert.True(t, result.Allowed)
+	result, _ = second.Allow(ctx, "a")
+	assert.True(t, result.Allowed)
+	result, _ = first.Allow(ctx, "a")
+	assert.False(t, result.Allowed, "both instances share one budget")
+}
+
+func TestRedisLimiterFailureModes(t *testing.T) {
+	ctx := context.Background()
+	config := RateLimitConfig{RequestsPerSecond: 0, Burst: 1}
+
+	newUnavailable := func(mode string) *RedisLimiter {
+		limiter, mr := setupRedisLimiter(t, config)
+		config := config
+		config.FailureMode = mode
+		limiter.Update(config)
+		mr.Close()
+		return limiter
+	}
+
+	t.Run("Local fallback", func(t *testing.T) {
+		limiter := newUnavailable(FailureModeLocal)
+		result, err := limiter.Allow(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		result, err = limiter.Allow(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, result.Allowed, "local limiter enforces the same burst")
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		limiter := newUnavailable(FailureModeOpen)
+		for i := 0; i < 3; i++ {
+			result, err := limiter.Allow(ctx, "a")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		limiter := newUnavailable(FailureModeClosed)
+		result, err := limiter.Allow(ctx, "a")
+		assert.ErrorIs(t, err, ErrLimiterUnavailable)
+		assert.False(t, result.Allowed)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(limiter.Middleware())
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestNewLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	assert.IsType(t, &KeyedLimiter{}, NewLimiter(RateLimitConfig{}, client))
+	assert.IsType(t, &RedisLimiter{}, NewLimiter(RateLimitConfig{Backend: BackendRedis}, client))
+	assert.IsType(t, &KeyedLimiter{}, NewLimiter(RateLimitConfig{Backend: BackendRedis}, nil))
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader 請求 ID 標頭，用戶端或上游提供時沿用，否則產生新的 ID
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey 請求 ID 在 gin.Context 中的鍵
+	RequestIDKey = "request_id"
+	// maxRequestIDLength 沿用上游請求 ID 的最大長度，過長或含有非法字元時重新產生
+	maxRequestIDLength = 128
+)
+
+// RequestLogger 以 zap 記錄每個請求的存取日誌，並設定 X-Request-ID 回應標頭
+// 需放在追蹤中間件之後，存取日誌與請求日誌才會帶有 trace_id
+// 帶有 request_id 的請求日誌存放於 c.Request.Context()，處理器與服務以 logger.FromContext 取得
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		requestLogger := logger.Log.With(logger.String(RequestIDKey, requestID))
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		// 未匹配的路由沒有模板，以固定值避免將任意路徑寫入 route 欄位
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := []logger.Field{
+			logger.String("method", c.Request.Method),
+			logger.String("route", route),
+			logger.Int("status", c.Writer.Status()),
+			logger.Duration("latency", time.Since(start)),
+			logger.Int("bytes", max(c.Writer.Size(), 0)),
+			logger.String("client_ip", c.ClientIP()),
+		}
+		// user_id 由 JWT 中間件設定，未登入的請求沒有此欄位
+		if userID, ok := c.Get("user_id"); ok {
+			fields = append(fields, logger.Any("user_id", userID))
+		}
+		// 以 context 取得日誌，啟用追蹤時帶有 trace_id
+		logger.FromContext(c.Request.Context()).Info("request", fields...)
+	}
+}
+
+// isValidRequestID 上游的請求 ID 只接受長度有限的英數字與 -_.: 字元，避免日誌注入
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 產生 32 個十六進位字元的隨機請求 ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeLogs replaces the global logger with an observer for the duration of the test.
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zap.DebugLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = previous })
+	return logs
+}
+
+func setupRequestLogRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestLogger())
+	router.GET("/users/:user_id", func(c *gin.Context) {
+		c.Set("user_id", uint(42))
+		logger.FromContext(c.Request.Context()).Info("handler")
+		c.String(http.StatusOK, "hello")
+	})
+	return router
+}
+
+func TestRequestLogger(t *testing.T) {
+	t.Run("Access log", func(t *testing.T) {
+		logs := observeLogs(t)
+		w := httptest.NewRecorder()
+		router := setupRequestLogRouter()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+		requestID := w.Header().Get(RequestIDHeader)
+		assert.Len(t, requestID, 32)
+
+		entries := logs.All()
+		require.Len(t, entries, 2)
+		assert.Equal(t, requestID, entries[0].ContextMap()[RequestIDKey], "handlers log with the request ID")
+
+		fields := entries[1].ContextMap()
+		assert.Equal(t, "request", entries[1].Message)
+		assert.Equal(t, requestID, fields[RequestIDKey])
+		assert.Equal(t, "GET", fields["method"])
+		assert.Equal(t, "/users/:user_id", fields["route"])
+		assert.Equal(t, int64(http.StatusOK), fields["status"])
+		assert.Equal(t, int64(5), fields["bytes"])
+		assert.Equal(t, uint64(42), fields["user_id"])
+		assert.Contains(t, fields, "latency")
+		assert.Contains(t, fields, "client_ip")
+	})
+
+	t.Run("Propagates request ID", func(t *testing.T) {
+		observeLogs(t)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		req.Header.Set(RequestIDHeader, "upstream-id.1")
+		setupRequestLogRouter().ServeHTTP(w, req)
+
+		assert.Equal(t, "upstream-id.1", w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("Replaces invalid request ID", func(t *testing.T) {
+		observeLogs(t)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		req.Header.Set(RequestIDHeader, "bad id\nforged=1")
+		setupRequestLogRouter().ServeHTTP(w, req)
+
+		assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+	})
+
+	t.Run("Unmatched route", func(t *testing.T) {
+		logs := observeLogs(t)
+		w := httptest.NewRecorder()
+		setupRequestLogRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing/123", nil))
+
+		require.Len(t, logs.All(), 1)
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "unmatched", fields["route"])
+		assert.Equal(t, int64(http.StatusNotFound), fields["status"])
+		assert.NotContains(t, fields, "user_id")
+	})
+}
@@ -0,0 +1,94 @@
+package observability
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	// gormCallbackName 註冊於各操作前後的回呼名稱
+	gormCallbackName = "observability:tracing"
+	// gormSpanKey 目前操作的 span 在 gorm.DB instance 中的鍵
+	gormSpanKey = "observability:span"
+)
+
+// GormPlugin 為每個 GORM 操作建立子 span，以 db.Use(observability.NewGormPlugin()) 安裝
+// 需以 db.WithContext(ctx) 傳入請求的 context，span 才會掛在請求的 span 之下
+type GormPlugin struct{}
+
+// NewGormPlugin 創建 GORM 追蹤外掛
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+// Name 實作 gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return gormCallbackName
+}
+
+// Initialize 在 create、query、update、delete、row、raw 前後註冊回呼
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	before, after := gormCallbackName+":before", gormCallbackName+":after"
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register(before, startGormSpan("create")),
+		callbacks.Create().After("gorm:create").Register(after, endGormSpan),
+		callbacks.Query().Before("gorm:query").Register(before, startGormSpan("query")),
+		callbacks.Query().After("gorm:query").Register(after, endGormSpan),
+		callbacks.Update().Before("gorm:update").Register(before, startGormSpan("update")),
+		callbacks.Update().After("gorm:update").Register(after, endGormSpan),
+		callbacks.Delete().Before("gorm:delete").Register(before, startGormSpan("delete")),
+		callbacks.Delete().After("gorm:delete").Register(after, endGormSpan),
+		callbacks.Row().Before("gorm:row").Register(before, startGormSpan("row")),
+		callbacks.Row().After("gorm:row").Register(after, endGormSpan),
+		callbacks.Raw().Before("gorm:raw").Register(before, startGormSpan("raw")),
+		callbacks.Raw().After("gorm:raw").Register(after, endGormSpan),
+	)
+}
+
+// startGormSpan 建立 span 並替換 Statement.Context，讓巢狀操作（如關聯的寫入）成為子 span
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		system := db.Dialector.Name()
+		if system == "postgres" {
+			system = "postgresql"
+		}
+		ctx, span := tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(system),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// endGormSpan 記錄 SQL（參數以佔位符表示，不含實際的值）、資料表與影響筆數後結束 span
+func endGormSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	// 查無資料屬於正常的查詢結果，不視為錯誤
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
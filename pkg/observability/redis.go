@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 為每個 Redis 命令與 pipeline 建立子 span，以 client.AddHook(observability.NewRedisHook()) 安裝
+// span 只記錄命令名稱，不記錄參數，避免 token 等機密資訊寫入追蹤資料
+type RedisHook struct{}
+
+var _ redis.Hook = (*RedisHook)(nil)
+
+// NewRedisHook 創建 Redis 追蹤 hook
+func NewRedisHook() *RedisHook {
+	return &RedisHook{}
+}
+
+// BeforeProcess 實作 redis.Hook
+func (h *RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = tracer().Start(ctx, "redis."+cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(cmd.Name())),
+	)
+	return ctx, nil
+}
+
+// AfterProcess 實作 redis.Hook
+func (h *RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endRedisSpan(ctx, cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline 實作 redis.Hook
+func (h *RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, _ = tracer().Start(ctx, "redis.pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameRedis,
+			semconv.DBOperationName("pipeline"),
+			semconv.DBOperationBatchSize(len(cmds)),
+		),
+	)
+	return ctx, nil
+}
+
+// AfterProcessPipeline 實作 redis.Hook
+func (h *RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+		if err == nil && cmd.Err() != nil {
+			err = cmd.Err()
+		}
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.StringSlice("db.redis.commands", names))
+	endRedisSpan(ctx, err)
+	return nil
+}
+
+// endRedisSpan 結束 span，redis.Nil（鍵不存在）不視為錯誤
+func endRedisSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,107 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName HTTP、GORM 與 Redis span 的 instrumentation scope
+const instrumentationName = "github.com/POABOB/slack-clone-back-end/pkg/observability"
+
+// 追蹤匯出器
+const (
+	// ExporterOTLP 以 OTLP/HTTP 匯出至 collector
+	ExporterOTLP = "otlp"
+	// ExporterStdout 輸出至標準輸出，供本機開發使用
+	ExporterStdout = "stdout"
+	// ExporterNone 只在行程內建立 span（如日誌中的 trace_id），不匯出
+	ExporterNone = "none"
+)
+
+// TracingConfig 分散式追蹤配置
+type TracingConfig struct {
+	// 是否啟用追蹤，停用時 span 不會被記錄，日誌中也不會有 trace_id
+	Enabled bool
+	// 匯出器：otlp、stdout 或 none
+	Exporter string `validate:"oneof=otlp stdout none"`
+	// OTLP/HTTP collector 位址，如 otel-collector:4318
+	Endpoint string `validate:"required_if=Exporter otlp"`
+	// 是否以 HTTP 連線 collector
+	Insecure bool
+	// 取樣比例，0 到 1；上游已決定取樣時沿用上游的決定
+	SampleRatio float64 `validate:"min=0,max=1"`
+}
+
+// NewTracerProvider 依配置建立 tracer provider 並設為全域，同時設定 W3C trace context 與 baggage 傳遞格式
+// 停用時回傳 nil，全域維持 no-op tracer；應用停止時需呼叫 Shutdown 送出尚未匯出的 span
+func NewTracerProvider(ctx context.Context, config TracingConfig, serviceName string) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	var options []sdktrace.TracerProviderOption
+	switch config.Exporter {
+	case ExporterOTLP:
+		exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterStdout:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider, err := newTracerProvider(serviceName, config.SampleRatio, options...)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// NewInMemoryTracerProvider 建立同步匯出至記憶體的 tracer provider 並設為全域，供測試檢查產生的 span
+func NewInMemoryTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	exporter := tracetest.NewInMemoryExporter()
+	provider, _ := newTracerProvider("test", 1, sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	return provider, exporter
+}
+
+// newTracerProvider 以服務名稱與取樣比例建立 tracer provider
+func newTracerProvider(serviceName string, sampleRatio float64, options ...sdktrace.TracerProviderOption) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+	options = append(options,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// tracer 每次從全域取得，tracer provider 在 hook 安裝之後才設定也能生效
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
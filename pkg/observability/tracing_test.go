@@ -0,0 +1,150 @@
+package observability
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// setupTracing installs an in-memory tracer provider for the duration of the test.
+func setupTracing(t *testing.T) *tracetest.InMemoryExporter {
+	provider, exporter := NewInMemoryTracerProvider()
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter
+}
+
+// attributes flattens span attributes for assertions.
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	result := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		result[kv.Key] = kv.Value
+	}
+	return result
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := setupTracing(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+
+	var handlerSpan trace.SpanContext
+	router.GET("/users/:user_id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /users/:user_id", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), "the upstream trace is continued")
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID(), "handlers see the server span")
+	assert.Equal(t, codes.Error, span.Status.Code)
+
+	attrs := attributes(span)
+	assert.Equal(t, "/users/:user_id", attrs["http.route"].AsString())
+	assert.Equal(t, int64(http.StatusInternalServerError), attrs["http.response.status_code"].AsInt64())
+}
+
+func TestGormPlugin(t *testing.T) {
+	exporter := setupTracing(t)
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewGormPlugin()))
+
+	type widget struct {
+		ID   uint
+		Name string
+	}
+	ctx, parent := tracer().Start(context.Background(), "parent")
+	db.WithContext(ctx).Where("name = ?", "secret").Find(&[]widget{})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "gorm.query", span.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+
+	attrs := attributes(span)
+	assert.Equal(t, "postgresql", attrs["db.system.name"].AsString())
+	assert.Equal(t, "widgets", attrs["db.collection.name"].AsString())
+	assert.Equal(t, `SELECT * FROM "widgets" WHERE name = $1`, attrs["db.query.text"].AsString(), "values are not recorded")
+}
+
+func TestRedisHook(t *testing.T) {
+	exporter := setupTracing(t)
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	client.AddHook(NewRedisHook())
+
+	ctx, parent := tracer().Start(context.Background(), "parent")
+	require.NoError(t, client.Set(ctx, "token", "secret", 0).Err())
+	assert.ErrorIs(t, client.Get(ctx, "missing").Err(), redis.Nil)
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, "counter")
+		pipe.Expire(ctx, "counter", 0)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Error(t, client.Do(ctx, "unknowncommand").Err())
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 5)
+	for _, span := range spans[:4] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	}
+	assert.Equal(t, "redis.set", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code, "redis.Nil is not an error")
+	assert.Equal(t, "redis.pipeline", spans[2].Name)
+	assert.Equal(t, []string{"incr", "expire"}, attributes(spans[2])["db.redis.commands"].AsStringSlice())
+	assert.Equal(t, codes.Error, spans[3].Status.Code)
+}
+
+func TestLoggerTraceIDs(t *testing.T) {
+	setupTracing(t)
+	core, logs := observer.New(zap.InfoLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = previous })
+
+	logger.FromContext(context.Background()).Info("untraced")
+	ctx, span := tracer().Start(context.Background(), "request")
+	logger.FromContext(ctx).Info("traced")
+	span.End()
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.NotContains(t, entries[0].ContextMap(), "trace_id")
+	assert.Equal(t, span.SpanContext().TraceID().String(), entries[1].ContextMap()["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), entries[1].ContextMap()["span_id"])
+}
@@ -64,3 +64,11 @@ func Exists(ctx context.Context, key string) (bool, error) {
 	result, err := Client.Exists(ctx, key).Result()
 	return result > 0, err
 }
+
+// NewClient 初始化 Redis 連接並回傳客戶端，供依賴注入使用
+func NewClient(cfg *config.RedisConfig) (*redis.Client, error) {
+	if err := InitRedis(cfg); err != nil {
+		return nil, err
+	}
+	return Client, nil
+}
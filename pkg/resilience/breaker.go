@@ -0,0 +1,162 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
+)
+
+// ErrOpen 熔斷期間直接拒絕呼叫
+var ErrOpen = apperror.Unavailable("dependency temporarily unavailable")
+
+// State 熔斷器狀態，數值即 circuit_breaker_state 指標的值
+type State int
+
+const (
+	// StateClosed 正常放行
+	StateClosed State = iota
+	// StateHalfOpen 放行少量試探呼叫，成功則關閉、失敗則重新熔斷
+	StateHalfOpen
+	// StateOpen 熔斷中，直接回傳 ErrOpen
+	StateOpen
+)
+
+// String 狀態名稱
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "open"
+	}
+}
+
+// Breaker 依連續失敗次數熔斷的熔斷器，狀態變化會更新 circuit_breaker_state 指標
+type Breaker struct {
+	name      string
+	config    BreakerConfig
+	isFailure func(error) bool
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// generation 每次狀態變化時遞增，舊狀態下放行的呼叫完成時不影響新狀態
+	generation int
+	inFlight   int
+	successes  int
+}
+
+// NewBreaker 創建熔斷器，isFailure 判斷錯誤是否計入失敗，nil 時使用 IsFailure
+func NewBreaker(name string, config BreakerConfig, isFailure func(error) bool) *Breaker {
+	if isFailure == nil {
+		isFailure = IsFailure
+	}
+	metrics.SetCircuitBreakerState(name, int(StateClosed))
+	return &Breaker{name: name, config: config, isFailure: isFailure, now: time.Now}
+}
+
+// State 目前的狀態
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Execute 熔斷中回傳 ErrOpen，否則執行 fn 並記錄結果
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	done(err)
+	return err
+}
+
+// Allow 判斷是否放行，放行時回傳的 done 需在呼叫完成後以結果呼叫一次
+// 供無法包住整個呼叫的擴充點（如 GORM 回呼、Redis hook）使用
+func (b *Breaker) Allow() (func(err error), error) {
+	if !b.config.Enabled {
+		return func(error) {}, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+
+	switch b.state {
+	case StateOpen:
+		metrics.RecordCircuitBreakerRejection(b.name)
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.inFlight+b.successes >= b.config.HalfOpenRequests {
+			metrics.RecordCircuitBreakerRejection(b.name)
+			return nil, ErrOpen
+		}
+		b.inFlight++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.record(generation, err) })
+	}, nil
+}
+
+// record 記錄呼叫結果並依結果轉換狀態
+func (b *Breaker) record(generation int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	failed := b.isFailure(err)
+	switch b.state {
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.transition(StateOpen)
+		}
+	case StateHalfOpen:
+		b.inFlight--
+		if failed {
+			b.transition(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenRequests {
+			b.transition(StateClosed)
+		}
+	}
+}
+
+// refresh 熔斷超過 OpenTimeout 後轉為半開
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= time.Duration(b.config.OpenTimeout)*time.Second {
+		b.transition(StateHalfOpen)
+	}
+}
+
+// transition 切換狀態並重置計數
+func (b *Breaker) transition(state State) {
+	b.state = state
+	b.generation++
+	b.failures, b.inFlight, b.successes = 0, 0, 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	metrics.SetCircuitBreakerState(b.name, int(state))
+}
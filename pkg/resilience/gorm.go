@@ -0,0 +1,86 @@
+package resilience
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	// gormCallbackName 註冊於各操作前後的回呼名稱
+	gormCallbackName = "resilience:breaker"
+	// gormDoneKey 熔斷器 done 回呼在 gorm.DB instance 中的鍵
+	gormDoneKey = "resilience:done"
+)
+
+// GormPlugin 以熔斷器保護所有 GORM 操作，以 db.Use(resilience.NewGormPlugin(breaker)) 安裝
+// 熔斷時操作不會送出並回傳 ErrOpen；不在 GORM 層重試，寫入重送並不安全，連線中斷的重試由 database/sql 處理
+type GormPlugin struct {
+	breaker *Breaker
+}
+
+// NewGormPlugin 創建 GORM 熔斷外掛，breaker 應以 IsDBFailure 判斷失敗
+func NewGormPlugin(breaker *Breaker) *GormPlugin {
+	return &GormPlugin{breaker: breaker}
+}
+
+// Name 實作 gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return gormCallbackName
+}
+
+// Initialize 在 create、query、update、delete、row、raw 前後註冊回呼
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	before, after := gormCallbackName+":before", gormCallbackName+":after"
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register(before, p.allow),
+		callbacks.Create().After("gorm:create").Register(after, p.done),
+		callbacks.Query().Before("gorm:query").Register(before, p.allow),
+		callbacks.Query().After("gorm:query").Register(after, p.done),
+		callbacks.Update().Before("gorm:update").Register(before, p.allow),
+		callbacks.Update().After("gorm:update").Register(after, p.done),
+		callbacks.Delete().Before("gorm:delete").Register(before, p.allow),
+		callbacks.Delete().After("gorm:delete").Register(after, p.done),
+		callbacks.Row().Before("gorm:row").Register(before, p.allow),
+		callbacks.Row().After("gorm:row").Register(after, p.done),
+		callbacks.Raw().Before("gorm:raw").Register(before, p.allow),
+		callbacks.Raw().After("gorm:raw").Register(after, p.done),
+	)
+}
+
+// allow 熔斷時加入 ErrOpen，後續的 GORM 回呼看到錯誤便不會執行 SQL
+func (p *GormPlugin) allow(db *gorm.DB) {
+	done, err := p.breaker.Allow()
+	if err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	db.InstanceSet(gormDoneKey, done)
+}
+
+// done 以操作結果更新熔斷器
+func (p *GormPlugin) done(db *gorm.DB) {
+	if value, ok := db.InstanceGet(gormDoneKey); ok {
+		value.(func(error))(db.Error)
+	}
+}
+
+// IsDBFailure 判斷資料庫錯誤是否代表資料庫異常
+// 查無資料、呼叫端取消與資料庫回報的一般錯誤（如違反唯一約束）不計入；連線、資源不足與系統錯誤（SQLSTATE 08、53、57、58 類）計入
+func IsDBFailure(err error) bool {
+	if !IsFailure(err) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code[:2] {
+		case "08", "53", "57", "58":
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisDoneKey 熔斷器 done 回呼在 context 中的鍵
+type redisDoneKey struct{}
+
+// RedisHook 以熔斷器保護 Redis 命令與 pipeline，以 client.AddHook(resilience.NewRedisHook(breaker)) 安裝
+// 熔斷時命令不會送出並回傳 ErrOpen；重試由 go-redis 的 MaxRetries 處理，其退避已含隨機抖動
+type RedisHook struct {
+	breaker *Breaker
+}
+
+var _ redis.Hook = (*RedisHook)(nil)
+
+// NewRedisHook 創建 Redis 熔斷 hook，breaker 應以 IsRedisFailure 判斷失敗
+func NewRedisHook(breaker *Breaker) *RedisHook {
+	return &RedisHook{breaker: breaker}
+}
+
+// BeforeProcess 實作 redis.Hook
+func (h *RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.allow(ctx)
+}
+
+// AfterProcess 實作 redis.Hook
+func (h *RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.done(ctx, cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline 實作 redis.Hook
+func (h *RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return h.allow(ctx)
+}
+
+// AfterProcessPipeline 實作 redis.Hook，以第一個錯誤作為結果
+func (h *RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			err = cmd.Err()
+			break
+		}
+	}
+	h.done(ctx, err)
+	return nil
+}
+
+// allow 放行時將 done 存入 context，熔斷時回傳 ErrOpen 讓 go-redis 不送出命令
+func (h *RedisHook) allow(ctx context.Context) (context.Context, error) {
+	done, err := h.breaker.Allow()
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, redisDoneKey{}, done), nil
+}
+
+// done 以命令結果更新熔斷器，被拒絕的命令沒有 done
+func (h *RedisHook) done(ctx context.Context, err error) {
+	if done, ok := ctx.Value(redisDoneKey{}).(func(error)); ok {
+		done(err)
+	}
+}
+
+// IsRedisFailure 判斷 Redis 錯誤是否代表 Redis 異常，鍵不存在（redis.Nil）、呼叫端取消與命令錯誤（如 WRONGTYPE）不計入
+func IsRedisFailure(err error) bool {
+	if !IsFailure(err) || errors.Is(err, redis.Nil) {
+		return false
+	}
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
@@ -0,0 +1,64 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+)
+
+// Config 外部依賴（PostgreSQL、Redis、其他服務）的熔斷與重試配置
+type Config struct {
+	Breaker BreakerConfig
+	Retry   RetryConfig
+}
+
+// BreakerConfig 熔斷器配置
+type BreakerConfig struct {
+	// 是否啟用熔斷，停用時所有呼叫都會放行
+	Enabled bool
+	// 連續失敗幾次後熔斷
+	FailureThreshold int `validate:"min=1"`
+	// 熔斷多久（秒）後進入半開狀態並放行試探呼叫
+	OpenTimeout int `validate:"min=1"`
+	// 半開狀態放行的試探呼叫數，全部成功才恢復
+	HalfOpenRequests int `validate:"min=1"`
+}
+
+// RetryConfig 重試配置，等待時間以指數增加並加上隨機抖動（full jitter）
+type RetryConfig struct {
+	// 最多嘗試次數（含第一次），1 表示不重試
+	MaxAttempts int `validate:"min=1"`
+	// 第一次重試前等待時間的上限（毫秒），之後每次加倍
+	BaseDelay int `validate:"min=0"`
+	// 單次等待時間的上限（毫秒）
+	MaxDelay int `validate:"gtefield=BaseDelay"`
+}
+
+// Policy 熔斷加重試，每次嘗試都經過熔斷器，熔斷時不再重試
+type Policy struct {
+	Breaker *Breaker
+	Retry   *Retry
+}
+
+// NewPolicy 以依賴名稱創建熔斷與重試策略，名稱作為指標的 name 標籤
+// isFailure 判斷錯誤是否代表依賴異常，只有異常會計入熔斷並重試，nil 表示所有錯誤都是異常
+func NewPolicy(name string, config Config, isFailure func(error) bool) *Policy {
+	if isFailure == nil {
+		isFailure = IsFailure
+	}
+	return &Policy{
+		Breaker: NewBreaker(name, config.Breaker, isFailure),
+		Retry:   NewRetry(name, config.Retry, isFailure),
+	}
+}
+
+// Do 在熔斷器保護下執行 fn，依依賴異常重試
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.Retry.Do(ctx, func(ctx context.Context) error {
+		return p.Breaker.Execute(ctx, fn)
+	})
+}
+
+// IsFailure 預設的異常判斷，呼叫端取消（context.Canceled）不視為依賴異常
+func IsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,325 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var errDown = errors.New("connection refused")
+
+func breakerConfig() BreakerConfig {
+	return BreakerConfig{Enabled: true, FailureThreshold: 2, OpenTimeout: 30, HalfOpenRequests: 1}
+}
+
+// newTestBreaker returns a breaker whose clock is advanced by the returned function.
+func newTestBreaker(name string, isFailure func(error) bool) (*Breaker, func(time.Duration)) {
+	breaker := NewBreaker(name, breakerConfig(), isFailure)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	return breaker, func(d time.Duration) { now = now.Add(d) }
+}
+
+func fail(context.Context) error    { return errDown }
+func succeed(context.Context) error { return nil }
+
+func TestBreaker(t *testing.T) {
+	t.Run("Opens after consecutive failures", func(t *testing.T) {
+		breaker, _ := newTestBreaker("test-open", nil)
+
+		assert.ErrorIs(t, breaker.Execute(context.Background(), fail), errDown)
+		assert.NoError(t, breaker.Execute(context.Background(), succeed), "a success resets the failure count")
+		assert.ErrorIs(t, breaker.Execute(context.Background(), fail), errDown)
+		assert.Equal(t, StateClosed, breaker.State())
+		assert.ErrorIs(t, breaker.Execute(context.Background(), fail), errDown)
+		assert.Equal(t, StateOpen, breaker.State())
+
+		called := false
+		err := breaker.Execute(context.Background(), func(context.Context) error {
+			called = true
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.False(t, called)
+		assert.Equal(t, 2.0, metricValue(t, "circuit_breaker_state", "test-open"))
+	})
+
+	t.Run("Half-open probe closes or reopens", func(t *testing.T) {
+		breaker, advance := newTestBreaker("test-half-open", nil)
+		_ = breaker.Execute(context.Background(), fail)
+		_ = breaker.Execute(context.Background(), fail)
+
+		advance(30 * time.Second)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+		done, err := breaker.Allow()
+		require.NoError(t, err)
+		_, err = breaker.Allow()
+		assert.ErrorIs(t, err, ErrOpen, "only HalfOpenRequests probes are let through")
+		done(errDown)
+		assert.Equal(t, StateOpen, breaker.State())
+
+		advance(30 * time.Second)
+		assert.NoError(t, breaker.Execute(context.Background(), succeed))
+		assert.Equal(t, StateClosed, breaker.State())
+		assert.Equal(t, 0.0, metricValue(t, "circuit_breaker_state", "test-half-open"))
+	})
+
+	t.Run("Ignored errors", func(t *testing.T) {
+		breaker, _ := newTestBreaker("test-ignored", IsDBFailure)
+		for i := 0; i < 3; i++ {
+			_ = breaker.Execute(context.Background(), func(context.Context) error { return gorm.ErrRecordNotFound })
+			_ = breaker.Execute(context.Background(), func(context.Context) error { return context.Canceled })
+			_ = breaker.Execute(context.Background(), func(context.Context) error { return &pgconn.PgError{Code: "23505"} })
+		}
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		breaker := NewBreaker("test-disabled", BreakerConfig{}, nil)
+		for i := 0; i < 5; i++ {
+			assert.ErrorIs(t, breaker.Execute(context.Background(), fail), errDown)
+		}
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+}
+
+func TestRetry(t *testing.T) {
+	config := RetryConfig{MaxAttempts: 3, BaseDelay: 1, MaxDelay: 5}
+
+	t.Run("Retries until success", func(t *testing.T) {
+		before := metricValue(t, "retry_attempts_total", "test-retry")
+		var calls int
+		err := NewRetry("test-retry", config, nil).Do(context.Background(), func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errDown
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 2.0, metricValue(t, "retry_attempts_total", "test-retry")-before)
+	})
+
+	t.Run("Gives up", func(t *testing.T) {
+		var calls int
+		err := NewRetry("test-give-up", config, nil).Do(context.Background(), func(context.Context) error {
+			calls++
+			return errDown
+		})
+		assert.ErrorIs(t, err, errDown)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Does not retry non-failures or an open breaker", func(t *testing.T) {
+		for _, err := range []error{gorm.ErrRecordNotFound, ErrOpen} {
+			var calls int
+			_ = NewRetry("test-no-retry", config, IsDBFailure).Do(context.Background(), func(context.Context) error {
+				calls++
+				return err
+			})
+			assert.Equal(t, 1, calls, err.Error())
+		}
+	})
+
+	t.Run("Respects the deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var calls int
+		err := NewRetry("test-deadline", RetryConfig{MaxAttempts: 5, BaseDelay: 1000, MaxDelay: 1000}, nil).Do(ctx, func(context.Context) error {
+			calls++
+			return errDown
+		})
+		assert.ErrorIs(t, err, errDown)
+		assert.Less(t, calls, 5)
+	})
+
+	t.Run("Backoff is bounded", func(t *testing.T) {
+		retry := NewRetry("test-backoff", RetryConfig{MaxAttempts: 10, BaseDelay: 10, MaxDelay: 40}, nil)
+		for attempt := 1; attempt < 10; attempt++ {
+			assert.LessOrEqual(t, retry.backoff(attempt), 40*time.Millisecond)
+		}
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(middleware.ErrorHandler(), Timeout(20*time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	router.GET("/slow-error", func(c *gin.Context) {
+		<-c.Done()
+		_ = c.Error(c.Err())
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		_, ok := c.Deadline()
+		assert.True(t, ok, "the deadline is visible through gin.Context")
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/slow", "/slow-error"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"code":"deadline_exceeded"`, path)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestTransport(t *testing.T) {
+	config := Config{
+		Breaker: BreakerConfig{Enabled: true, FailureThreshold: 5, OpenTimeout: 30, HalfOpenRequests: 1},
+		Retry:   RetryConfig{MaxAttempts: 3, BaseDelay: 1, MaxDelay: 5},
+	}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/flaky" && n%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	t.Run("Retries idempotent requests", func(t *testing.T) {
+		calls.Store(0)
+		client := &http.Client{Transport: NewTransport(nil, NewPolicy("test-http", config, nil))}
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/flaky", strings.NewReader("payload"))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "payload", string(body), "the body is replayed on retry")
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Does not retry POST", func(t *testing.T) {
+		calls.Store(0)
+		client := &http.Client{Transport: NewTransport(nil, NewPolicy("test-http-post", config, nil))}
+		resp, err := client.Post(server.URL+"/flaky", "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Opens on repeated 5xx", func(t *testing.T) {
+		calls.Store(0)
+		policy := NewPolicy("test-http-down", config, nil)
+		client := &http.Client{Transport: NewTransport(nil, policy)}
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(server.URL + "/down")
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadGateway, resp.StatusCode, "the last response is returned once retries are exhausted")
+		}
+		assert.Equal(t, StateOpen, policy.Breaker.State())
+
+		_, err := client.Get(server.URL + "/down")
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.Equal(t, int32(5), calls.Load())
+	})
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	breaker, _ := newTestBreaker("test-gorm", IsDBFailure)
+	require.NoError(t, db.Use(NewGormPlugin(breaker)))
+
+	type widget struct {
+		ID uint
+	}
+	assert.NoError(t, db.Find(&[]widget{}).Error)
+
+	_ = breaker.Execute(context.Background(), fail)
+	_ = breaker.Execute(context.Background(), fail)
+	assert.ErrorIs(t, db.Find(&[]widget{}).Error, ErrOpen)
+	assert.ErrorIs(t, db.Create(&widget{}).Error, ErrOpen)
+}
+
+func TestRedisHook(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+	breaker, _ := newTestBreaker("test-redis", IsRedisFailure)
+	client.AddHook(NewRedisHook(breaker))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, client.Get(ctx, "missing").Err(), redis.Nil)
+	}
+	require.NoError(t, client.Set(ctx, "key", "value", 0).Err())
+	for i := 0; i < 3; i++ {
+		assert.Error(t, client.LPush(ctx, "key", "x").Err(), "WRONGTYPE")
+	}
+	assert.Equal(t, StateClosed, breaker.State(), "missing keys and command errors are not failures")
+
+	mr.Close()
+	assert.Error(t, client.Get(ctx, "key").Err())
+	assert.Error(t, client.Get(ctx, "key").Err())
+	assert.Equal(t, StateOpen, breaker.State())
+	assert.ErrorIs(t, client.Get(ctx, "key").Err(), ErrOpen)
+
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Get(ctx, "key")
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrOpen)
+}
+
+// metricValue reads a gauge or counter with the given name label from metrics.Registry, 0 if it has not been recorded.
+func metricValue(t *testing.T, metric, name string) float64 {
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != metric {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == name {
+					if m.GetGauge() != nil {
+						return m.GetGauge().GetValue()
+					}
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
+)
+
+// Retry 以指數退避加隨機抖動重試，重試次數記錄於 retry_attempts_total 指標
+type Retry struct {
+	name      string
+	config    RetryConfig
+	retryable func(error) bool
+}
+
+// NewRetry 創建重試器，retryable 判斷錯誤是否值得重試，nil 時使用 IsFailure；熔斷（ErrOpen）一律不重試
+func NewRetry(name string, config RetryConfig, retryable func(error) bool) *Retry {
+	if retryable == nil {
+		retryable = IsFailure
+	}
+	return &Retry{name: name, config: config, retryable: retryable}
+}
+
+// Do 執行 fn，失敗且可重試時等待後再試，最多 MaxAttempts 次
+// 等待時間會超過 ctx 的期限時不再重試，直接回傳最後一次的錯誤
+func (r *Retry) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < r.config.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := r.backoff(attempt)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return err
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			metrics.RecordRetry(r.name)
+		}
+
+		err = fn(ctx)
+		if err == nil || errors.Is(err, ErrOpen) || !r.retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// backoff 第 attempt 次重試前的等待時間，介於 0 與 min(MaxDelay, BaseDelay*2^(attempt-1)) 之間
+func (r *Retry) backoff(attempt int) time.Duration {
+	ceiling := time.Duration(r.config.BaseDelay) * time.Millisecond << (attempt - 1)
+	maxDelay := time.Duration(r.config.MaxDelay) * time.Millisecond
+	if ceiling > maxDelay || ceiling <= 0 {
+		ceiling = maxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
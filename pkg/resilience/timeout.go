@@ -0,0 +1,35 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrDeadlineExceeded 請求未在期限內完成
+var ErrDeadlineExceeded = apperror.DeadlineExceeded("request deadline exceeded")
+
+// Timeout 為請求的 context 設定期限，服務與儲存層以 c.Request.Context()（或 gin.Context）傳入 GORM、Redis 與下游呼叫時會在期限到達時中止
+// 期限到達而處理器尚未回應時以 504 回應；全域設定後，路由可再掛一次設定更短的期限，但無法延長
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			middleware.AbortWithError(c, ErrDeadlineExceeded.Wrap(ctx.Err()))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// idempotentMethods 重送不會產生副作用的方法
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// statusError 下游回應 5xx 或 429，計入熔斷並可重試
+type statusError struct {
+	resp *http.Response
+}
+
+// Error 實作 error 介面
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.resp.StatusCode)
+}
+
+// Transport 以 Policy 保護對其他服務的 HTTP 呼叫
+// 連線錯誤、5xx 與 429 計入熔斷；只有冪等方法且 body 可重讀（GetBody）的請求會重試
+type Transport struct {
+	base   http.RoundTripper
+	policy *Policy
+}
+
+// NewTransport 包裝 base（nil 時使用 http.DefaultTransport），如 client.Transport = resilience.NewTransport(client.Transport, policy)
+func NewTransport(base http.RoundTripper, policy *Policy) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, policy: policy}
+}
+
+// RoundTrip 實作 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	attempts := 0
+	roundTrip := func(ctx context.Context) error {
+		attempts++
+		if resp != nil {
+			// 重試前釋放上一次的回應
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			resp = nil
+		}
+		attempt := req
+		if attempts > 1 {
+			attempt = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				attempt.Body = body
+			}
+		}
+
+		var err error
+		resp, err = t.base.RoundTrip(attempt)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			return &statusError{resp: resp}
+		}
+		return nil
+	}
+
+	var err error
+	if idempotentMethods[req.Method] && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil) {
+		err = t.policy.Do(req.Context(), roundTrip)
+	} else {
+		err = t.policy.Breaker.Execute(req.Context(), roundTrip)
+	}
+
+	// 重試用盡或重試途中熔斷時，回傳最後一次的 5xx/429 回應
+	var status *statusError
+	if errors.As(err, &status) || (errors.Is(err, ErrOpen) && resp != nil) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+)
+
+// tlsVersions 配置中的最低 TLS 版本
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Server HTTP 服務，Start 與 Shutdown 分別對應 fx 的 OnStart 與 OnStop
+type Server struct {
+	server   *http.Server
+	config   *config.ServerConfig
+	listener net.Listener
+}
+
+// New 依配置創建 HTTP 服務，啟用 TLS 時會先載入憑證，憑證錯誤在此回傳而不是等到啟動
+func New(cfg *config.ServerConfig, handler http.Handler) (*Server, error) {
+	server := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Handler:           handler,
+		ReadTimeout:       seconds(cfg.ReadTimeout),
+		ReadHeaderTimeout: seconds(cfg.ReadHeaderTimeout),
+		WriteTimeout:      seconds(cfg.WriteTimeout),
+		IdleTimeout:       seconds(cfg.IdleTimeout),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		Protocols:         new(http.Protocols),
+	}
+	server.Protocols.SetHTTP1(true)
+
+	if cfg.TLS.Enabled {
+		certificate, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls certificate: %w", err)
+		}
+		minVersion, ok := tlsVersions[cfg.TLS.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls version %q", cfg.TLS.MinVersion)
+		}
+		server.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   minVersion,
+		}
+		server.Protocols.SetHTTP2(cfg.EnableHTTP2)
+	} else {
+		server.Protocols.SetUnencryptedHTTP2(cfg.EnableHTTP2)
+	}
+
+	return &Server{server: server, config: cfg}, nil
+}
+
+// Start 綁定位址後在背景處理請求，綁定失敗（如位址已被佔用）時回傳錯誤讓應用停止啟動
+func (s *Server) Start(ctx context.Context) error {
+	var listenConfig net.ListenConfig
+	listener, err := listenConfig.Listen(ctx, "tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.server.Addr, err)
+	}
+	s.listener = listener
+
+	go func() {
+		var err error
+		if s.server.TLSConfig != nil {
+			err = s.server.ServeTLS(listener, "", "")
+		} else {
+			err = s.server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("http server stopped unexpectedly", logger.Err(err))
+		}
+	}()
+
+	logger.Info("http server started",
+		logger.String("addr", listener.Addr().String()),
+		logger.Any("tls", s.server.TLSConfig != nil))
+	return nil
+}
+
+// Shutdown 停止接受新連線並等待處理中的請求完成，最多等待 ShutdownTimeout 或 ctx 的期限
+// 逾時仍未完成時強制關閉所有連線並回傳錯誤
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, seconds(s.config.ShutdownTimeout))
+		defer cancel()
+	}
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		_ = s.server.Close()
+		return fmt.Errorf("failed to shut down http server gracefully: %w", err)
+	}
+	logger.Info("http server stopped")
+	return nil
+}
+
+// Addr 實際綁定的位址，Port 為 0 時可由此取得系統分配的埠號
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// seconds 將配置中的秒數轉為 time.Duration
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *config.ServerConfig {
+	return &config.ServerConfig{
+		Host:              "127.0.0.1",
+		Port:              0,
+		ReadHeaderTimeout: 5,
+		ShutdownTimeout:   5,
+		EnableHTTP2:       true,
+		TLS:               config.TLSConfig{MinVersion: "1.2"},
+	}
+}
+
+func startServer(t *testing.T, cfg *config.ServerConfig, handler http.Handler) *Server {
+	server, err := New(cfg, handler)
+	require.NoError(t, err)
+	require.NoError(t, server.Start(context.Background()))
+	t.Cleanup(func() { _ = server.server.Close() })
+	return server
+}
+
+func TestStart(t *testing.T) {
+	t.Run("Serves requests", func(t *testing.T) {
+		server := startServer(t, testConfig(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}))
+
+		resp, err := http.Get("http://" + server.Addr().String())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "ok", string(body))
+	})
+
+	t.Run("Bind error is returned", func(t *testing.T) {
+		first := startServer(t, testConfig(), http.NotFoundHandler())
+
+		cfg := testConfig()
+		cfg.Port = first.Addr().(*net.TCPAddr).Port
+		second, err := New(cfg, http.NotFoundHandler())
+		require.NoError(t, err)
+		assert.ErrorContains(t, second.Start(context.Background()), "failed to listen")
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("Waits for in-flight requests", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		server := startServer(t, testConfig(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			_, _ = io.WriteString(w, "done")
+		}))
+
+		result := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + server.Addr().String())
+			if err != nil {
+				result <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			result <- string(body)
+		}()
+		<-started
+
+		shutdown := make(chan error, 1)
+		go func() { shutdown <- server.Shutdown(context.Background()) }()
+		select {
+		case <-shutdown:
+			t.Fatal("shutdown returned before the in-flight request finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		assert.Equal(t, "done", <-result)
+		assert.NoError(t, <-shutdown)
+	})
+
+	t.Run("Deadline exceeded", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		defer close(release)
+		server := startServer(t, testConfig(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}))
+
+		go func() {
+			if resp, err := http.Get("http://" + server.Addr().String()); err == nil {
+				resp.Body.Close()
+			}
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+	})
+}
+
+func TestTLS(t *testing.T) {
+	t.Run("Negotiates HTTP/2", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.TLS.Enabled = true
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = writeCertificate(t)
+		server := startServer(t, cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.Proto)
+		}))
+
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		}}
+		resp, err := client.Get("https://" + server.Addr().String())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "HTTP/2.0", string(body))
+	})
+
+	t.Run("Missing certificate", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.TLS.Enabled = true
+		cfg.TLS.CertFile = filepath.Join(t.TempDir(), "missing.pem")
+		cfg.TLS.KeyFile = cfg.TLS.CertFile
+
+		_, err := New(cfg, http.NotFoundHandler())
+		assert.ErrorContains(t, err, "failed to load tls certificate")
+	})
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and returns the cert and key paths.
+func writeCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
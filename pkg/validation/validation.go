@@ -0,0 +1,263 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh_Hant_TW"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtwtranslations "github.com/go-playground/validator/v10/translations/zh_tw"
+	"golang.org/x/text/language"
+)
+
+// 自訂規則
+const (
+	// TagHandle 使用者代號：3 到 30 個小寫英文字母、數字、「.」、「_」或「-」，且以英文字母或數字開頭
+	TagHandle = "handle"
+	// TagPassword 密碼策略：至少 8 個字元且不超過 72 bytes（bcrypt 上限），至少包含一個英文字母與一個數字
+	TagPassword = "password"
+)
+
+// 密碼長度限制，下限以字元計算，上限為 bcrypt 可處理的 bytes
+const (
+	passwordMinLength = 8
+	passwordMaxBytes  = 72
+)
+
+// 非 validator 規則的訊息鍵
+const (
+	// ruleType JSON 值的型別不正確
+	ruleType = "type"
+	// ruleInvalid 沒有對應訊息的規則
+	ruleInvalid = "invalid"
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{2,29}$`)
+
+// supportedLanguages 支援的語系，第一個為預設語系
+var supportedLanguages = []language.Tag{language.English, language.MustParse("zh-TW")}
+
+// locales 與 supportedLanguages 對應的 universal-translator 語系名稱
+var locales = []string{"en", "zh_Hant_TW"}
+
+var languageMatcher = language.NewMatcher(supportedLanguages)
+
+// messages 各語系的自訂訊息
+var messages = map[string]map[string]string{
+	"en": {
+		TagHandle:   "{0} must be 3-30 lowercase letters, digits, '.', '_' or '-', starting with a letter or digit",
+		TagPassword: "{0} must be at least 8 characters and at most 72 bytes, and contain at least one letter and one digit",
+		ruleType:    "{0} must be a {1}",
+		ruleInvalid: "{0} is invalid",
+	},
+	"zh_Hant_TW": {
+		TagHandle:   "{0}必須為 3 到 30 個小寫英文字母、數字、「.」、「_」或「-」，且以英文字母或數字開頭",
+		TagPassword: "{0}必須至少 8 個字元且不超過 72 bytes，並至少包含一個英文字母與一個數字",
+		ruleType:    "{0}必須為 {1}",
+		ruleInvalid: "{0}不正確",
+	},
+}
+
+// FieldError 單一不合法的請求欄位
+type FieldError struct {
+	// 欄位路徑，使用 JSON 名稱，如 channels[0]
+	Field string `json:"field"`
+	// 未通過的規則，如 required、min
+	Rule string `json:"rule"`
+	// 規則參數，如 min=8 的 8
+	Params []string `json:"params,omitempty"`
+	// 依語系翻譯的錯誤訊息
+	Message string `json:"message"`
+}
+
+// Validator 請求驗證器，註冊自訂規則並提供各語系的錯誤訊息
+type Validator struct {
+	translators *ut.UniversalTranslator
+}
+
+// New 在 validate 上註冊自訂規則、以 JSON 名稱回報欄位並載入各語系的錯誤訊息
+// 錯誤訊息綁定於 validate，同一個 validate 只能註冊一次
+func New(validate *validator.Validate) (*Validator, error) {
+	validate.RegisterTagNameFunc(fieldName)
+	if err := validate.RegisterValidation(TagHandle, isHandle); err != nil {
+		return nil, err
+	}
+	if err := validate.RegisterValidation(TagPassword, isPassword); err != nil {
+		return nil, err
+	}
+
+	v := &Validator{translators: ut.New(en.New(), en.New(), zh_Hant_TW.New())}
+	registerDefaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"en":         entranslations.RegisterDefaultTranslations,
+		"zh_Hant_TW": zhtwtranslations.RegisterDefaultTranslations,
+	}
+	for _, locale := range locales {
+		trans, _ := v.translators.GetTranslator(locale)
+		if err := registerDefaults[locale](validate, trans); err != nil {
+			return nil, err
+		}
+		for _, tag := range []string{TagHandle, TagPassword} {
+			if err := validate.RegisterTranslation(tag, trans, registerMessage(tag), translateMessage); err != nil {
+				return nil, err
+			}
+		}
+		for _, key := range []string{ruleType, ruleInvalid} {
+			if err := trans.Add(key, messages[locale][key], false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+var (
+	defaultOnce      sync.Once
+	defaultValidator *Validator
+)
+
+// Default gin binding 使用的驗證器，第一次呼叫時註冊於 binding.Validator
+// 註冊只會因程式錯誤（如規則重複）失敗，因此直接 panic
+func Default() *Validator {
+	defaultOnce.Do(func() {
+		validate, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			panic("validation: gin binding does not use go-playground/validator")
+		}
+		v, err := New(validate)
+		if err != nil {
+			panic("validation: " + err.Error())
+		}
+		defaultValidator = v
+	})
+	return defaultValidator
+}
+
+// Translate 以 Default 轉換 binding 錯誤，見 Validator.Translate
+func Translate(err error, acceptLanguage string) ([]FieldError, bool) {
+	return Default().Translate(err, acceptLanguage)
+}
+
+// Translate 將 binding 錯誤轉為欄位錯誤清單，訊息依 Accept-Language 選擇語系，不支援的語系使用英文
+// 支援 validator 的驗證錯誤與 JSON 型別錯誤，其他錯誤（如 JSON 格式錯誤）回傳 false
+func (v *Validator) Translate(err error, acceptLanguage string) ([]FieldError, bool) {
+	trans, _ := v.translators.GetTranslator(matchLocale(acceptLanguage))
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fieldError := range validationErrors {
+			message := fieldError.Translate(trans)
+			// 沒有對應訊息時 Translate 會回傳英文的原始錯誤
+			if message == fieldError.Error() {
+				message, _ = trans.T(ruleInvalid, fieldError.Field())
+			}
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fieldError.Namespace()),
+				Rule:    fieldError.Tag(),
+				Params:  params(fieldError.Param()),
+				Message: message,
+			})
+		}
+		return fields, true
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		message, _ := trans.T(ruleType, typeError.Field, typeError.Type.String())
+		return []FieldError{{
+			Field:   typeError.Field,
+			Rule:    ruleType,
+			Params:  []string{typeError.Type.String()},
+			Message: message,
+		}}, true
+	}
+	return nil, false
+}
+
+// matchLocale 依 Accept-Language 選擇最接近的語系
+func matchLocale(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return locales[0]
+	}
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return locales[0]
+	}
+	return locales[index]
+}
+
+// fieldName 欄位名稱優先使用 json tag，其次為 form tag
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldPath 去掉 namespace 開頭的結構名稱，如 GuestRequest.channels[0] 轉為 channels[0]
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// params 拆分規則參數，如 oneof=a b 的 a、b
+func params(param string) []string {
+	if param == "" {
+		return nil
+	}
+	return strings.Fields(param)
+}
+
+// isHandle 驗證使用者代號格式
+func isHandle(fl validator.FieldLevel) bool {
+	return handlePattern.MatchString(fl.Field().String())
+}
+
+// isPassword 驗證密碼策略
+func isPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if utf8.RuneCountInString(password) < passwordMinLength || len(password) > passwordMaxBytes {
+		return false
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		hasDigit = hasDigit || unicode.IsDigit(r)
+	}
+	return hasLetter && hasDigit
+}
+
+// registerMessage 註冊自訂規則在該語系的訊息
+func registerMessage(tag string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, messages[trans.Locale()][tag], false)
+	}
+}
+
+// translateMessage 以欄位名稱翻譯自訂規則的訊息
+func translateMessage(trans ut.Translator, fieldError validator.FieldError) string {
+	message, err := trans.T(fieldError.Tag(), fieldError.Field())
+	if err != nil {
+		return fieldError.Error()
+	}
+	return message
+}
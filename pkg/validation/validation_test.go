@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signupRequest struct {
+	Email    string   `json:"email" validate:"required,email"`
+	Username string   `json:"username" validate:"handle"`
+	Password string   `json:"password" validate:"password"`
+	Role     string   `json:"role" validate:"oneof=member admin"`
+	Channels []string `json:"channels" validate:"dive,min=2"`
+	Age      int      `form:"age" validate:"gte=13"`
+}
+
+func newTestValidator(t *testing.T) (*Validator, *validator.Validate) {
+	validate := validator.New()
+	v, err := New(validate)
+	require.NoError(t, err)
+	return v, validate
+}
+
+func TestCustomRules(t *testing.T) {
+	_, validate := newTestValidator(t)
+
+	for handle, valid := range map[string]bool{
+		"alice": true, "a.b_c-1": true, "0xdead": true,
+		"ab": false, "Alice": false, "_alice": false, "alice!": false,
+	} {
+		assert.Equal(t, valid, validate.Var(handle, TagHandle) == nil, "handle %q", handle)
+	}
+
+	for password, valid := range map[string]bool{
+		"passw0rd": true, "長密碼長密碼長密碼1": true,
+		"short1": false, "password": false, "12345678": false,
+		// 長度下限以字元計算，上限以 bytes 計算
+		"密碼ab12": false, strings.Repeat("密", 24) + "a1": false, strings.Repeat("a", 70) + "1": true,
+	} {
+		assert.Equal(t, valid, validate.Var(password, TagPassword) == nil, "password %q", password)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	v, validate := newTestValidator(t)
+	err := validate.Struct(signupRequest{
+		Email:    "alice@example.com",
+		Username: "Alice",
+		Password: "password",
+		Role:     "owner",
+		Channels: []string{"general", "x"},
+		Age:      12,
+	})
+	require.Error(t, err)
+
+	fields, ok := v.Translate(err, "en-US,en;q=0.9")
+	require.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "username", Rule: "handle", Message: "username must be 3-30 lowercase letters, digits, '.', '_' or '-', starting with a letter or digit"},
+		{Field: "password", Rule: "password", Message: "password must be at least 8 characters and at most 72 bytes, and contain at least one letter and one digit"},
+		{Field: "role", Rule: "oneof", Params: []string{"member", "admin"}, Message: "role must be one of [member admin]"},
+		{Field: "channels[1]", Rule: "min", Params: []string{"2"}, Message: "channels[1] must be at least 2 characters in length"},
+		{Field: "age", Rule: "gte", Params: []string{"13"}, Message: "age must be 13 or greater"},
+	}, fields)
+
+	fields, ok = v.Translate(err, "zh-TW,zh;q=0.9,en;q=0.8")
+	require.True(t, ok)
+	assert.Equal(t, "username必須為 3 到 30 個小寫英文字母、數字、「.」、「_」或「-」，且以英文字母或數字開頭", fields[0].Message)
+	assert.Equal(t, "age必須大於或等於13", fields[4].Message)
+
+	// Unsupported languages fall back to English.
+	fields, _ = v.Translate(err, "fr-FR")
+	assert.Equal(t, "age must be 13 or greater", fields[4].Message)
+}
+
+func TestTranslateTypeError(t *testing.T) {
+	v, _ := newTestValidator(t)
+	var request signupRequest
+	err := json.Unmarshal([]byte(`{"role": 1}`), &request)
+	require.Error(t, err)
+
+	fields, ok := v.Translate(err, "")
+	require.True(t, ok)
+	assert.Equal(t, []FieldError{{Field: "role", Rule: "type", Params: []string{"string"}, Message: "role must be a string"}}, fields)
+
+	_, ok = v.Translate(json.Unmarshal([]byte(`{`), &request), "")
+	assert.False(t, ok, "syntax errors have no field")
+}
+
+func TestMatchLocale(t *testing.T) {
+	assert.Equal(t, "en", matchLocale(""))
+	assert.Equal(t, "en", matchLocale("ja"))
+	assert.Equal(t, "zh_Hant_TW", matchLocale("zh-TW"))
+	assert.Equal(t, "zh_Hant_TW", matchLocale("zh-Hant"))
+	assert.Equal(t, "zh_Hant_TW", matchLocale("ja;q=0.9, zh-TW;q=0.8"))
+	assert.Equal(t, "en", matchLocale("en;q=0.9, zh-TW;q=0.8"))
+}
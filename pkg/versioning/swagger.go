@@ -0,0 +1,122 @@
+package versioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerMethods Swagger 2.0 path item 中的操作
+var swaggerMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// swaggerParam Swagger 路徑參數，轉為 gin 的 :param 後比對路由
+var swaggerParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// SwaggerDoc 由 swag 產生的完整文件（swagger.json）擷取單一版本的文件
+// 只保留 routes 中該版本實際註冊的操作，路徑改為相對於 <PathPrefix>/<版本> 的 basePath；版本已棄用時所有操作標記為 deprecated
+// 註解中的 @Router 可以是完整路徑（/api/v1/user/{id}）或相對於 @BasePath 的路徑，不在 PathPrefix 之下的路徑不列入
+func (r *Registry) SwaggerDoc(doc []byte, routes gin.RoutesInfo, name string) ([]byte, error) {
+	if _, ok := r.byName[name]; !ok {
+		return nil, fmt.Errorf("unknown API version %q", name)
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, err
+	}
+
+	basePath := r.prefix + "/" + name
+	registered := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, basePath+"/") {
+			registered[strings.ToLower(route.Method)+" "+route.Path] = struct{}{}
+		}
+	}
+
+	docBasePath, _ := spec["basePath"].(string)
+	paths, _ := spec["paths"].(map[string]interface{})
+	versionPaths := make(map[string]interface{})
+	for swaggerPath, value := range paths {
+		item, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rest, ok := r.relativePath(docBasePath, swaggerPath)
+		if !ok {
+			continue
+		}
+		routePath := basePath + swaggerParam.ReplaceAllString(rest, ":$1")
+
+		operations := make(map[string]interface{})
+		for key, operation := range item {
+			if !isSwaggerMethod(key) {
+				// 共用的 parameters 等欄位保留
+				operations[key] = operation
+				continue
+			}
+			if _, ok := registered[key+" "+routePath]; !ok {
+				continue
+			}
+			if op, ok := operation.(map[string]interface{}); ok && r.Deprecated(name) {
+				op["deprecated"] = true
+			}
+			operations[key] = operation
+		}
+		if hasSwaggerOperation(operations) {
+			versionPaths[rest] = operations
+		}
+	}
+
+	spec["basePath"] = basePath
+	spec["paths"] = versionPaths
+	if info, ok := spec["info"].(map[string]interface{}); ok {
+		info["version"] = name
+	}
+	return json.Marshal(spec)
+}
+
+// relativePath 將文件中的路徑轉為相對於版本前綴的路徑，如 /api/v1/user/{id} 轉為 /user/{id}
+func (r *Registry) relativePath(docBasePath, swaggerPath string) (string, bool) {
+	full := swaggerPath
+	if !strings.HasPrefix(swaggerPath, r.prefix+"/") {
+		full = path.Join("/", docBasePath, swaggerPath)
+	}
+	rest, ok := strings.CutPrefix(full, r.prefix+"/")
+	if !ok {
+		return "", false
+	}
+	// 去掉文件中原本的版本片段
+	if segment := firstSegment(rest); versionPattern.MatchString(segment) {
+		rest = strings.TrimPrefix(rest, segment)
+	} else {
+		rest = "/" + rest
+	}
+	if rest == "" {
+		rest = "/"
+	}
+	return rest, true
+}
+
+// isSwaggerMethod key 是否為 path item 中的操作
+func isSwaggerMethod(key string) bool {
+	for _, method := range swaggerMethods {
+		if key == method {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSwaggerOperation path item 是否還有任何操作
+func hasSwaggerOperation(item map[string]interface{}) bool {
+	for key := range item {
+		if isSwaggerMethod(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package versioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// VersionHeader 回應實際使用的 API 版本
+	VersionHeader = "API-Version"
+	// DeprecationHeader 版本已棄用或預計棄用的時間（RFC 9745）
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader 版本停止提供的時間（RFC 8594）
+	SunsetHeader = "Sunset"
+
+	// versionKey API 版本在 gin.Context 中的鍵
+	versionKey = "api_version"
+	// timeLayout 配置中時間的格式
+	timeLayout = time.RFC3339
+)
+
+// ErrUnsupportedVersion Accept 標頭要求的版本不存在
+var ErrUnsupportedVersion = apperror.NotAcceptable("unsupported API version")
+
+// versionPattern 路徑中形如版本的片段，不存在的版本不會被改寫為預設版本
+var versionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// Version 單一 API 版本
+type Version struct {
+	// 版本名稱，作為路徑的一段，例如 v1
+	Name string `validate:"required"`
+	// 棄用時間（RFC 3339），設定後回應帶上 Deprecation 標頭，可為未來的時間
+	Deprecation string `validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// 停止提供的時間（RFC 3339），設定後回應帶上 Sunset 標頭
+	Sunset string `validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// 說明遷移方式的文件，以 Link 標頭回傳
+	Link string `validate:"omitempty,url"`
+}
+
+// Config API 版本配置
+type Config struct {
+	// 版本化 API 的路徑前綴，各版本的路由位於 <PathPrefix>/<版本>
+	PathPrefix string `validate:"required,startswith=/"`
+	// Accept 標頭的廠商媒體類型，例如 application/vnd.slack-clone 時可以 application/vnd.slack-clone.v2+json 指定版本
+	MediaType string
+	// 同時提供的版本，未列出預設版本時自動加入
+	Versions []Version `validate:"dive"`
+}
+
+// version 解析後的版本
+type version struct {
+	name        string
+	deprecation time.Time
+	sunset      time.Time
+	link        string
+}
+
+// Registry API 版本註冊表，處理器的路由依此註冊到每個版本
+type Registry struct {
+	prefix         string
+	mediaType      string
+	defaultVersion string
+	versions       []*version
+	byName         map[string]*version
+}
+
+// New 創建 API 版本註冊表，defaultVersion 為路徑與 Accept 都未指定版本時使用的版本
+func New(defaultVersion string, config Config) (*Registry, error) {
+	registry := &Registry{
+		prefix:         strings.TrimSuffix(config.PathPrefix, "/"),
+		mediaType:      strings.ToLower(config.MediaType),
+		defaultVersion: defaultVersion,
+		byName:         make(map[string]*version),
+	}
+
+	versions := config.Versions
+	if !containsVersion(versions, defaultVersion) {
+		versions = append([]Version{{Name: defaultVersion}}, versions...)
+	}
+	for _, v := range versions {
+		if _, ok := registry.byName[v.Name]; ok {
+			return nil, fmt.Errorf("duplicate API version %q", v.Name)
+		}
+		parsed := &version{name: v.Name, link: v.Link}
+		var err error
+		if parsed.deprecation, err = parseTime(v.Deprecation); err != nil {
+			return nil, fmt.Errorf("API version %q deprecation: %w", v.Name, err)
+		}
+		if parsed.sunset, err = parseTime(v.Sunset); err != nil {
+			return nil, fmt.Errorf("API version %q sunset: %w", v.Name, err)
+		}
+		registry.versions = append(registry.versions, parsed)
+		registry.byName[v.Name] = parsed
+	}
+	return registry, nil
+}
+
+// Versions 所有提供的版本，依配置順序
+func (r *Registry) Versions() []string {
+	names := make([]string, 0, len(r.versions))
+	for _, v := range r.versions {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+// Default 預設版本
+func (r *Registry) Default() string {
+	return r.defaultVersion
+}
+
+// Deprecated 版本是否設定了棄用時間
+func (r *Registry) Deprecated(name string) bool {
+	v, ok := r.byName[name]
+	return ok && (!v.deprecation.IsZero() || !v.sunset.IsZero())
+}
+
+// Register 為每個版本建立 <PathPrefix>/<版本> 路由群組並呼叫 register，處理器的路由因此同時提供於所有版本
+// register 可依 version 略過或調整只存在於部分版本的路由
+func (r *Registry) Register(router gin.IRouter, register func(version string, group *gin.RouterGroup)) {
+	for _, v := range r.versions {
+		register(v.name, router.Group(r.prefix+"/"+v.name, v.middleware()))
+	}
+}
+
+// FromContext 取得請求使用的 API 版本，不在版本路由上時為空字串
+func FromContext(c *gin.Context) string {
+	return c.GetString(versionKey)
+}
+
+// middleware 記錄請求的版本並回傳 API-Version 與棄用相關標頭
+func (v *version) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(versionKey, v.name)
+		header := c.Writer.Header()
+		header.Set(VersionHeader, v.name)
+		if !v.deprecation.IsZero() {
+			header.Set(DeprecationHeader, "@"+strconv.FormatInt(v.deprecation.Unix(), 10))
+		}
+		if !v.sunset.IsZero() {
+			header.Set(SunsetHeader, v.sunset.UTC().Format(http.TimeFormat))
+		}
+		if v.link != "" {
+			rel := "deprecation"
+			if v.deprecation.IsZero() {
+				rel = "sunset"
+			}
+			header.Add("Link", fmt.Sprintf("<%s>; rel=%q", v.link, rel))
+		}
+		c.Next()
+	}
+}
+
+// Handler 包裝 next，將未帶版本的路徑（<PathPrefix>/user/1）依 Accept 標頭改寫為版本路徑，未指定時使用預設版本
+// 需在路由之前執行，因此包裝整個 gin.Engine；路徑已帶版本時以路徑為準，Accept 要求不存在的版本時回應 406
+func (r *Registry) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rest, ok := strings.CutPrefix(req.URL.Path, r.prefix+"/")
+		if !ok || versionPattern.MatchString(firstSegment(rest)) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		name, requested := r.negotiate(req.Header.Values("Accept"))
+		w.Header().Add("Vary", "Accept")
+		if !requested {
+			name = r.defaultVersion
+		} else if _, ok := r.byName[name]; !ok {
+			writeProblem(w, req, ErrUnsupportedVersion.WithDetail("versions", r.Versions()))
+			return
+		}
+
+		req.URL.Path = r.prefix + "/" + name + "/" + rest
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = r.prefix + "/" + name + "/" + strings.TrimPrefix(req.URL.RawPath, r.prefix+"/")
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// negotiate 從 Accept 標頭取得版本，支援 <MediaType>.<版本>+json 與 version=<版本> 參數，回傳第一個指定的版本
+func (r *Registry) negotiate(accepts []string) (string, bool) {
+	for _, accept := range accepts {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+			if name, ok := params["version"]; ok && name != "" {
+				return name, true
+			}
+			if r.mediaType == "" {
+				continue
+			}
+			if name, ok := strings.CutPrefix(mediaType, r.mediaType+"."); ok {
+				name, _, _ = strings.Cut(name, "+")
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// writeProblem 在 gin 之外回應 RFC 7807 錯誤
+func writeProblem(w http.ResponseWriter, req *http.Request, err *apperror.Error) {
+	w.Header().Set("Content-Type", apperror.ContentType)
+	w.WriteHeader(err.Status())
+	_ = json.NewEncoder(w).Encode(err.Problem(req.URL.Path))
+}
+
+// firstSegment 路徑的第一段
+func firstSegment(path string) string {
+	segment, _, _ := strings.Cut(path, "/")
+	return segment
+}
+
+// containsVersion 版本清單是否包含 name
+func containsVersion(versions []Version, name string) bool {
+	for _, v := range versions {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTime 解析配置中的時間，空字串為零值
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(timeLayout, value)
+}
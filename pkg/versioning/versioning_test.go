@@ -0,0 +1,196 @@
+package versioning
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{
+		PathPrefix: "/api",
+		MediaType:  "application/vnd.slack-clone",
+		Versions: []Version{
+			{Name: "v1", Deprecation: "2026-01-01T00:00:00Z", Sunset: "2026-12-31T00:00:00Z", Link: "https://example.com/migrate-v2"},
+			{Name: "v2"},
+		},
+	}
+}
+
+// setupVersionedRouter registers GET /user/:id on every version, and GET /channels only from v2.
+func setupVersionedRouter(t *testing.T) (*Registry, *gin.Engine) {
+	registry, err := New("v2", testConfig())
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	registry.Register(engine, func(version string, group *gin.RouterGroup) {
+		group.GET("/user/:id", func(c *gin.Context) {
+			c.String(http.StatusOK, FromContext(c)+" "+c.Param("id"))
+		})
+		if version != "v1" {
+			group.GET("/channels", func(c *gin.Context) { c.String(http.StatusOK, FromContext(c)) })
+		}
+	})
+	engine.GET("/healthz", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return registry, engine
+}
+
+func serve(handler http.Handler, path string, accept ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, value := range accept {
+		req.Header.Add("Accept", value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Adds the default version", func(t *testing.T) {
+		registry, err := New("v1", Config{PathPrefix: "/api/", Versions: []Version{{Name: "v2"}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1", "v2"}, registry.Versions())
+		assert.Equal(t, "v1", registry.Default())
+		assert.False(t, registry.Deprecated("v1"))
+	})
+
+	t.Run("Duplicate version", func(t *testing.T) {
+		_, err := New("v1", Config{PathPrefix: "/api", Versions: []Version{{Name: "v1"}, {Name: "v1"}}})
+		assert.ErrorContains(t, err, "duplicate")
+	})
+
+	t.Run("Invalid time", func(t *testing.T) {
+		_, err := New("v1", Config{PathPrefix: "/api", Versions: []Version{{Name: "v1", Sunset: "2026-12-31"}}})
+		assert.ErrorContains(t, err, "sunset")
+	})
+}
+
+func TestRegister(t *testing.T) {
+	_, engine := setupVersionedRouter(t)
+
+	t.Run("Routes are served on every version", func(t *testing.T) {
+		w := serve(engine, "/api/v1/user/7")
+		assert.Equal(t, "v1 7", w.Body.String())
+		w = serve(engine, "/api/v2/user/7")
+		assert.Equal(t, "v2 7", w.Body.String())
+		assert.Equal(t, "v2", w.Header().Get(VersionHeader))
+	})
+
+	t.Run("Version specific routes", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(engine, "/api/v1/channels").Code)
+		assert.Equal(t, http.StatusOK, serve(engine, "/api/v2/channels").Code)
+	})
+
+	t.Run("Deprecated version headers", func(t *testing.T) {
+		w := serve(engine, "/api/v1/user/7")
+		assert.Equal(t, "v1", w.Header().Get(VersionHeader))
+		assert.Equal(t, "@1767225600", w.Header().Get(DeprecationHeader))
+		assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", w.Header().Get(SunsetHeader))
+		assert.Equal(t, `<https://example.com/migrate-v2>; rel="deprecation"`, w.Header().Get("Link"))
+
+		w = serve(engine, "/api/v2/user/7")
+		assert.Empty(t, w.Header().Get(DeprecationHeader))
+		assert.Empty(t, w.Header().Get(SunsetHeader))
+	})
+}
+
+func TestHandler(t *testing.T) {
+	registry, engine := setupVersionedRouter(t)
+	handler := registry.Handler(engine)
+
+	tests := []struct {
+		name   string
+		path   string
+		accept []string
+		status int
+		body   string
+	}{
+		{name: "Default version", path: "/api/user/7", status: http.StatusOK, body: "v2 7"},
+		{name: "Vendor media type", path: "/api/user/7", accept: []string{"application/vnd.slack-clone.v1+json"}, status: http.StatusOK, body: "v1 7"},
+		{name: "Version parameter", path: "/api/user/7", accept: []string{"text/html, application/json; version=v1"}, status: http.StatusOK, body: "v1 7"},
+		{name: "Generic Accept", path: "/api/user/7", accept: []string{"application/json", "*/*"}, status: http.StatusOK, body: "v2 7"},
+		{name: "Path takes precedence", path: "/api/v1/user/7", accept: []string{"application/vnd.slack-clone.v2+json"}, status: http.StatusOK, body: "v1 7"},
+		{name: "Unknown version in path", path: "/api/v9/user/7", status: http.StatusNotFound},
+		{name: "Outside the prefix", path: "/healthz", accept: []string{"application/vnd.slack-clone.v9+json"}, status: http.StatusOK, body: "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.path, tt.accept...)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Unversioned responses vary by Accept", func(t *testing.T) {
+		assert.Equal(t, "Accept", serve(handler, "/api/user/7").Header().Get("Vary"))
+		assert.Empty(t, serve(handler, "/api/v2/user/7").Header().Get("Vary"))
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		w := serve(handler, "/api/user/7", "application/vnd.slack-clone.v9+json")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+		var problem map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "not_acceptable", problem["code"])
+		assert.Equal(t, []interface{}{"v1", "v2"}, problem["details"].(map[string]interface{})["versions"])
+	})
+}
+
+func TestSwaggerDoc(t *testing.T) {
+	registry, engine := setupVersionedRouter(t)
+	doc := []byte(`{
+		"swagger": "2.0",
+		"info": {"title": "User Service API", "version": "1.0"},
+		"basePath": "/api/v1",
+		"paths": {
+			"/api/v1/user/{id}": {"get": {"summary": "get user"}, "delete": {"summary": "not registered"}},
+			"/channels": {"get": {"summary": "list channels"}},
+			"/oauth/token": {"post": {"summary": "token"}}
+		}
+	}`)
+
+	t.Run("Current version", func(t *testing.T) {
+		result, err := registry.SwaggerDoc(doc, engine.Routes(), "v2")
+		require.NoError(t, err)
+
+		var spec map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &spec))
+		assert.Equal(t, "/api/v2", spec["basePath"])
+		assert.Equal(t, "v2", spec["info"].(map[string]interface{})["version"])
+
+		paths := spec["paths"].(map[string]interface{})
+		assert.Len(t, paths, 2, "only operations registered on v2 are kept")
+		user := paths["/user/{id}"].(map[string]interface{})
+		assert.Contains(t, user, "get")
+		assert.NotContains(t, user, "delete")
+		assert.Contains(t, paths, "/channels")
+		assert.NotContains(t, user["get"], "deprecated")
+	})
+
+	t.Run("Deprecated version", func(t *testing.T) {
+		result, err := registry.SwaggerDoc(doc, engine.Routes(), "v1")
+		require.NoError(t, err)
+
+		var spec map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &spec))
+		paths := spec["paths"].(map[string]interface{})
+		assert.Len(t, paths, 1)
+		get := paths["/user/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+		assert.Equal(t, true, get["deprecated"])
+	})
+
+	t.Run("Unknown version", func(t *testing.T) {
+		_, err := registry.SwaggerDoc(doc, engine.Routes(), "v9")
+		assert.Error(t, err)
+	})
+}
@@ -2,16 +2,25 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/health"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/server"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/config"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/job"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/router"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/pkg"
 	"go.uber.org/fx"
-	"log"
+	"os"
+	"path/filepath"
+	"time"
 )
 
+// stopTimeout 應用停止的總時限，需大於 router.healthConfig.drainDelay 與 server.shutdownTimeout 的總和
+const stopTimeout = time.Minute
+
 // @title User Service API
 // @version 1.0
 // @description User Service API 文檔
@@ -33,13 +42,23 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 func main() {
+	// user-service config validate|print|diff 以相同的流程載入配置，供部署前檢查
+	if len(os.Args) > 1 && os.Args[1] == configlib.CommandName {
+		os.Exit(configlib.RunCommand(filepath.Base(os.Args[0]), os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// TODO 依賴注入 JWT AUTH Service
-	// TODO 熔斷、超時、重試
 	app := fx.New(
 		config.Module,
 		pkg.AuthModule,
 		pkg.PostgresqlModule,
+		pkg.RedisModule,
+		pkg.TracingModule,
+		pkg.MetricsModule,
+		pkg.ResilienceModule,
+		pkg.FeatureFlagModule,
 		internal.Module,
+		job.Module,
 		router.Module,
 		// 加上 Setup 和 HTTP Server 啟動
 		fx.Invoke(
@@ -48,27 +67,30 @@ func main() {
 			},
 			StartHTTPServer, // 啟動 Gin server（用 fx.Lifecycle）
 		),
+		fx.StopTimeout(stopTimeout),
 	)
 	app.Run()
 }
 
-// StartHTTPServer 開啟 HTTP 服務
-func StartHTTPServer(lc fx.Lifecycle, r *router.Router, cfg *configlib.ServerConfig) {
-	engine := r.Engine() // 取得 gin.Engine
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+// StartHTTPServer 開啟 HTTP 服務，綁定失敗時應用停止啟動；停止時先將就緒探針改為失敗，再等待處理中的請求完成
+// 排空失敗時仍會關閉 HTTP 服務，兩者的錯誤一併回傳
+func StartHTTPServer(lc fx.Lifecycle, r *router.Router, cfg *configlib.ServerConfig, registry *health.Registry) error {
+	srv, err := server.New(cfg, r.Handler())
+	if err != nil {
+		return err
+	}
 
 	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			go func() {
-				if err := engine.Run(addr); err != nil {
-					log.Fatalf("failed to run server: %v", err)
-				}
-			}()
-			return nil
-		},
+		OnStart: srv.Start,
 		OnStop: func(ctx context.Context) error {
-			log.Println("Shutting down...")
-			return nil
+			logger.Info("draining")
+			drainErr := registry.Drain(ctx)
+			if drainErr != nil {
+				logger.Error("drain failed, shutting down anyway", logger.Err(drainErr))
+			}
+			logger.Info("shutting down http server")
+			return errors.Join(drainErr, srv.Shutdown(ctx))
 		},
 	})
+	return nil
 }
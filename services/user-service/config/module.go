@@ -1,20 +1,59 @@
 package config
 
 import (
+	"context"
+	"os"
+
 	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/observability"
+	"github.com/POABOB/slack-clone-back-end/pkg/resilience"
 	"go.uber.org/fx"
 )
 
 // Module 依賴注入統一管理
 var Module = fx.Module("config",
 	fx.Provide(
-		func() (*configlib.Config, error) {
-			return configlib.LoadConfig()
+		func() (*configlib.Watcher, error) {
+			flags := configlib.NewFlagSet(os.Args[0])
+			if err := flags.Parse(os.Args[1:]); err != nil {
+				return nil, err
+			}
+			return configlib.NewWatcher(configlib.LoadOptions{Flags: flags})
 		},
+		// 啟動時的配置快照，只有透過 Subscribe 訂閱的區塊會熱更新
+		func(watcher *configlib.Watcher) *configlib.Config { return watcher.Config() },
 		func(cfg *configlib.Config) *configlib.ServerConfig { return &cfg.Server },
 		func(cfg *configlib.Config) *configlib.RouterConfig { return &cfg.Router },
 		func(cfg *configlib.Config) *configlib.JWTConfig { return &cfg.JWT },
 		func(cfg *configlib.Config) *configlib.DatabaseConfig { return &cfg.Database },
 		func(cfg *configlib.Config) *configlib.RedisConfig { return &cfg.Redis },
+		func(cfg *configlib.Config) *configlib.SCIMConfig { return &cfg.SCIM },
+		func(cfg *configlib.Config) *configlib.OAuthConfig { return &cfg.OAuth },
+		func(cfg *configlib.Config) *configlib.ServiceAuthConfig { return &cfg.Service },
+		func(cfg *configlib.Config) *configlib.FeatureFlagConfig { return &cfg.FeatureFlag },
+		func(cfg *configlib.Config) *observability.TracingConfig { return &cfg.Tracing },
+		func(cfg *configlib.Config) *resilience.Config { return &cfg.Resilience },
 	),
+	fx.Invoke(StartWatcher),
 )
+
+// StartWatcher 初始化日誌並監聽配置檔變更
+func StartWatcher(lc fx.Lifecycle, watcher *configlib.Watcher) error {
+	if err := logger.InitLogger(watcher.Config().Log.Level); err != nil {
+		return err
+	}
+	configlib.Subscribe(watcher, func(c *configlib.Config) string { return c.Log.Level },
+		func(change configlib.Change[string]) { logger.SetLevel(change.New) })
+	// 資料庫與 Redis 連線池沿用啟動時的密碼，密碼輪替後需重啟才會生效
+	configlib.Subscribe(watcher, func(c *configlib.Config) [2]string { return [2]string{c.Database.Password, c.Redis.Password} },
+		func(configlib.Change[[2]string]) {
+			logger.Warn("database or redis password changed, restart the service to use the new password")
+		})
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error { return watcher.Start() },
+		OnStop:  func(ctx context.Context) error { return watcher.Stop() },
+	})
+	return nil
+}
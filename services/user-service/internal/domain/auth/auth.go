@@ -1,15 +1,72 @@
 package auth
 
 import (
+	"context"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
 )
 
+var (
+	// ErrAccountExpired 帳號已過期
+	ErrAccountExpired = apperror.PermissionDenied("account has expired")
+	// ErrInvalidGuest 訪客設定不正確
+	ErrInvalidGuest = apperror.InvalidArgument("invalid guest account")
+	// ErrInvalidCredentials 帳號不存在或密碼錯誤，兩者不區分以免洩漏帳號是否存在
+	ErrInvalidCredentials = apperror.Unauthenticated("invalid email or password")
+	// ErrUserDisabled 帳號已停用
+	ErrUserDisabled = apperror.PermissionDenied("user is disabled")
+)
+
 // LoginRequest 登入結構體
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
+// RegisterRequest 註冊結構體，帳號類型與權限一律使用預設值
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,password"`
+	Username string `json:"username" binding:"required,handle"`
+}
+
+// ToUser 轉換為使用者實體
+func (r *RegisterRequest) ToUser() *user.User {
+	return &user.User{
+		Email:    r.Email,
+		Password: r.Password,
+		Username: r.Username,
+	}
+}
+
+// GuestRequest 邀請訪客結構體
+type GuestRequest struct {
+	Email       string    `json:"email" binding:"required,email"`
+	Password    string    `json:"password" binding:"required,password"`
+	Username    string    `json:"username" binding:"required,handle"`
+	AccountType string    `json:"account_type" binding:"required,oneof=single_channel_guest multi_channel_guest"`
+	Channels    []string  `json:"channels" binding:"required,min=1,dive,required"`
+	ExpiresAt   time.Time `json:"expires_at" binding:"required"`
+}
+
+// ToUser 轉換為訪客使用者實體
+func (r *GuestRequest) ToUser() *user.User {
+	expiresAt := r.ExpiresAt
+	return &user.User{
+		Email:         r.Email,
+		Password:      r.Password,
+		Username:      r.Username,
+		Role:          "guest",
+		AccountType:   r.AccountType,
+		GuestChannels: r.Channels,
+		ExpiresAt:     &expiresAt,
+	}
+}
+
 // LoginResponse 登入響應 VO
 type LoginResponse struct {
 	Token string    `json:"token"`
@@ -58,7 +115,13 @@ func NewTokenResponse(token string) *TokenResponse {
 
 // AuthService 驗證邏輯介面
 type AuthService interface {
-	Register(user *user.User) error
-	Login(email, password string) (string, error)
+	Register(ctx context.Context, user *user.User) error
+	RegisterGuest(ctx context.Context, guest *user.User) error
+	Login(ctx context.Context, email, password string) (string, error)
 	GenerateToken(user *user.User) (string, error)
+	RefreshToken(ctx context.Context, userID uint) (string, error)
+	IssueTokenPair(ctx context.Context, user *user.User) (*oauth.TokenResponse, error)
+	RefreshTokenPair(ctx context.Context, refreshToken string) (*oauth.TokenResponse, error)
+	RevokeTokens(ctx context.Context, userID uint) error
+	DeactivateExpiredGuests(ctx context.Context, now time.Time) (int, error)
 }
@@ -0,0 +1,73 @@
+package group
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+// Group 使用者群組實體
+type Group struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	DisplayName string       `json:"display_name" gorm:"uniqueIndex;not null"`
+	ExternalID  string       `json:"external_id" gorm:"index"`
+	Members     []*user.User `json:"members" gorm:"many2many:group_members"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// MemberPatch 成員的增量變更，只寫入有變動的成員，未提及的成員（包含已刪除的使用者）維持不變
+// Replace 為 true 時改以 Add 取代全部成員
+type MemberPatch struct {
+	Replace bool
+	Add     []uint
+	Remove  []uint
+}
+
+// AddMembers 加入成員，並取消先前對相同成員的移除
+func (p *MemberPatch) AddMembers(ids []uint) {
+	for _, id := range ids {
+		if !slices.Contains(p.Add, id) {
+			p.Add = append(p.Add, id)
+		}
+	}
+	p.Remove = slices.DeleteFunc(p.Remove, func(id uint) bool { return slices.Contains(ids, id) })
+}
+
+// RemoveMembers 移除成員，並取消先前對相同成員的加入
+func (p *MemberPatch) RemoveMembers(ids []uint) {
+	p.Add = slices.DeleteFunc(p.Add, func(id uint) bool { return slices.Contains(ids, id) })
+	if p.Replace {
+		return
+	}
+	for _, id := range ids {
+		if !slices.Contains(p.Remove, id) {
+			p.Remove = append(p.Remove, id)
+		}
+	}
+}
+
+// ReplaceMembers 以 ids 取代全部成員，先前的變更一併捨棄
+func (p *MemberPatch) ReplaceMembers(ids []uint) {
+	p.Replace = true
+	p.Add = nil
+	p.Remove = nil
+	p.AddMembers(ids)
+}
+
+// GroupRepository 群組資料存取介面
+type GroupRepository interface {
+	// Create 建立群組並設定成員，於同一個 transaction 完成
+	Create(ctx context.Context, group *Group, memberIDs []uint) error
+	FindByID(ctx context.Context, id uint) (*Group, error)
+	FindByDisplayName(ctx context.Context, displayName string) (*Group, error)
+	FindByMemberID(ctx context.Context, userID uint) ([]*Group, error)
+	List(ctx context.Context, query *user.ListQuery) ([]*Group, int64, error)
+	// Update 更新群組並以 memberIDs 取代全部成員，於同一個 transaction 完成
+	Update(ctx context.Context, group *Group, memberIDs []uint) error
+	// Patch 更新群組並套用成員的增量變更，於同一個 transaction 完成
+	Patch(ctx context.Context, group *Group, members *MemberPatch) error
+	Delete(ctx context.Context, id uint) error
+}
@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"time"
+)
+
+// ResponseTypeCode 授權碼流程的 response_type
+const ResponseTypeCode = "code"
+
+// CodeChallengeMethodS256 唯一支援的 PKCE 方法（RFC 7636 4.2）
+const CodeChallengeMethodS256 = "S256"
+
+// AuthorizeRequest 授權請求（RFC 6749 4.1.1、RFC 7636 4.3）
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// ConsentRequest 使用者於同意畫面的決定
+type ConsentRequest struct {
+	AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// ConsentInfo 同意畫面顯示的應用程式與 scope
+type ConsentInfo struct {
+	ClientID    string             `json:"client_id"`
+	ClientName  string             `json:"client_name"`
+	RedirectURI string             `json:"redirect_uri"`
+	Scopes      []ScopeDescription `json:"scopes"`
+	State       string             `json:"state,omitempty"`
+}
+
+// ConsentResponse 使用者決定後前端應導向的位址，帶有 code 或 error
+type ConsentResponse struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// AuthorizationCode 授權碼，存放於 Redis 且只能兌換一次
+type AuthorizationCode struct {
+	Code                string    `json:"code"`
+	ClientID            string    `json:"client_id"`
+	UserID              uint      `json:"user_id"`
+	RedirectURI         string    `json:"redirect_uri"`
+	Scope               string    `json:"scope"`
+	CodeChallenge       string    `json:"code_challenge"`
+	CodeChallengeMethod string    `json:"code_challenge_method"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+// AuthorizationCodeRepository 授權碼存取介面
+type AuthorizationCodeRepository interface {
+	Save(ctx context.Context, code *AuthorizationCode) error
+	// Consume 取出並刪除授權碼
+	Consume(ctx context.Context, code string) (*AuthorizationCode, error)
+}
+
+// AuthorizationService 第三方應用程式授權邏輯介面
+type AuthorizationService interface {
+	RegisterClient(ctx context.Context, ownerID uint, req *ClientRegistrationRequest) (*ClientRegistrationResponse, error)
+	ListClients(ctx context.Context, ownerID uint) ([]*Client, error)
+	DeleteClient(ctx context.Context, ownerID uint, clientID string) error
+
+	Authorize(ctx context.Context, req *AuthorizeRequest) (*ConsentInfo, error)
+	Consent(ctx context.Context, userID uint, req *ConsentRequest) (*ConsentResponse, error)
+	ExchangeCode(ctx context.Context, req *TokenRequest) (*TokenResponse, error)
+	ClientCredentials(ctx context.Context, req *TokenRequest) (*TokenResponse, error)
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"context"
+	"time"
+)
+
+// 應用程式類型（RFC 6749 2.1）
+const (
+	// ClientTypeConfidential 可安全保存 client_secret 的伺服器端應用程式
+	ClientTypeConfidential = "confidential"
+	// ClientTypePublic 無法保存 client_secret 的應用程式，如 SPA 與行動 App，必須使用 PKCE
+	ClientTypePublic = "public"
+)
+
+// Client 第三方應用程式
+type Client struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	ClientID     string    `json:"client_id" gorm:"uniqueIndex;not null"`
+	ClientSecret string    `json:"-"`
+	Name         string    `json:"name" gorm:"not null"`
+	ClientType   string    `json:"client_type" gorm:"not null;default:'confidential'"`
+	RedirectURIs []string  `json:"redirect_uris" gorm:"type:json;serializer:json"`
+	GrantTypes   []string  `json:"grant_types" gorm:"type:json;serializer:json"`
+	Scopes       []string  `json:"scopes" gorm:"type:json;serializer:json"`
+	OwnerID      uint      `json:"owner_id" gorm:"index;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// IsPublic 是否為 public client
+func (c *Client) IsPublic() bool {
+	return c.ClientType == ClientTypePublic
+}
+
+// AllowsGrant 是否允許使用指定的 grant type
+func (c *Client) AllowsGrant(grantType string) bool {
+	return contains(c.GrantTypes, grantType)
+}
+
+// AllowsRedirectURI redirect_uri 是否與註冊的值完全相符
+func (c *Client) AllowsRedirectURI(redirectURI string) bool {
+	return contains(c.RedirectURIs, redirectURI)
+}
+
+// AllowsScope 是否允許申請指定的 scope
+func (c *Client) AllowsScope(scope string) bool {
+	return contains(c.Scopes, scope)
+}
+
+// ClientRegistrationRequest 應用程式註冊請求
+type ClientRegistrationRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	ClientType   string   `json:"client_type" binding:"required,oneof=confidential public"`
+	RedirectURIs []string `json:"redirect_uris" binding:"dive,url"`
+	GrantTypes   []string `json:"grant_types" binding:"required,min=1,dive,oneof=authorization_code client_credentials"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+}
+
+// ClientRegistrationResponse 應用程式註冊響應，client_secret 僅在註冊時回傳一次
+type ClientRegistrationResponse struct {
+	*Client
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// ClientRepository 第三方應用程式資料存取介面
+type ClientRepository interface {
+	Create(ctx context.Context, client *Client) error
+	FindByClientID(ctx context.Context, clientID string) (*Client, error)
+	FindByOwnerID(ctx context.Context, ownerID uint) ([]*Client, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package oauth
+
+import (
+	"context"
+	"time"
+)
+
+// 裝置授權狀態
+const (
+	DeviceStatusPending  = "pending"
+	DeviceStatusApproved = "approved"
+	DeviceStatusDenied   = "denied"
+)
+
+// DeviceAuthorization 裝置授權紀錄，存放於 Redis 並於過期後自動清除
+type DeviceAuthorization struct {
+	DeviceCode string    `json:"device_code"`
+	UserCode   string    `json:"user_code"`
+	ClientID   string    `json:"client_id"`
+	Scope      string    `json:"scope"`
+	Status     string    `json:"status"`
+	UserID     uint      `json:"user_id,omitempty"`
+	Interval   int       `json:"interval"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// DevicePoll 裝置的輪詢紀錄，與授權紀錄分開存放，輪詢不會覆蓋使用者的確認結果
+type DevicePoll struct {
+	LastPolledAt time.Time
+	// 因 slow_down 累計增加的輪詢間隔（秒）
+	SlowDown int
+}
+
+// DeviceCodeRequest 裝置授權請求（RFC 8628 3.1）
+type DeviceCodeRequest struct {
+	ClientID string `form:"client_id" binding:"required"`
+	Scope    string `form:"scope"`
+}
+
+// DeviceCodeResponse 裝置授權響應（RFC 8628 3.2）
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceVerificationRequest 使用者確認或拒絕裝置授權
+type DeviceVerificationRequest struct {
+	UserCode string `json:"user_code" binding:"required"`
+	Approve  bool   `json:"approve"`
+}
+
+// DeviceVerificationInfo 驗證頁面顯示的待授權資訊
+type DeviceVerificationInfo struct {
+	UserCode  string    `json:"user_code"`
+	ClientID  string    `json:"client_id"`
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceRepository 裝置授權存取介面
+type DeviceRepository interface {
+	Save(ctx context.Context, authorization *DeviceAuthorization) error
+	FindByDeviceCode(ctx context.Context, deviceCode string) (*DeviceAuthorization, error)
+	FindByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error)
+	Update(ctx context.Context, authorization *DeviceAuthorization) error
+	// RecordPoll 記錄本次輪詢時間並回傳先前的輪詢紀錄
+	RecordPoll(ctx context.Context, authorization *DeviceAuthorization, polledAt time.Time) (*DevicePoll, error)
+	// SlowDown 增加裝置的輪詢間隔
+	SlowDown(ctx context.Context, authorization *DeviceAuthorization, seconds int) error
+	// Consume 刪除並回傳授權紀錄，紀錄已被其他請求取走時回傳 ErrNotFound
+	Consume(ctx context.Context, authorization *DeviceAuthorization) (*DeviceAuthorization, error)
+}
+
+// DeviceService 裝置授權流程邏輯介面
+type DeviceService interface {
+	RequestCode(ctx context.Context, req *DeviceCodeRequest) (*DeviceCodeResponse, error)
+	GetVerification(ctx context.Context, userCode string) (*DeviceVerificationInfo, error)
+	Verify(ctx context.Context, userCode string, userID uint, approve bool) error
+	PollToken(ctx context.Context, clientID, deviceCode string) (*TokenResponse, error)
+}
@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+)
+
+// Grant types
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+var (
+	// ErrNotFound 授權紀錄或應用程式不存在
+	ErrNotFound = apperror.NotFound("authorization not found")
+	// ErrConflict 授權紀錄已存在
+	ErrConflict = apperror.Conflict("authorization already exists")
+)
+
+// Error OAuth 2.0 錯誤響應（RFC 6749 5.2）
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+}
+
+// Error 實作 error 介面
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+// Is 以錯誤碼比對，讓帶有描述的錯誤也能與預設錯誤比對
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// AppError 轉為應用層錯誤，讓非 OAuth 端點（如裝置授權確認）以 problem+json 回應
+func (e *Error) AppError() *apperror.Error {
+	code := apperror.CodeInvalidArgument
+	switch {
+	case e.Status == http.StatusUnauthorized:
+		code = apperror.CodeUnauthenticated
+	case e.Status >= http.StatusInternalServerError:
+		code = apperror.CodeInternal
+	}
+	return apperror.New(code, e.Error()).WithDetail("error", e.Code)
+}
+
+// WithDescription 回傳帶有描述的錯誤副本
+func (e *Error) WithDescription(description string) *Error {
+	return &Error{Code: e.Code, Description: description, Status: e.Status}
+}
+
+var (
+	ErrInvalidRequest          = &Error{Code: "invalid_request", Status: http.StatusBadRequest}
+	ErrInvalidClient           = &Error{Code: "invalid_client", Status: http.StatusUnauthorized}
+	ErrInvalidGrant            = &Error{Code: "invalid_grant", Status: http.StatusBadRequest}
+	ErrInvalidScope            = &Error{Code: "invalid_scope", Status: http.StatusBadRequest}
+	ErrUnauthorizedClient      = &Error{Code: "unauthorized_client", Status: http.StatusBadRequest}
+	ErrUnsupportedGrantType    = &Error{Code: "unsupported_grant_type", Status: http.StatusBadRequest}
+	ErrUnsupportedResponseType = &Error{Code: "unsupported_response_type", Status: http.StatusBadRequest}
+	ErrAuthorizationPending    = &Error{Code: "authorization_pending", Status: http.StatusBadRequest}
+	ErrSlowDown                = &Error{Code: "slow_down", Status: http.StatusBadRequest}
+	ErrAccessDenied            = &Error{Code: "access_denied", Status: http.StatusBadRequest}
+	ErrExpiredToken            = &Error{Code: "expired_token", Status: http.StatusBadRequest}
+)
+
+// TokenResponse token 響應（RFC 6749 5.1）
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// TokenRequest token 端點請求
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+	DeviceCode   string `form:"device_code"`
+	RefreshToken string `form:"refresh_token"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// RefreshTokenRepository refresh token 存取介面，refresh token 為不透明字串
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token string, userID uint, ttlSeconds int) error
+	// Consume 取出並刪除 refresh token，確保每個 refresh token 只能使用一次
+	Consume(ctx context.Context, token string) (uint, error)
+	Delete(ctx context.Context, token string) error
+}
@@ -0,0 +1,47 @@
+package oauth
+
+import (
+	"strings"
+)
+
+// 第三方應用程式可申請的 scope
+const (
+	ScopeUsersRead       = "users:read"
+	ScopeUsersReadEmail  = "users:read.email"
+	ScopeChannelsRead    = "channels:read"
+	ScopeChannelsHistory = "channels:history"
+	ScopeChatWrite       = "chat:write"
+)
+
+// Scopes scope 與同意畫面顯示的說明
+var Scopes = map[string]string{
+	ScopeUsersRead:       "檢視工作區成員的基本資料",
+	ScopeUsersReadEmail:  "檢視工作區成員的郵箱",
+	ScopeChannelsRead:    "檢視頻道列表",
+	ScopeChannelsHistory: "檢視頻道中的訊息",
+	ScopeChatWrite:       "以應用程式身分傳送訊息",
+}
+
+// ScopeDescription 同意畫面上的單一 scope
+type ScopeDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ParseScope 解析以空白分隔的 scope 字串並去除重複
+func ParseScope(scope string) []string {
+	scopes := make([]string, 0)
+	for _, s := range strings.Fields(scope) {
+		if !contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// UserInfo 第三方應用程式可讀取的使用者資料
+type UserInfo struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+}
@@ -0,0 +1,160 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// SCIM 2.0 schema URN 與媒體類型（RFC 7643、RFC 7644）
+const (
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	ContentType = "application/scim+json"
+)
+
+// SCIM 錯誤類型（scimType）
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeUniqueness    = "uniqueness"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeNoTarget      = "noTarget"
+)
+
+var (
+	// ErrNotFound 資源不存在
+	ErrNotFound = errors.New("resource not found")
+	// ErrUniqueness 唯一值衝突
+	ErrUniqueness = errors.New("resource already exists")
+	// ErrInvalidFilter 無效的 filter 語法或不支援的屬性
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrInvalidValue 無效的屬性值
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrInvalidPath 無效的 PATCH path
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrNoTarget PATCH path 沒有對應的目標
+	ErrNoTarget = errors.New("no target")
+)
+
+// Meta 資源中繼資料
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// Email 使用者郵箱
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// GroupRef 使用者所屬群組（唯讀）
+type GroupRef struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// Member 群組成員
+type Member struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// User SCIM User 資源
+type User struct {
+	Schemas     []string   `json:"schemas"`
+	ID          string     `json:"id,omitempty"`
+	ExternalID  string     `json:"externalId,omitempty"`
+	UserName    string     `json:"userName" binding:"required"`
+	DisplayName string     `json:"displayName,omitempty"`
+	Active      *bool      `json:"active,omitempty"`
+	Password    string     `json:"password,omitempty"`
+	Emails      []Email    `json:"emails,omitempty"`
+	Groups      []GroupRef `json:"groups,omitempty"`
+	Meta        *Meta      `json:"meta,omitempty"`
+}
+
+// Group SCIM Group 資源
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName" binding:"required"`
+	Members     []Member `json:"members"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// ListResponse 列表查詢響應
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	StartIndex   int           `json:"startIndex"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// PatchOperation 單一 PATCH 操作，Value 保留原始 JSON 解碼結果
+type PatchOperation struct {
+	Op    string      `json:"op" binding:"required"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// PatchRequest PATCH 請求
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations" binding:"required,min=1"`
+}
+
+// Error SCIM 錯誤響應
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// ListParams 列表查詢參數，StartIndex 從 1 開始
+type ListParams struct {
+	Filter     string `form:"filter"`
+	StartIndex int    `form:"startIndex"`
+	Count      *int   `form:"count"`
+}
+
+// NewError 創建新的 SCIM 錯誤響應
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// SCIMService SCIM 佈建邏輯介面
+type SCIMService interface {
+	CreateUser(ctx context.Context, u *User) (*User, error)
+	GetUser(ctx context.Context, id string) (*User, error)
+	ListUsers(ctx context.Context, params *ListParams) (*ListResponse, error)
+	ReplaceUser(ctx context.Context, id string, u *User) (*User, error)
+	PatchUser(ctx context.Context, id string, req *PatchRequest) (*User, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	CreateGroup(ctx context.Context, g *Group) (*Group, error)
+	GetGroup(ctx context.Context, id string) (*Group, error)
+	ListGroups(ctx context.Context, params *ListParams) (*ListResponse, error)
+	ReplaceGroup(ctx context.Context, id string, g *Group) (*Group, error)
+	PatchGroup(ctx context.Context, id string, req *PatchRequest) (*Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
@@ -1,35 +1,108 @@
 package user
 
 import (
+	"context"
 	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/apperror"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+)
+
+var (
+	// ErrUserNotFound 使用者不存在
+	ErrUserNotFound = apperror.NotFound("user not found")
+	// ErrEmailExists Email 已被註冊
+	ErrEmailExists = apperror.Conflict("email already exists")
+)
+
+// 帳號類型，與 token 中的 account_type 共用同一組定義
+const (
+	// AccountTypeMember 一般成員
+	AccountTypeMember = rbac.AccountTypeMember
+	// AccountTypeSingleChannelGuest 單一頻道訪客
+	AccountTypeSingleChannelGuest = rbac.AccountTypeSingleChannelGuest
+	// AccountTypeMultiChannelGuest 多頻道訪客
+	AccountTypeMultiChannelGuest = rbac.AccountTypeMultiChannelGuest
 )
 
 // User 使用者實體
 type User struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Email       string    `json:"email" gorm:"uniqueIndex"`
-	Password    string    `json:"-" gorm:"not null"`
-	Username    string    `json:"username" gorm:"not null"`
-	Role        string    `json:"role" gorm:"not null;default:'user'"`
-	Permissions []string  `json:"permissions" gorm:"type:json"`
-	LastLogin   time.Time `json:"last_login"`
-	IsDeleted   bool      `json:"is_deleted"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Email         string     `json:"email" gorm:"uniqueIndex"`
+	Password      string     `json:"-" gorm:"not null"`
+	Username      string     `json:"username" gorm:"not null"`
+	Role          string     `json:"role" gorm:"not null;default:'user'"`
+	Permissions   []string   `json:"permissions" gorm:"type:json;serializer:json"`
+	ExternalID    string     `json:"external_id" gorm:"index"`
+	AccountType   string     `json:"account_type" gorm:"not null;default:'member'"`
+	GuestChannels []string   `json:"guest_channels,omitempty" gorm:"type:json;serializer:json"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	LastLogin     time.Time  `json:"last_login"`
+	IsDisabled    bool       `json:"is_disabled"`
+	IsDeleted     bool       `json:"is_deleted"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// UpdateRequest 使用者可自行修改的欄位，未提供的欄位維持不變
+type UpdateRequest struct {
+	Username *string `json:"username" binding:"omitempty,handle"`
+	Password *string `json:"password" binding:"omitempty,password"`
+}
+
+// IsGuest 是否為訪客帳號
+func (u *User) IsGuest() bool {
+	return u.AccountType == AccountTypeSingleChannelGuest || u.AccountType == AccountTypeMultiChannelGuest
+}
+
+// IsExpired 帳號是否已過期，未設定到期時間的帳號永不過期
+func (u *User) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(now)
+}
+
+// 查詢條件運算子
+const (
+	OperatorEqual          = "eq"
+	OperatorNotEqual       = "ne"
+	OperatorContains       = "co"
+	OperatorStartsWith     = "sw"
+	OperatorEndsWith       = "ew"
+	OperatorGreaterThan    = "gt"
+	OperatorGreaterOrEqual = "ge"
+	OperatorLessThan       = "lt"
+	OperatorLessOrEqual    = "le"
+	OperatorPresent        = "pr"
+)
+
+// Condition 單一查詢條件，Field 為實體欄位名稱（如 username、email）
+type Condition struct {
+	Field    string
+	Operator string
+	Value    interface{}
+}
+
+// ListQuery 列表查詢參數，所有 Conditions 以 AND 組合
+type ListQuery struct {
+	Conditions []Condition
+	Offset     int
+	Limit      int
 }
 
 // UserRepository 使用者資料存取介面
 type UserRepository interface {
-	Create(user *User) error
-	FindByID(id uint) (*User, error)
-	FindByEmail(email string) (*User, error)
-	Update(user *User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *User) error
+	FindByID(ctx context.Context, id uint) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByUsername(ctx context.Context, username string) (*User, error)
+	List(ctx context.Context, query *ListQuery) ([]*User, int64, error)
+	Update(ctx context.Context, user *User) error
+	UpdateProfile(ctx context.Context, id uint, req *UpdateRequest) error
+	Delete(ctx context.Context, id uint) error
 }
 
 // UserService 使用者業務邏輯介面
 type UserService interface {
-	GetUserByID(id uint) (*User, error)
-	UpdateUser(user *User) error
-	DeleteUser(id uint) error
+	GetUserByID(ctx context.Context, id uint) (*User, error)
+	UpdateUser(ctx context.Context, id uint, req *UpdateRequest) error
+	DeleteUser(ctx context.Context, id uint) error
 }
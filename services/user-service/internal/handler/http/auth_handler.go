@@ -1,21 +1,29 @@
 package handler
 
 import (
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
-	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type AuthHandler struct {
 	authService    auth.AuthService
+	jwtManager     *rbac.RBACJWTManager
 	rbacMiddleware gin.HandlerFunc
+	rateLimits     *middleware.RateLimitPolicies
+	idempotency    *middleware.Idempotency
 }
 
-func NewAuthHandler(authService auth.AuthService, rbacMiddleware gin.HandlerFunc) *AuthHandler {
+func NewAuthHandler(authService auth.AuthService, jwtManager *rbac.RBACJWTManager, rbacMiddleware gin.HandlerFunc,
+	rateLimits *middleware.RateLimitPolicies, idempotency *middleware.Idempotency) *AuthHandler {
 	return &AuthHandler{
 		authService:    authService,
+		jwtManager:     jwtManager,
 		rbacMiddleware: rbacMiddleware,
+		rateLimits:     rateLimits,
+		idempotency:    idempotency,
 	}
 }
 
@@ -23,27 +31,47 @@ func NewAuthHandler(authService auth.AuthService, rbacMiddleware gin.HandlerFunc
 func (h *AuthHandler) RegisterRoutes(e *gin.RouterGroup) {
 	authGroup := e.Group("/auth")
 
-	authGroup.POST("/register", h.Register)
-	authGroup.POST("/register", h.Login)
+	// 未登入的端點依 IP 限制，降低暴力破解與大量註冊的風險
+	// 建立帳號的端點支援 Idempotency-Key，用戶端重試時不會重複建立
+	authGroup.POST("/register", h.rateLimits.Middleware("register"), h.idempotency.Middleware(), h.Register)
+	authGroup.POST("/login", h.rateLimits.Middleware("login"), h.Login)
 	authGroup.Use(h.rbacMiddleware)
 	{
-		authGroup.DELETE("/refresh", h.RefreshToken)
+		// 金鑰輪替後，舊金鑰簽發的 token 只能使用到 keyOverlap 結束，不得換發新 token
+		authGroup.DELETE("/refresh", rbac.RequireCurrentKey(h.jwtManager), h.RefreshToken)
 		authGroup.DELETE("/info", h.GetUserInfo)
+		authGroup.POST("/guests", rbac.RequireMember(), rbac.RequirePermission("guest:invite"),
+			h.idempotency.Middleware(), h.RegisterGuest)
 	}
 }
 
 // TODO OAUTH 之類的登入、註冊
-// TODO service、repo 層錯誤處理
 
 // Register 處理使用者註冊請求
 func (h *AuthHandler) Register(c *gin.Context) {
-	var singleUser user.User
-	if err := c.ShouldBindJSON(&singleUser); err != nil {
+	var registerRequest auth.RegisterRequest
+	if err := c.ShouldBindJSON(&registerRequest); err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	if err := h.authService.Register(&singleUser); err != nil {
+	if err := h.authService.Register(c.Request.Context(), registerRequest.ToUser()); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusCreated, nil)
+}
+
+// RegisterGuest 邀請訪客，訪客帳號到期後會自動停用
+func (h *AuthHandler) RegisterGuest(c *gin.Context) {
+	var guestRequest auth.GuestRequest
+	if err := c.ShouldBindJSON(&guestRequest); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := h.authService.RegisterGuest(c.Request.Context(), guestRequest.ToUser()); err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
 		return
 	}
@@ -59,7 +87,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.Login(loginRequest.Email, loginRequest.Password)
+	token, err := h.authService.Login(c.Request.Context(), loginRequest.Email, loginRequest.Password)
 	if err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
 		return
@@ -68,20 +96,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, auth.NewTokenResponse(token))
 }
 
-// RefreshToken 刷新 token
+// RefreshToken 刷新 token，帳號資料以資料庫為準而非沿用舊 token 的 claims
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	userId := c.MustGet("user_id").(uint)
-	email := c.MustGet("email").(string)
-	username := c.MustGet("username").(string)
-	role := c.MustGet("role").(string)
-	permissions := c.MustGet("permissions").([]string)
-	token, err := h.authService.GenerateToken(&user.User{
-		ID:          userId,
-		Email:       email,
-		Username:    username,
-		Role:        role,
-		Permissions: permissions,
-	})
+	token, err := h.authService.RefreshToken(c.Request.Context(), userId)
 	if err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
 		return
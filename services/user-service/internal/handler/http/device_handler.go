@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceHandler 已登入使用者確認裝置授權
+type DeviceHandler struct {
+	deviceService  oauth.DeviceService
+	rbacMiddleware gin.HandlerFunc
+}
+
+// NewDeviceHandler 創建新的裝置授權確認處理器實例
+func NewDeviceHandler(deviceService oauth.DeviceService, rbacMiddleware gin.HandlerFunc) *DeviceHandler {
+	return &DeviceHandler{
+		deviceService:  deviceService,
+		rbacMiddleware: rbacMiddleware,
+	}
+}
+
+// RegisterRoutes sets up the device verification routes on the provided RouterGroup with RBAC middleware.
+func (h *DeviceHandler) RegisterRoutes(e *gin.RouterGroup) {
+	deviceGroup := e.Group("/auth/device")
+	deviceGroup.Use(h.rbacMiddleware)
+	{
+		deviceGroup.GET("", h.GetVerification)
+		deviceGroup.POST("", h.Verify)
+	}
+}
+
+// GetVerification 依 user_code 獲取待確認的裝置授權
+func (h *DeviceHandler) GetVerification(c *gin.Context) {
+	userCode := c.Query("user_code")
+	if userCode == "" {
+		_ = c.Error(oauth.ErrInvalidRequest.WithDescription("user_code is required")).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	info, err := h.deviceService.GetVerification(c.Request.Context(), userCode)
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// Verify 確認或拒絕裝置授權
+func (h *DeviceHandler) Verify(c *gin.Context) {
+	var req oauth.DeviceVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	userId := c.MustGet("user_id").(uint)
+	if err := h.deviceService.Verify(c.Request.Context(), req.UserCode, userId, req.Approve); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
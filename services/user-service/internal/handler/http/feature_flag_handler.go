@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/featureflag"
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagHandler 查詢目前使用者啟用的功能，以及管理員調整旗標
+type FeatureFlagHandler struct {
+	evaluator      *featureflag.Evaluator
+	adminHandler   *featureflag.AdminHandler
+	rbacMiddleware gin.HandlerFunc
+}
+
+// NewFeatureFlagHandler 創建新的功能旗標處理器實例
+func NewFeatureFlagHandler(evaluator *featureflag.Evaluator, adminHandler *featureflag.AdminHandler,
+	rbacMiddleware gin.HandlerFunc) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		evaluator:      evaluator,
+		adminHandler:   adminHandler,
+		rbacMiddleware: rbacMiddleware,
+	}
+}
+
+// RegisterRoutes sets up the feature flag routes on the provided RouterGroup with RBAC middleware.
+func (h *FeatureFlagHandler) RegisterRoutes(e *gin.RouterGroup) {
+	e.GET("/feature-flags", h.rbacMiddleware, h.ListEnabled)
+
+	adminGroup := e.Group("/admin")
+	adminGroup.Use(h.rbacMiddleware, rbac.RequirePermission(featureflag.PermissionManage))
+	h.adminHandler.RegisterRoutes(adminGroup)
+}
+
+// ListEnabled 列出所有旗標對目前使用者的評估結果，供前端決定要顯示的功能
+func (h *FeatureFlagHandler) ListEnabled(c *gin.Context) {
+	flags, err := h.evaluator.EvaluateAll(c.Request.Context(), featureflag.SubjectFromContext(c))
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/servicetoken"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	"github.com/gin-gonic/gin"
+)
+
+// InternalHandler 供工作區內其他服務呼叫的內部 API，僅接受 service token
+type InternalHandler struct {
+	userService       user.UserService
+	serviceMiddleware gin.HandlerFunc
+	allowedCallers    []string
+}
+
+// NewInternalHandler 創建新的內部 API 處理器實例
+func NewInternalHandler(userService user.UserService, manager *servicetoken.Manager,
+	cfg *configlib.ServiceAuthConfig) *InternalHandler {
+	return &InternalHandler{
+		userService:       userService,
+		serviceMiddleware: servicetoken.Middleware(manager),
+		allowedCallers:    cfg.AllowedCallers,
+	}
+}
+
+// RegisterRoutes sets up the internal routes on the provided RouterGroup restricted to the allowed calling services.
+func (h *InternalHandler) RegisterRoutes(e *gin.RouterGroup) {
+	internalGroup := e.Group("")
+	internalGroup.Use(h.serviceMiddleware, servicetoken.RequireService(h.allowedCallers...))
+	{
+		internalGroup.GET("/users/:user_id", h.GetUser)
+	}
+}
+
+// GetUser 依 ID 獲取使用者訊息
+func (h *InternalHandler) GetUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		_ = c.Error(authlib.ErrInvalidID).SetType(gin.ErrorTypePublic)
+		return
+	}
+
+	singleUser, err := h.userService.GetUserByID(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusOK, singleUser)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthAppHandler 第三方應用程式註冊與使用者同意畫面，需以第一方 token 登入
+type OAuthAppHandler struct {
+	authorizationService oauth.AuthorizationService
+	rbacMiddleware       gin.HandlerFunc
+}
+
+// NewOAuthAppHandler 創建新的第三方應用程式處理器實例
+func NewOAuthAppHandler(authorizationService oauth.AuthorizationService, rbacMiddleware gin.HandlerFunc) *OAuthAppHandler {
+	return &OAuthAppHandler{
+		authorizationService: authorizationService,
+		rbacMiddleware:       rbacMiddleware,
+	}
+}
+
+// RegisterRoutes sets up the OAuth app registration and consent routes on the provided RouterGroup with RBAC middleware.
+func (h *OAuthAppHandler) RegisterRoutes(e *gin.RouterGroup) {
+	oauthGroup := e.Group("/oauth")
+	oauthGroup.Use(h.rbacMiddleware)
+	{
+		oauthGroup.POST("/clients", rbac.RequireMember(), h.RegisterClient)
+		oauthGroup.GET("/clients", h.ListClients)
+		oauthGroup.DELETE("/clients/:client_id", h.DeleteClient)
+
+		oauthGroup.GET("/authorize", h.Authorize)
+		oauthGroup.POST("/authorize", h.Consent)
+	}
+}
+
+// RegisterClient 註冊第三方應用程式，client_secret 僅回傳一次
+func (h *OAuthAppHandler) RegisterClient(c *gin.Context) {
+	var req oauth.ClientRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	resp, err := h.authorizationService.RegisterClient(c.Request.Context(), c.MustGet("user_id").(uint), &req)
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListClients 列出自己註冊的應用程式
+func (h *OAuthAppHandler) ListClients(c *gin.Context) {
+	clients, err := h.authorizationService.ListClients(c.Request.Context(), c.MustGet("user_id").(uint))
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// DeleteClient 刪除自己註冊的應用程式
+func (h *OAuthAppHandler) DeleteClient(c *gin.Context) {
+	if err := h.authorizationService.DeleteClient(c.Request.Context(), c.MustGet("user_id").(uint), c.Param("client_id")); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Authorize 驗證授權請求並回傳同意畫面所需的應用程式與 scope 說明
+func (h *OAuthAppHandler) Authorize(c *gin.Context) {
+	var req oauth.AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		renderOAuthError(c, oauth.ErrInvalidRequest.WithDescription(err.Error()))
+		return
+	}
+
+	info, err := h.authorizationService.Authorize(c.Request.Context(), &req)
+	if err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+	renderOAuth(c, info)
+}
+
+// Consent 使用者同意或拒絕授權，回傳前端應導向的 redirect_uri
+func (h *OAuthAppHandler) Consent(c *gin.Context) {
+	var req oauth.ConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		renderOAuthError(c, oauth.ErrInvalidRequest.WithDescription(err.Error()))
+		return
+	}
+
+	resp, err := h.authorizationService.Consent(c.Request.Context(), c.MustGet("user_id").(uint), &req)
+	if err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+	renderOAuth(c, resp)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/scoped"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthHandler OAuth 2.0 端點，錯誤依 RFC 6749 格式直接回應，不經過全局錯誤處理
+type OAuthHandler struct {
+	authService          auth.AuthService
+	deviceService        oauth.DeviceService
+	authorizationService oauth.AuthorizationService
+	userService          user.UserService
+	scopedMiddleware     gin.HandlerFunc
+}
+
+// NewOAuthHandler 創建新的 OAuth 處理器實例
+func NewOAuthHandler(authService auth.AuthService, deviceService oauth.DeviceService,
+	authorizationService oauth.AuthorizationService, userService user.UserService,
+	jwtManager *scoped.ScopedJWTManager) *OAuthHandler {
+	return &OAuthHandler{
+		authService:          authService,
+		deviceService:        deviceService,
+		authorizationService: authorizationService,
+		userService:          userService,
+		scopedMiddleware:     scoped.ScopedMiddleware(jwtManager),
+	}
+}
+
+// RegisterRoutes sets up the OAuth 2.0 endpoints on the provided RouterGroup.
+func (h *OAuthHandler) RegisterRoutes(e *gin.RouterGroup) {
+	e.POST("/device/code", h.RequestDeviceCode)
+	e.POST("/token", h.Token)
+	e.GET("/userinfo", h.scopedMiddleware, scoped.RequireUser(), scoped.RequireScope(oauth.ScopeUsersRead), h.UserInfo)
+}
+
+// RequestDeviceCode 裝置授權請求
+// @Summary 裝置授權請求
+// @Id OAuth-1
+// @Tags OAuth
+// @accept application/x-www-form-urlencoded
+// @produce json
+// @param client_id formData string true "Client ID"
+// @param scope formData string false "Scope"
+// @Success 200 {object} oauth.DeviceCodeResponse
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Router /oauth/device/code [post]
+func (h *OAuthHandler) RequestDeviceCode(c *gin.Context) {
+	var req oauth.DeviceCodeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		renderOAuthError(c, oauth.ErrInvalidRequest.WithDescription(err.Error()))
+		return
+	}
+
+	resp, err := h.deviceService.RequestCode(c.Request.Context(), &req)
+	if err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+	renderOAuth(c, resp)
+}
+
+// Token 換發 token，支援 authorization_code、client_credentials、device_code 與 refresh_token grant
+// @Summary 換發 token
+// @Id OAuth-2
+// @Tags OAuth
+// @accept application/x-www-form-urlencoded
+// @produce json
+// @param grant_type formData string true "Grant type"
+// @param client_id formData string false "Client ID"
+// @param client_secret formData string false "Client secret，亦可使用 HTTP Basic"
+// @param scope formData string false "Scope"
+// @param code formData string false "Authorization code"
+// @param redirect_uri formData string false "Redirect URI"
+// @param code_verifier formData string false "PKCE code verifier"
+// @param device_code formData string false "Device code"
+// @param refresh_token formData string false "Refresh token"
+// @Success 200 {object} oauth.TokenResponse
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	var req oauth.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		renderOAuthError(c, oauth.ErrInvalidRequest.WithDescription(err.Error()))
+		return
+	}
+	// client 驗證優先使用 HTTP Basic（RFC 6749 2.3.1）
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, _ = url.QueryUnescape(clientID)
+		req.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	var (
+		token *oauth.TokenResponse
+		err   error
+	)
+	switch req.GrantType {
+	case oauth.GrantTypeAuthorizationCode:
+		token, err = h.authorizationService.ExchangeCode(c.Request.Context(), &req)
+	case oauth.GrantTypeClientCredentials:
+		token, err = h.authorizationService.ClientCredentials(c.Request.Context(), &req)
+	case oauth.GrantTypeDeviceCode:
+		if req.DeviceCode == "" || req.ClientID == "" {
+			renderOAuthError(c, oauth.ErrInvalidRequest.WithDescription("device_code and client_id are required"))
+			return
+		}
+		token, err = h.deviceService.PollToken(c.Request.Context(), req.ClientID, req.DeviceCode)
+	case oauth.GrantTypeRefreshToken:
+		if req.RefreshToken == "" {
+			renderOAuthError(c, oauth.ErrInvalidRequest.WithDescription("refresh_token is required"))
+			return
+		}
+		token, err = h.authService.RefreshTokenPair(c.Request.Context(), req.RefreshToken)
+	default:
+		err = oauth.ErrUnsupportedGrantType
+	}
+	if err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+	renderOAuth(c, token)
+}
+
+// UserInfo 以第三方應用程式 token 獲取授權使用者的基本資料，郵箱需 users:read.email
+// @Summary 獲取授權使用者資料
+// @Id OAuth-3
+// @Tags OAuth
+// @produce json
+// @Security BearerAuth
+// @Success 200 {object} oauth.UserInfo
+// @Router /oauth/userinfo [get]
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	singleUser, err := h.userService.GetUserByID(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+
+	info := &oauth.UserInfo{ID: singleUser.ID, Username: singleUser.Username}
+	if c.GetString("email") != "" {
+		info.Email = singleUser.Email
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// renderOAuth 輸出 OAuth 響應，token 相關響應不得被快取（RFC 6749 5.1）
+func renderOAuth(c *gin.Context, body interface{}) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, body)
+}
+
+// renderOAuthError 輸出 OAuth 錯誤響應，非 OAuth 錯誤一律視為 server_error
+func renderOAuthError(c *gin.Context, err error) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var oauthErr *oauth.Error
+	if errors.As(err, &oauthErr) {
+		c.AbortWithStatusJSON(oauthErr.Status, oauthErr)
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Error("oauth request error",
+		logger.String("path", c.Request.URL.Path),
+		logger.String("method", c.Request.Method),
+		logger.Err(err),
+	)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, &oauth.Error{Code: "server_error"})
+}
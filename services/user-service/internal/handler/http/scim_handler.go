@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/scim"
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMHandler SCIM 2.0 佈建 API，錯誤依 RFC 7644 格式直接回應，不經過全局錯誤處理
+type SCIMHandler struct {
+	scimService     scim.SCIMService
+	tokenMiddleware gin.HandlerFunc
+}
+
+// NewSCIMHandler 創建新的 SCIM 處理器實例，傳入 watcher 時佈建 token 隨配置輪替
+func NewSCIMHandler(scimService scim.SCIMService, cfg *configlib.SCIMConfig, watcher *configlib.Watcher) *SCIMHandler {
+	tokens := authlib.NewTokenSet(cfg.Token)
+	if watcher != nil {
+		configlib.Subscribe(watcher, func(c *configlib.Config) string { return c.SCIM.Token },
+			func(change configlib.Change[string]) {
+				tokens.Set(change.New)
+				logger.Info("scim token rotated")
+			})
+	}
+	return &SCIMHandler{
+		scimService:     scimService,
+		tokenMiddleware: tokens.Middleware(),
+	}
+}
+
+// RegisterRoutes sets up the SCIM provisioning routes on the provided RouterGroup protected by the provisioning token.
+func (h *SCIMHandler) RegisterRoutes(e *gin.RouterGroup) {
+	scimGroup := e.Group("")
+	scimGroup.Use(h.tokenMiddleware)
+	{
+		scimGroup.POST("/Users", h.CreateUser)
+		scimGroup.GET("/Users", h.ListUsers)
+		scimGroup.GET("/Users/:id", h.GetUser)
+		scimGroup.PUT("/Users/:id", h.ReplaceUser)
+		scimGroup.PATCH("/Users/:id", h.PatchUser)
+		scimGroup.DELETE("/Users/:id", h.DeleteUser)
+
+		scimGroup.POST("/Groups", h.CreateGroup)
+		scimGroup.GET("/Groups", h.ListGroups)
+		scimGroup.GET("/Groups/:id", h.GetGroup)
+		scimGroup.PUT("/Groups/:id", h.ReplaceGroup)
+		scimGroup.PATCH("/Groups/:id", h.PatchGroup)
+		scimGroup.DELETE("/Groups/:id", h.DeleteGroup)
+	}
+}
+
+// CreateUser 佈建使用者
+// @Summary 佈建使用者
+// @Id SCIM-1
+// @Tags SCIM
+// @accept application/scim+json
+// @produce application/scim+json
+// @Security BearerAuth
+// @param user body scim.User true "SCIM User"
+// @Success 201 {object} scim.User
+// @Failure 400 {object} scim.Error
+// @Failure 409 {object} scim.Error
+// @Router /scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	created, err := h.scimService.CreateUser(c.Request.Context(), &resource)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusCreated, created)
+}
+
+// ListUsers 查詢使用者，支援 filter、startIndex 與 count
+// @Summary 查詢使用者
+// @Id SCIM-2
+// @Tags SCIM
+// @produce application/scim+json
+// @Security BearerAuth
+// @param filter query string false "SCIM filter，例如 userName eq \"bjensen\""
+// @param startIndex query int false "起始位置（從 1 開始）"
+// @param count query int false "每頁筆數"
+// @Success 200 {object} scim.ListResponse
+// @Failure 400 {object} scim.Error
+// @Router /scim/v2/Users [get]
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	var params scim.ListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	list, err := h.scimService.ListUsers(c.Request.Context(), &params)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, list)
+}
+
+// GetUser 獲取單一使用者
+// @Summary 獲取使用者
+// @Id SCIM-3
+// @Tags SCIM
+// @produce application/scim+json
+// @Security BearerAuth
+// @param id path string true "使用者 ID"
+// @Success 200 {object} scim.User
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	resource, err := h.scimService.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, resource)
+}
+
+// ReplaceUser 取代使用者
+// @Summary 取代使用者
+// @Id SCIM-4
+// @Tags SCIM
+// @accept application/scim+json
+// @produce application/scim+json
+// @Security BearerAuth
+// @param id path string true "使用者 ID"
+// @param user body scim.User true "SCIM User"
+// @Success 200 {object} scim.User
+// @Failure 400 {object} scim.Error
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	replaced, err := h.scimService.ReplaceUser(c.Request.Context(), c.Param("id"), &resource)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, replaced)
+}
+
+// PatchUser 部分更新使用者，停用帳號使用 replace active=false
+// @Summary 部分更新使用者
+// @Id SCIM-5
+// @Tags SCIM
+// @accept application/scim+json
+// @produce application/scim+json
+// @Security BearerAuth
+// @param id path string true "使用者 ID"
+// @param patch body scim.PatchRequest true "PATCH 操作"
+// @Success 200 {object} scim.User
+// @Failure 400 {object} scim.Error
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	patched, err := h.scimService.PatchUser(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, patched)
+}
+
+// DeleteUser 刪除使用者
+// @Summary 刪除使用者
+// @Id SCIM-6
+// @Tags SCIM
+// @Security BearerAuth
+// @param id path string true "使用者 ID"
+// @Success 204
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	if err := h.scimService.DeleteUser(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreateGroup 建立群組
+// @Summary 建立群組
+// @Id SCIM-7
+// @Tags SCIM
+// @accept application/scim+json
+// @produce application/scim+json
+// @Security BearerAuth
+// @param group body scim.Group true "SCIM Group"
+// @Success 201 {object} scim.Group
+// @Failure 400 {object} scim.Error
+// @Failure 409 {object} scim.Error
+// @Router /scim/v2/Groups [post]
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	created, err := h.scimService.CreateGroup(c.Request.Context(), &resource)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusCreated, created)
+}
+
+// ListGroups 查詢群組，支援 filter、startIndex 與 count
+// @Summary 查詢群組
+// @Id SCIM-8
+// @Tags SCIM
+// @produce application/scim+json
+// @Security BearerAuth
+// @param filter query string false "SCIM filter，例如 displayName eq \"Engineering\""
+// @param startIndex query int false "起始位置（從 1 開始）"
+// @param count query int false "每頁筆數"
+// @Success 200 {object} scim.ListResponse
+// @Failure 400 {object} scim.Error
+// @Router /scim/v2/Groups [get]
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	var params scim.ListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	list, err := h.scimService.ListGroups(c.Request.Context(), &params)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, list)
+}
+
+// GetGroup 獲取單一群組
+// @Summary 獲取群組
+// @Id SCIM-9
+// @Tags SCIM
+// @produce application/scim+json
+// @Security BearerAuth
+// @param id path string true "群組 ID"
+// @Success 200 {object} scim.Group
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Groups/{id} [get]
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	resource, err := h.scimService.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, resource)
+}
+
+// ReplaceGroup 取代群組
+// @Summary 取代群組
+// @Id SCIM-10
+// @Tags SCIM
+// @accept application/scim+json
+// @produce application/scim+json
+// @Security BearerAuth
+// @param id path string true "群組 ID"
+// @param group body scim.Group true "SCIM Group"
+// @Success 200 {object} scim.Group
+// @Failure 400 {object} scim.Error
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Groups/{id} [put]
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	replaced, err := h.scimService.ReplaceGroup(c.Request.Context(), c.Param("id"), &resource)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, replaced)
+}
+
+// PatchGroup 部分更新群組成員或屬性
+// @Summary 部分更新群組
+// @Id SCIM-11
+// @Tags SCIM
+// @accept application/scim+json
+// @produce application/scim+json
+// @Security BearerAuth
+// @param id path string true "群組 ID"
+// @param patch body scim.PatchRequest true "PATCH 操作"
+// @Success 200 {object} scim.Group
+// @Failure 400 {object} scim.Error
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Groups/{id} [patch]
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	patched, err := h.scimService.PatchGroup(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, patched)
+}
+
+// DeleteGroup 刪除群組
+// @Summary 刪除群組
+// @Id SCIM-12
+// @Tags SCIM
+// @Security BearerAuth
+// @param id path string true "群組 ID"
+// @Success 204
+// @Failure 404 {object} scim.Error
+// @Router /scim/v2/Groups/{id} [delete]
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	if err := h.scimService.DeleteGroup(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// handleError 將服務層錯誤轉換為 SCIM 錯誤響應
+func (h *SCIMHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scim.ErrNotFound):
+		h.renderError(c, http.StatusNotFound, "", err.Error())
+	case errors.Is(err, scim.ErrUniqueness):
+		h.renderError(c, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+	case errors.Is(err, scim.ErrInvalidFilter):
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err.Error())
+	case errors.Is(err, scim.ErrInvalidPath):
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidPath, err.Error())
+	case errors.Is(err, scim.ErrNoTarget):
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeNoTarget, err.Error())
+	case errors.Is(err, scim.ErrInvalidValue):
+		h.renderError(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+	default:
+		logger.FromContext(c.Request.Context()).Error("scim request error",
+			logger.String("path", c.Request.URL.Path),
+			logger.String("method", c.Request.Method),
+			logger.Err(err),
+		)
+		h.renderError(c, http.StatusInternalServerError, "", "internal server error")
+	}
+}
+
+// renderError 回應 SCIM 錯誤
+func (h *SCIMHandler) renderError(c *gin.Context, status int, scimType, detail string) {
+	h.render(c, status, scim.NewError(status, scimType, detail))
+	c.Abort()
+}
+
+// render 以 application/scim+json 回應
+func (h *SCIMHandler) render(c *gin.Context, status int, obj interface{}) {
+	c.Header("Content-Type", scim.ContentType+"; charset=utf-8")
+	c.JSON(status, obj)
+}
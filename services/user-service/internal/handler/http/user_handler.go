@@ -3,6 +3,7 @@ package handler
 import (
 	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
 	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/middleware"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
 	"net/http"
 	"strconv"
@@ -13,21 +14,24 @@ import (
 type UserHandler struct {
 	userService    user.UserService
 	rbacMiddleware gin.HandlerFunc
+	rateLimits     *middleware.RateLimitPolicies
 }
 
 // NewUserHandler 創建新的使用者處理器實例
-func NewUserHandler(userService user.UserService, rbacMiddleware gin.HandlerFunc) *UserHandler {
+func NewUserHandler(userService user.UserService, rbacMiddleware gin.HandlerFunc,
+	rateLimits *middleware.RateLimitPolicies) *UserHandler {
 	return &UserHandler{
 		userService:    userService,
 		rbacMiddleware: rbacMiddleware,
+		rateLimits:     rateLimits,
 	}
 }
 
-// TODO 針對單一路由進行速率限制
 // RegisterRoutes sets up the user-related routes on the provided RouterGroup with RBAC middleware and permission checks.
+// 速率限制放在 RBAC 之後，default 策略才能依 user_id 計算
 func (h *UserHandler) RegisterRoutes(e *gin.RouterGroup) {
 	userGroup := e.Group("/user")
-	userGroup.Use(h.rbacMiddleware)
+	userGroup.Use(h.rbacMiddleware, h.rateLimits.Middleware("default"))
 	{
 		userGroup.GET("/:user_id", rbac.RequirePermission("user:read"), h.GetUser)
 		userGroup.PATCH("/:user_id", rbac.RequirePermission("user:update"), h.UpdateUser)
@@ -45,11 +49,13 @@ func (h *UserHandler) RegisterRoutes(e *gin.RouterGroup) {
 // @Security BearerAuth
 // @param user_id path int true "使用者 ID"
 // @Success 200 {objects} user.User
+// @Failure 401 {objects} middleware.ErrorResponse
+// @Failure 404 {objects} middleware.ErrorResponse
 // @Failure 500 {objects} middleware.ErrorResponse
 // @Router /api/v1/user/{user_id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id := c.GetUint("user_id")
-	singleUser, err := h.userService.GetUserByID(id)
+	singleUser, err := h.userService.GetUserByID(c.Request.Context(), id)
 	if err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
 		return
@@ -67,17 +73,26 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 // @produce application/json
 // @Security BearerAuth
 // @param user_id path int true "使用者 ID"
+// @param request body user.UpdateRequest true "可修改的欄位"
 // @Success 200 {objects} nil
+// @Failure 400 {objects} middleware.ErrorResponse
+// @Failure 401 {objects} middleware.ErrorResponse
+// @Failure 403 {objects} middleware.ErrorResponse
 // @Failure 500 {objects} middleware.ErrorResponse
 // @Router /api/v1/user/{user_id} [patch]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	var singleUser user.User
-	if err := c.ShouldBindJSON(&singleUser); err != nil {
+	id, ok := selfID(c)
+	if !ok {
+		return
+	}
+
+	var updateRequest user.UpdateRequest
+	if err := c.ShouldBindJSON(&updateRequest); err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	if err := h.userService.UpdateUser(&singleUser); err != nil {
+	if err := h.userService.UpdateUser(c.Request.Context(), id, &updateRequest); err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
 		return
 	}
@@ -95,27 +110,36 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Security BearerAuth
 // @param user_id path int true "使用者 ID"
 // @Success 200 {objects} nil
+// @Failure 400 {objects} middleware.ErrorResponse
+// @Failure 401 {objects} middleware.ErrorResponse
+// @Failure 403 {objects} middleware.ErrorResponse
 // @Failure 500 {objects} middleware.ErrorResponse
 // @Router /api/v1/user/{user_id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	id := c.GetUint("user_id")
-	pathIDString := c.Param("user_id")
-	pathID, err := strconv.ParseUint(pathIDString, 10, 64)
-	if err != nil {
-		_ = c.Error(authlib.ErrInvalidID).SetType(gin.ErrorTypePublic)
-		return
-	}
-
-	// 確保提交人是自己
-	if pathID != uint64(id) {
-		_ = c.Error(authlib.ErrForbidden).SetType(gin.ErrorTypePublic)
+	id, ok := selfID(c)
+	if !ok {
 		return
 	}
 
-	if err := h.userService.DeleteUser(id); err != nil {
+	if err := h.userService.DeleteUser(c.Request.Context(), id); err != nil {
 		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
 		return
 	}
 
 	c.JSON(http.StatusOK, nil)
 }
+
+// selfID 回傳路徑中的使用者 ID，並確保提交人是自己；失敗時已寫入錯誤
+func selfID(c *gin.Context) (uint, bool) {
+	id := c.GetUint("user_id")
+	pathID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		_ = c.Error(authlib.ErrInvalidID).SetType(gin.ErrorTypePublic)
+		return 0, false
+	}
+	if pathID != uint64(id) {
+		_ = c.Error(authlib.ErrForbidden).SetType(gin.ErrorTypePublic)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,81 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+)
+
+// guestExpirationInterval 檢查過期訪客的間隔
+const guestExpirationInterval = time.Minute
+
+// GuestExpirationJob 定期停用過期的訪客帳號並撤銷其 token
+type GuestExpirationJob struct {
+	authService auth.AuthService
+	interval    time.Duration
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// NewGuestExpirationJob 創建新的訪客過期任務
+func NewGuestExpirationJob(authService auth.AuthService) *GuestExpirationJob {
+	return &GuestExpirationJob{
+		authService: authService,
+		interval:    guestExpirationInterval,
+	}
+}
+
+// Start 啟動背景任務
+func (j *GuestExpirationJob) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止背景任務，取消進行中的批次並等待其結束
+func (j *GuestExpirationJob) Stop(ctx context.Context) error {
+	if j.cancel == nil {
+		return nil
+	}
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 執行一次過期檢查，ctx 取消時中止
+func (j *GuestExpirationJob) run(ctx context.Context) {
+	count, err := j.authService.DeactivateExpiredGuests(ctx, time.Now())
+	if err != nil {
+		// 停止時中止的批次不視為錯誤，下次啟動會再處理
+		if ctx.Err() == nil {
+			logger.Error("failed to deactivate expired guests", logger.Err(err))
+		}
+		return
+	}
+	if count > 0 {
+		logger.Info("deactivated expired guests", logger.Int("count", count))
+	}
+}
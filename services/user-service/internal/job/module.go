@@ -0,0 +1,16 @@
+package job
+
+import (
+	"go.uber.org/fx"
+)
+
+// Module 依賴注入統一管理
+var Module = fx.Module("job",
+	fx.Provide(NewGuestExpirationJob),
+	fx.Invoke(func(lc fx.Lifecycle, guestExpirationJob *GuestExpirationJob) {
+		lc.Append(fx.Hook{
+			OnStart: guestExpirationJob.Start,
+			OnStop:  guestExpirationJob.Stop,
+		})
+	}),
+)
@@ -3,6 +3,7 @@ package internal
 import (
 	handler "github.com/POABOB/slack-clone-back-end/services/user-service/internal/handler/http"
 	repository "github.com/POABOB/slack-clone-back-end/services/user-service/internal/repository/postgresql"
+	redisrepo "github.com/POABOB/slack-clone-back-end/services/user-service/internal/repository/redis"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/service"
 	"go.uber.org/fx"
 )
@@ -13,7 +14,26 @@ var Module = fx.Module("internal",
 		service.NewUserService,
 		handler.NewUserHandler,
 
+		redisrepo.NewRefreshTokenRepository,
 		service.NewAuthService,
 		handler.NewAuthHandler,
+
+		repository.NewGroupRepository,
+		service.NewSCIMService,
+		handler.NewSCIMHandler,
+
+		redisrepo.NewDeviceRepository,
+		service.NewDeviceService,
+		handler.NewOAuthHandler,
+		handler.NewDeviceHandler,
+
+		repository.NewClientRepository,
+		redisrepo.NewAuthorizationCodeRepository,
+		service.NewAuthorizationService,
+		handler.NewOAuthAppHandler,
+
+		handler.NewInternalHandler,
+
+		handler.NewFeatureFlagHandler,
 	),
 )
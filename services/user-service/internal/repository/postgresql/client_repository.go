@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"gorm.io/gorm"
+)
+
+type clientRepository struct {
+	db *gorm.DB
+}
+
+// NewClientRepository 創建新的第三方應用程式資料存取實例
+func NewClientRepository(db *gorm.DB) oauth.ClientRepository {
+	return &clientRepository{db: db}
+}
+
+func (r *clientRepository) Create(ctx context.Context, client *oauth.Client) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *clientRepository) FindByClientID(ctx context.Context, clientID string) (*oauth.Client, error) {
+	var client oauth.Client
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *clientRepository) FindByOwnerID(ctx context.Context, ownerID uint) ([]*oauth.Client, error) {
+	var clients []*oauth.Client
+	err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("id").Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (r *clientRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&oauth.Client{}, id).Error
+}
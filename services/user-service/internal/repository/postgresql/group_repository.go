@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/group"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupRepository 創建新的群組資料存取實例
+func NewGroupRepository(db *gorm.DB) group.GroupRepository {
+	return &groupRepository{db: db}
+}
+
+// groupColumns 可供查詢的群組欄位
+var groupColumns = map[string]string{
+	"id":           "id",
+	"display_name": "display_name",
+	"external_id":  "external_id",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
+
+func (r *groupRepository) Create(ctx context.Context, singleGroup *group.Group, memberIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Create(singleGroup).Error; err != nil {
+			return err
+		}
+		return replaceMembers(tx, singleGroup.ID, memberIDs)
+	})
+}
+
+func (r *groupRepository) FindByID(ctx context.Context, id uint) (*group.Group, error) {
+	var singleGroup group.Group
+	err := r.db.WithContext(ctx).Preload("Members", "is_deleted = ?", false).First(&singleGroup, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &singleGroup, nil
+}
+
+func (r *groupRepository) FindByDisplayName(ctx context.Context, displayName string) (*group.Group, error) {
+	var singleGroup group.Group
+	err := r.db.WithContext(ctx).Where("LOWER(display_name) = LOWER(?)", displayName).First(&singleGroup).Error
+	if err != nil {
+		return nil, err
+	}
+	return &singleGroup, nil
+}
+
+func (r *groupRepository) FindByMemberID(ctx context.Context, userID uint) ([]*group.Group, error) {
+	groups := make([]*group.Group, 0)
+	err := r.db.WithContext(ctx).
+		Joins("JOIN group_members ON group_members.group_id = groups.id").
+		Where("group_members.user_id = ?", userID).
+		Order("groups.id").
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *groupRepository) List(ctx context.Context, query *user.ListQuery) ([]*group.Group, int64, error) {
+	db, err := applyConditions(r.db.WithContext(ctx).Model(&group.Group{}), query.Conditions, groupColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	groups := make([]*group.Group, 0)
+	err = applyPagination(db, query.Offset, query.Limit).
+		Preload("Members", "is_deleted = ?", false).
+		Order("id").
+		Find(&groups).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+func (r *groupRepository) Update(ctx context.Context, singleGroup *group.Group, memberIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Save(singleGroup).Error; err != nil {
+			return err
+		}
+		return replaceMembers(tx, singleGroup.ID, memberIDs)
+	})
+}
+
+func (r *groupRepository) Patch(ctx context.Context, singleGroup *group.Group, members *group.MemberPatch) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Save(singleGroup).Error; err != nil {
+			return err
+		}
+		if members.Replace {
+			return replaceMembers(tx, singleGroup.ID, members.Add)
+		}
+		if len(members.Remove) > 0 {
+			err := tx.Exec("DELETE FROM group_members WHERE group_id = ? AND user_id IN ?", singleGroup.ID, members.Remove).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(members.Add) == 0 {
+			return nil
+		}
+		rows := make([]map[string]interface{}, 0, len(members.Add))
+		for _, userID := range members.Add {
+			rows = append(rows, map[string]interface{}{"group_id": singleGroup.ID, "user_id": userID})
+		}
+		// 並行的 PATCH 可能已加入相同成員，重複時略過
+		return tx.Table("group_members").Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error
+	})
+}
+
+func (r *groupRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&group.Group{ID: id}).Association("Members").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&group.Group{}, id).Error
+	})
+}
+
+// replaceMembers 以 userIDs 取代群組的全部成員
+func replaceMembers(tx *gorm.DB, groupID uint, userIDs []uint) error {
+	association := tx.Model(&group.Group{ID: groupID}).Omit("Members.*").Association("Members")
+	if len(userIDs) == 0 {
+		return association.Clear()
+	}
+	return association.Replace(toUsers(userIDs))
+}
+
+// toUsers 將使用者 ID 轉為關聯所需的實體
+func toUsers(userIDs []uint) []*user.User {
+	users := make([]*user.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		users = append(users, &user.User{ID: id})
+	}
+	return users
+}
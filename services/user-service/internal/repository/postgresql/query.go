@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	"gorm.io/gorm"
+)
+
+// applyConditions 將查詢條件轉換為 SQL，columns 為欄位名稱到資料表欄位的白名單
+func applyConditions(db *gorm.DB, conditions []user.Condition, columns map[string]string) (*gorm.DB, error) {
+	for _, cond := range conditions {
+		column, ok := columns[cond.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported field: %s", cond.Field)
+		}
+
+		// 字串比對不區分大小寫
+		str, isString := cond.Value.(string)
+		switch cond.Operator {
+		case user.OperatorEqual:
+			if isString {
+				db = db.Where(fmt.Sprintf("LOWER(%s) = LOWER(?)", column), str)
+			} else {
+				db = db.Where(fmt.Sprintf("%s = ?", column), cond.Value)
+			}
+		case user.OperatorNotEqual:
+			if isString {
+				db = db.Where(fmt.Sprintf("LOWER(%s) <> LOWER(?)", column), str)
+			} else {
+				db = db.Where(fmt.Sprintf("%s <> ?", column), cond.Value)
+			}
+		case user.OperatorContains, user.OperatorStartsWith, user.OperatorEndsWith:
+			if !isString {
+				return nil, fmt.Errorf("operator %s requires a string value", cond.Operator)
+			}
+			db = db.Where(fmt.Sprintf("%s ILIKE ?", column), likePattern(cond.Operator, str))
+		case user.OperatorGreaterThan:
+			db = db.Where(fmt.Sprintf("%s > ?", column), cond.Value)
+		case user.OperatorGreaterOrEqual:
+			db = db.Where(fmt.Sprintf("%s >= ?", column), cond.Value)
+		case user.OperatorLessThan:
+			db = db.Where(fmt.Sprintf("%s < ?", column), cond.Value)
+		case user.OperatorLessOrEqual:
+			db = db.Where(fmt.Sprintf("%s <= ?", column), cond.Value)
+		case user.OperatorPresent:
+			db = db.Where(fmt.Sprintf("%s IS NOT NULL", column))
+		default:
+			return nil, fmt.Errorf("unsupported operator: %s", cond.Operator)
+		}
+	}
+	return db, nil
+}
+
+// likePattern 依運算子產生 LIKE 樣式，並跳脫萬用字元
+func likePattern(operator, value string) string {
+	escaped := ""
+	for _, r := range value {
+		if r == '%' || r == '_' || r == '\\' {
+			escaped += "\\"
+		}
+		escaped += string(r)
+	}
+
+	switch operator {
+	case user.OperatorStartsWith:
+		return escaped + "%"
+	case user.OperatorEndsWith:
+		return "%" + escaped
+	default:
+		return "%" + escaped + "%"
+	}
+}
+
+// applyPagination 套用分頁，Limit 小於等於 0 時不限制筆數
+func applyPagination(db *gorm.DB, offset, limit int) *gorm.DB {
+	if offset > 0 {
+		db = db.Offset(offset)
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	return db
+}
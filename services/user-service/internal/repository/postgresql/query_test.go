@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+// newDryRunDB creates a gorm DB that builds SQL without executing it.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+	return db
+}
+
+func TestApplyConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition user.Condition
+		sql       string
+		vars      []interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "String equality is case-insensitive",
+			condition: user.Condition{Field: "username", Operator: user.OperatorEqual, Value: "BJensen"},
+			sql:       "LOWER(username) = LOWER($1)",
+			vars:      []interface{}{"BJensen"},
+		},
+		{
+			name:      "Non-string equality",
+			condition: user.Condition{Field: "is_disabled", Operator: user.OperatorEqual, Value: true},
+			sql:       "is_disabled = $1",
+			vars:      []interface{}{true},
+		},
+		{
+			name:      "Not equal",
+			condition: user.Condition{Field: "email", Operator: user.OperatorNotEqual, Value: "a@example.com"},
+			sql:       "LOWER(email) <> LOWER($1)",
+			vars:      []interface{}{"a@example.com"},
+		},
+		{
+			name:      "Contains escapes wildcards",
+			condition: user.Condition{Field: "username", Operator: user.OperatorContains, Value: "50%_off"},
+			sql:       "username ILIKE $1",
+			vars:      []interface{}{`%50\%\_off%`},
+		},
+		{
+			name:      "Starts with",
+			condition: user.Condition{Field: "email", Operator: user.OperatorStartsWith, Value: "b"},
+			sql:       "email ILIKE $1",
+			vars:      []interface{}{"b%"},
+		},
+		{
+			name:      "Ends with",
+			condition: user.Condition{Field: "email", Operator: user.OperatorEndsWith, Value: "@example.com"},
+			sql:       "email ILIKE $1",
+			vars:      []interface{}{"%@example.com"},
+		},
+		{
+			name:      "Greater than",
+			condition: user.Condition{Field: "id", Operator: user.OperatorGreaterThan, Value: 3},
+			sql:       "id > $1",
+			vars:      []interface{}{3},
+		},
+		{
+			name:      "Less or equal",
+			condition: user.Condition{Field: "created_at", Operator: user.OperatorLessOrEqual, Value: "2025-01-01"},
+			sql:       "created_at <= $1",
+			vars:      []interface{}{"2025-01-01"},
+		},
+		{
+			name:      "Present",
+			condition: user.Condition{Field: "external_id", Operator: user.OperatorPresent},
+			sql:       "external_id IS NOT NULL",
+		},
+		{
+			name:      "Field outside the whitelist",
+			condition: user.Condition{Field: "password", Operator: user.OperatorEqual, Value: "x"},
+			wantErr:   true,
+		},
+		{
+			name:      "Contains requires a string",
+			condition: user.Condition{Field: "id", Operator: user.OperatorContains, Value: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "Unknown operator",
+			condition: user.Condition{Field: "id", Operator: "in", Value: 1},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := applyConditions(newDryRunDB(t).Model(&user.User{}), []user.Condition{tt.condition}, userColumns)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			statement := db.Find(&[]*user.User{}).Statement
+			assert.Equal(t, `SELECT * FROM "users" WHERE `+tt.sql, statement.SQL.String())
+			if tt.vars != nil {
+				assert.Equal(t, tt.vars, statement.Vars)
+			} else {
+				assert.Empty(t, statement.Vars)
+			}
+		})
+	}
+}
+
+func TestApplyPagination(t *testing.T) {
+	statement := applyPagination(newDryRunDB(t).Model(&user.User{}), 20, 10).Find(&[]*user.User{}).Statement
+	assert.Equal(t, `SELECT * FROM "users" LIMIT $1 OFFSET $2`, statement.SQL.String())
+
+	statement = applyPagination(newDryRunDB(t).Model(&user.User{}), 0, 0).Find(&[]*user.User{}).Statement
+	assert.Equal(t, `SELECT * FROM "users"`, statement.SQL.String())
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
 	"gorm.io/gorm"
 )
@@ -14,32 +16,97 @@ func NewUserRepository(db *gorm.DB) user.UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *user.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *user.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) FindByID(id uint) (*user.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
 	var singleUser user.User
-	err := r.db.First(&singleUser, id).Error
+	err := r.db.WithContext(ctx).First(&singleUser, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &singleUser, nil
 }
 
-func (r *userRepository) FindByEmail(email string) (*user.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	var singleUser user.User
-	err := r.db.Where("email = ?", email).First(&singleUser).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&singleUser).Error
 	if err != nil {
 		return nil, err
 	}
 	return &singleUser, nil
 }
 
-func (r *userRepository) Update(user *user.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	var singleUser user.User
+	err := r.db.WithContext(ctx).Where("LOWER(username) = LOWER(?) AND is_deleted = ?", username, false).First(&singleUser).Error
+	if err != nil {
+		return nil, err
+	}
+	return &singleUser, nil
+}
+
+// userColumns 可供查詢的使用者欄位
+var userColumns = map[string]string{
+	"id":           "id",
+	"username":     "username",
+	"email":        "email",
+	"external_id":  "external_id",
+	"account_type": "account_type",
+	"expires_at":   "expires_at",
+	"is_disabled":  "is_disabled",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
+
+func (r *userRepository) List(ctx context.Context, query *user.ListQuery) ([]*user.User, int64, error) {
+	db, err := applyConditions(r.db.WithContext(ctx).Model(&user.User{}).Where("is_deleted = ?", false), query.Conditions, userColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]*user.User, 0)
+	if err := applyPagination(db, query.Offset, query.Limit).Order("id").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *user.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+// UpdateProfile 只更新請求中提供的欄位，帳號類型、停用狀態等由管理流程維護的欄位不受影響
+func (r *userRepository) UpdateProfile(ctx context.Context, id uint, req *user.UpdateRequest) error {
+	columns := make(map[string]interface{})
+	if req.Username != nil {
+		columns["username"] = *req.Username
+	}
+	if req.Password != nil {
+		columns["password"] = *req.Password
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND is_deleted = ?", id, false).
+		Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Model(&user.User{}).Where("id = ?", id).Update("is_deleted", true).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&user.User{}).Where("id = ?", id).Update("is_deleted", true).Error
 }
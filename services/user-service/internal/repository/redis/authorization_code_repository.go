@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/go-redis/redis/v8"
+)
+
+const authorizationCodeKeyPrefix = "oauth:code:"
+
+type authorizationCodeRepository struct {
+	client *redis.Client
+}
+
+// NewAuthorizationCodeRepository 創建新的授權碼資料存取實例
+func NewAuthorizationCodeRepository(client *redis.Client) oauth.AuthorizationCodeRepository {
+	return &authorizationCodeRepository{client: client}
+}
+
+func (r *authorizationCodeRepository) Save(ctx context.Context, code *oauth.AuthorizationCode) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, authorizationCodeKeyPrefix+code.Code, data, time.Until(code.ExpiresAt)).Err()
+}
+
+func (r *authorizationCodeRepository) Consume(ctx context.Context, code string) (*oauth.AuthorizationCode, error) {
+	data, err := r.client.GetDel(ctx, authorizationCodeKeyPrefix+code).Bytes()
+	if err == redis.Nil {
+		return nil, oauth.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var authorizationCode oauth.AuthorizationCode
+	if err := json.Unmarshal(data, &authorizationCode); err != nil {
+		return nil, err
+	}
+	return &authorizationCode, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	deviceCodeKeyPrefix = "oauth:device:code:"
+	userCodeKeyPrefix   = "oauth:device:user:"
+	devicePollKeyPrefix = "oauth:device:poll:"
+)
+
+// 輪詢紀錄的 hash 欄位
+const (
+	devicePollLastField     = "last_polled_at"
+	devicePollSlowDownField = "slow_down"
+)
+
+type deviceRepository struct {
+	client *redis.Client
+}
+
+// NewDeviceRepository 創建新的裝置授權資料存取實例
+func NewDeviceRepository(client *redis.Client) oauth.DeviceRepository {
+	return &deviceRepository{client: client}
+}
+
+func (r *deviceRepository) Save(ctx context.Context, authorization *oauth.DeviceAuthorization) error {
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(authorization.ExpiresAt)
+	// 以 SetNX 避免 user_code 碰撞覆蓋其他裝置的授權
+	ok, err := r.client.SetNX(ctx, userCodeKeyPrefix+authorization.UserCode, authorization.DeviceCode, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return oauth.ErrConflict
+	}
+	return r.client.Set(ctx, deviceCodeKeyPrefix+authorization.DeviceCode, data, ttl).Err()
+}
+
+func (r *deviceRepository) FindByDeviceCode(ctx context.Context, deviceCode string) (*oauth.DeviceAuthorization, error) {
+	data, err := r.client.Get(ctx, deviceCodeKeyPrefix+deviceCode).Bytes()
+	if err == redis.Nil {
+		return nil, oauth.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var authorization oauth.DeviceAuthorization
+	if err := json.Unmarshal(data, &authorization); err != nil {
+		return nil, err
+	}
+	return &authorization, nil
+}
+
+func (r *deviceRepository) FindByUserCode(ctx context.Context, userCode string) (*oauth.DeviceAuthorization, error) {
+	deviceCode, err := r.client.Get(ctx, userCodeKeyPrefix+userCode).Result()
+	if err == redis.Nil {
+		return nil, oauth.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByDeviceCode(ctx, deviceCode)
+}
+
+func (r *deviceRepository) Update(ctx context.Context, authorization *oauth.DeviceAuthorization) error {
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		return err
+	}
+
+	// 保留原本的 TTL，並確保不會重建已過期的紀錄
+	ok, err := r.client.SetXX(ctx, deviceCodeKeyPrefix+authorization.DeviceCode, data, redis.KeepTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return oauth.ErrNotFound
+	}
+	return nil
+}
+
+func (r *deviceRepository) RecordPoll(ctx context.Context, authorization *oauth.DeviceAuthorization,
+	polledAt time.Time) (*oauth.DevicePoll, error) {
+	key := devicePollKeyPrefix + authorization.DeviceCode
+
+	// MULTI 內的 HGETALL 取得寫入前的紀錄，同時輪詢的請求只有一個能看到舊的輪詢時間
+	var previous *redis.StringStringMapCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		previous = pipe.HGetAll(ctx, key)
+		pipe.HSet(ctx, key, devicePollLastField, polledAt.UnixNano())
+		pipe.ExpireAt(ctx, key, authorization.ExpiresAt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	poll := &oauth.DevicePoll{}
+	fields := previous.Val()
+	if value, ok := fields[devicePollLastField]; ok {
+		nanos, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		poll.LastPolledAt = time.Unix(0, nanos)
+	}
+	if value, ok := fields[devicePollSlowDownField]; ok {
+		if poll.SlowDown, err = strconv.Atoi(value); err != nil {
+			return nil, err
+		}
+	}
+	return poll, nil
+}
+
+func (r *deviceRepository) SlowDown(ctx context.Context, authorization *oauth.DeviceAuthorization, seconds int) error {
+	return r.client.HIncrBy(ctx, devicePollKeyPrefix+authorization.DeviceCode, devicePollSlowDownField, int64(seconds)).Err()
+}
+
+func (r *deviceRepository) Consume(ctx context.Context, authorization *oauth.DeviceAuthorization) (*oauth.DeviceAuthorization, error) {
+	// 以 GETDEL 取走紀錄，同時兌換的請求只有一個能取得資料
+	var claimed *redis.StringCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		claimed = pipe.GetDel(ctx, deviceCodeKeyPrefix+authorization.DeviceCode)
+		pipe.Del(ctx, userCodeKeyPrefix+authorization.UserCode, devicePollKeyPrefix+authorization.DeviceCode)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	data, err := claimed.Bytes()
+	if err == redis.Nil {
+		return nil, oauth.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var consumed oauth.DeviceAuthorization
+	if err := json.Unmarshal(data, &consumed); err != nil {
+		return nil, err
+	}
+	return &consumed, nil
+}
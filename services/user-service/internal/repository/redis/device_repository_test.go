@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+)
+
+// newTestDeviceAuthorization saves a pending authorization and returns it with the repository.
+func newTestDeviceAuthorization(t *testing.T) (oauth.DeviceRepository, *oauth.DeviceAuthorization) {
+	mr := miniredis.RunT(t)
+	repo := NewDeviceRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	authorization := &oauth.DeviceAuthorization{
+		DeviceCode: "device-code",
+		UserCode:   "BCDFGHJK",
+		ClientID:   "slack-cli",
+		Status:     oauth.DeviceStatusPending,
+		Interval:   5,
+		ExpiresAt:  time.Now().Add(time.Minute),
+	}
+	require.NoError(t, repo.Save(context.Background(), authorization))
+	return repo, authorization
+}
+
+func TestDeviceRepositoryPoll(t *testing.T) {
+	ctx := context.Background()
+	repo, authorization := newTestDeviceAuthorization(t)
+
+	first := time.Now()
+	poll, err := repo.RecordPoll(ctx, authorization, first)
+	require.NoError(t, err)
+	assert.True(t, poll.LastPolledAt.IsZero())
+	assert.Zero(t, poll.SlowDown)
+
+	require.NoError(t, repo.SlowDown(ctx, authorization, 5))
+	poll, err = repo.RecordPoll(ctx, authorization, first.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, first.Equal(poll.LastPolledAt))
+	assert.Equal(t, 5, poll.SlowDown)
+
+	// 輪詢紀錄不會寫入授權紀錄
+	authorization.Status = oauth.DeviceStatusApproved
+	require.NoError(t, repo.Update(ctx, authorization))
+	_, err = repo.RecordPoll(ctx, authorization, first.Add(2*time.Second))
+	require.NoError(t, err)
+	stored, err := repo.FindByDeviceCode(ctx, authorization.DeviceCode)
+	require.NoError(t, err)
+	assert.Equal(t, oauth.DeviceStatusApproved, stored.Status)
+}
+
+func TestDeviceRepositoryConsume(t *testing.T) {
+	ctx := context.Background()
+	repo, authorization := newTestDeviceAuthorization(t)
+
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Consume(ctx, authorization)
+			if err == nil {
+				consumed.Add(1)
+				return
+			}
+			assert.True(t, errors.Is(err, oauth.ErrNotFound))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), consumed.Load())
+
+	_, err := repo.FindByDeviceCode(ctx, authorization.DeviceCode)
+	assert.True(t, errors.Is(err, oauth.ErrNotFound))
+	_, err = repo.FindByUserCode(ctx, authorization.UserCode)
+	assert.True(t, errors.Is(err, oauth.ErrNotFound))
+}
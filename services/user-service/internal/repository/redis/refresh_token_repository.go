@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/go-redis/redis/v8"
+)
+
+const refreshTokenKeyPrefix = "oauth:refresh:"
+
+type refreshTokenRepository struct {
+	client *redis.Client
+}
+
+// NewRefreshTokenRepository 創建新的 refresh token 資料存取實例
+func NewRefreshTokenRepository(client *redis.Client) oauth.RefreshTokenRepository {
+	return &refreshTokenRepository{client: client}
+}
+
+func (r *refreshTokenRepository) Save(ctx context.Context, token string, userID uint, ttlSeconds int) error {
+	ttl := time.Duration(ttlSeconds) * time.Second
+	return r.client.Set(ctx, refreshTokenKeyPrefix+token, userID, ttl).Err()
+}
+
+func (r *refreshTokenRepository) Consume(ctx context.Context, token string) (uint, error) {
+	value, err := r.client.GetDel(ctx, refreshTokenKeyPrefix+token).Result()
+	if err == redis.Nil {
+		return 0, oauth.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(userID), nil
+}
+
+func (r *refreshTokenRepository) Delete(ctx context.Context, token string) error {
+	return r.client.Del(ctx, refreshTokenKeyPrefix+token).Err()
+}
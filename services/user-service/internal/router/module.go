@@ -9,6 +9,10 @@ import (
 var Module = fx.Module("router",
 	fx.Provide(
 		configlib.NewGinEngine,
+		configlib.NewHealthRegistry,
+		configlib.NewRateLimitPolicies,
+		configlib.NewIdempotency,
+		configlib.NewVersioning,
 		NewRouter,
 	),
 )
@@ -1,48 +1,119 @@
 package router
 
 import (
+	nethttp "net/http"
+	"strings"
+
 	"github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/versioning"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/handler/http"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
 )
 
 // Router 路由管理器
 type Router struct {
-	engine *gin.Engine
-	config *config.RouterConfig
+	engine   *gin.Engine
+	config   *config.RouterConfig
+	versions *versioning.Registry
 
 	// 其他處理器...
 	userHandler *handler.UserHandler
 	authHandler *handler.AuthHandler
+	scimHandler *handler.SCIMHandler
+
+	oauthHandler    *handler.OAuthHandler
+	deviceHandler   *handler.DeviceHandler
+	oauthAppHandler *handler.OAuthAppHandler
+	internalHandler *handler.InternalHandler
+
+	featureFlagHandler *handler.FeatureFlagHandler
 }
 
 // NewRouter 創建新的路由管理器
-func NewRouter(engine *gin.Engine, config *config.RouterConfig, userHandler *handler.UserHandler,
-	authHandler *handler.AuthHandler) *Router {
+func NewRouter(engine *gin.Engine, config *config.RouterConfig, versions *versioning.Registry, userHandler *handler.UserHandler,
+	authHandler *handler.AuthHandler, scimHandler *handler.SCIMHandler, oauthHandler *handler.OAuthHandler,
+	deviceHandler *handler.DeviceHandler, oauthAppHandler *handler.OAuthAppHandler,
+	internalHandler *handler.InternalHandler, featureFlagHandler *handler.FeatureFlagHandler) *Router {
 	return &Router{
-		engine:      engine,
-		config:      config,
-		userHandler: userHandler,
-		authHandler: authHandler,
+		engine:          engine,
+		config:          config,
+		versions:        versions,
+		userHandler:     userHandler,
+		authHandler:     authHandler,
+		scimHandler:     scimHandler,
+		oauthHandler:    oauthHandler,
+		deviceHandler:   deviceHandler,
+		oauthAppHandler: oauthAppHandler,
+		internalHandler: internalHandler,
+
+		featureFlagHandler: featureFlagHandler,
 	}
 }
 
 // Setup 設置所有路由
 func (r *Router) Setup() {
-	// API 版本分組
-	v1 := r.engine.Group("/api/v1")
-	{
-		// 設置各個模組的路由
-		r.authHandler.RegisterRoutes(v1)
-		r.userHandler.RegisterRoutes(v1)
-	}
-	url := ginSwagger.URL("/swagger/doc.json") // The url pointing to API definition
-	r.engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
+	// API 版本分組，各模組的路由同時提供於所有版本，只存在於部分版本的路由依 version 判斷
+	r.versions.Register(r.engine, func(version string, group *gin.RouterGroup) {
+		r.authHandler.RegisterRoutes(group)
+		r.userHandler.RegisterRoutes(group)
+		r.deviceHandler.RegisterRoutes(group)
+		r.oauthAppHandler.RegisterRoutes(group)
+		r.featureFlagHandler.RegisterRoutes(group)
+	})
+
+	// SCIM 2.0 佈建 API 路徑由規範定義，不隨 API 版本變動
+	r.scimHandler.RegisterRoutes(r.engine.Group("/scim/v2"))
+	// OAuth 2.0 端點供第三方客戶端使用，同樣不隨 API 版本變動
+	r.oauthHandler.RegisterRoutes(r.engine.Group("/oauth"))
+	// 內部 API 僅供工作區內其他服務以 service token 呼叫，不對外公開
+	r.internalHandler.RegisterRoutes(r.engine.Group("/internal"))
+	r.engine.GET("/swagger/*any", r.swagger())
 }
 
 // Engine returns the underlying gin.Engine instance used by the Router.
 func (r *Router) Engine() *gin.Engine {
 	return r.engine
 }
+
+// Handler 提供給 HTTP 服務的 handler，未帶版本的 API 路徑依 Accept 標頭導向對應版本
+func (r *Router) Handler() nethttp.Handler {
+	return r.versions.Handler(r.engine)
+}
+
+// swagger 提供 Swagger UI，/swagger/index.html 為完整文件，/swagger/<版本>/index.html 只包含該版本的 API
+func (r *Router) swagger() gin.HandlerFunc {
+	full := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/swagger/doc.json"))
+	uis := make(map[string]gin.HandlerFunc)
+	for _, version := range r.versions.Versions() {
+		uis[version] = ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/swagger/"+version+"/doc.json"))
+	}
+
+	return func(c *gin.Context) {
+		version, file, _ := strings.Cut(strings.TrimPrefix(c.Param("any"), "/"), "/")
+		ui, ok := uis[version]
+		if !ok {
+			full(c)
+			return
+		}
+		if file != "doc.json" {
+			ui(c)
+			return
+		}
+
+		doc, err := swag.ReadDoc()
+		if err == nil {
+			var versionDoc []byte
+			if versionDoc, err = r.versions.SwaggerDoc([]byte(doc), r.engine.Routes(), version); err == nil {
+				c.Data(nethttp.StatusOK, "application/json; charset=utf-8", versionDoc)
+				return
+			}
+		}
+		logger.FromContext(c.Request.Context()).Error("failed to build swagger document",
+			logger.String("version", version), logger.Err(err))
+		c.AbortWithStatus(nethttp.StatusInternalServerError)
+	}
+}
@@ -1,75 +1,260 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"time"
 
 	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
 	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
 	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/revocation"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
 
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
 )
 
+// defaultRefreshTokenTTL 未配置時 refresh token 的有效期（30 天）
+const defaultRefreshTokenTTL = 30 * 24 * 60 * 60
+
 type authService struct {
-	userRepo   user.UserRepository
-	jwtManager jwt.TokenManager
+	userRepo         user.UserRepository
+	refreshTokenRepo oauth.RefreshTokenRepository
+	jwtManager       jwt.TokenManager
+	revocationStore  revocation.Store
+	refreshTokenTTL  int
 }
 
 // NewAuthService 創建新的驗證服務實例
-func NewAuthService(userRepo user.UserRepository, jwtManager jwt.TokenManager) auth.AuthService {
+func NewAuthService(userRepo user.UserRepository, refreshTokenRepo oauth.RefreshTokenRepository,
+	jwtManager jwt.TokenManager, revocationStore revocation.Store, cfg *configlib.OAuthConfig) auth.AuthService {
+	refreshTokenTTL := cfg.RefreshTokenTTL
+	if refreshTokenTTL <= 0 {
+		refreshTokenTTL = defaultRefreshTokenTTL
+	}
 	return &authService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		jwtManager:       jwtManager,
+		revocationStore:  revocationStore,
+		refreshTokenTTL:  refreshTokenTTL,
 	}
 }
 
 // Register 註冊新使用者
-func (s *authService) Register(user *user.User) error {
+func (s *authService) Register(ctx context.Context, newUser *user.User) error {
 	// 檢查 Email 是否存在
-	existingUser, err := s.userRepo.FindByEmail(user.Email)
+	existingUser, err := s.userRepo.FindByEmail(ctx, newUser.Email)
 	if err == nil && existingUser != nil {
-		return errors.New("email already exists")
+		return user.ErrEmailExists
 	}
 
 	// 加密密碼
-	hashedPassword, err := authlib.HashPassword(user.Password)
+	hashedPassword, err := authlib.HashPassword(newUser.Password)
 	if err != nil {
 		return err
 	}
-	user.Password = hashedPassword
-	return s.userRepo.Create(user)
+	newUser.Password = hashedPassword
+	return s.userRepo.Create(ctx, newUser)
+}
+
+// RegisterGuest 註冊訪客，單一頻道訪客只能指定一個頻道，且到期時間必須在未來
+func (s *authService) RegisterGuest(ctx context.Context, guest *user.User) error {
+	switch guest.AccountType {
+	case user.AccountTypeSingleChannelGuest:
+		if len(guest.GuestChannels) != 1 {
+			return auth.ErrInvalidGuest.WithMessage("single-channel guest requires exactly one channel")
+		}
+	case user.AccountTypeMultiChannelGuest:
+		if len(guest.GuestChannels) == 0 {
+			return auth.ErrInvalidGuest.WithMessage("multi-channel guest requires at least one channel")
+		}
+	default:
+		return auth.ErrInvalidGuest.WithMessage(fmt.Sprintf("unknown account type %q", guest.AccountType))
+	}
+	if guest.ExpiresAt == nil || guest.IsExpired(time.Now()) {
+		return auth.ErrInvalidGuest.WithMessage("expiration must be in the future")
+	}
+
+	// 訪客不繼承任何權限
+	guest.Permissions = []string{}
+	return s.Register(ctx, guest)
 }
 
-// Login 使用者登入
-func (s *authService) Login(email, password string) (string, error) {
+// Login 使用者登入，並依結果記錄 auth_login_attempts_total
+func (s *authService) Login(ctx context.Context, email, password string) (string, error) {
+	token, err := s.login(ctx, email, password)
+	metrics.RecordLogin(loginResult(err))
+	return token, err
+}
+
+// login 驗證帳號密碼並產生 token
+func (s *authService) login(ctx context.Context, email, password string) (string, error) {
 	// 查找使用者
-	singleUser, err := s.userRepo.FindByEmail(email)
-	if err != nil {
-		return "", errors.New("user not found")
+	singleUser, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || singleUser.IsDeleted {
+		return "", auth.ErrInvalidCredentials
 	}
 
-	// 驗證密碼
+	// 先驗證密碼，帳號狀態只透露給知道密碼的人，避免以此探測帳號是否存在
 	err = authlib.CheckPassword(password, singleUser.Password)
 	if err != nil {
-		return "", errors.New("invalid password")
+		return "", auth.ErrInvalidCredentials
+	}
+	if singleUser.IsDisabled {
+		return "", auth.ErrUserDisabled
+	}
+	if singleUser.IsExpired(time.Now()) {
+		return "", auth.ErrAccountExpired
 	}
 
 	tokenString, err := s.GenerateToken(singleUser)
 	return tokenString, err
 }
 
-// GenerateToken 產生 JWT Token
+// loginResult 登入錯誤對應的指標標籤
+func loginResult(err error) string {
+	switch {
+	case err == nil:
+		return metrics.LoginSuccess
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return metrics.LoginInvalidCredentials
+	case errors.Is(err, auth.ErrUserDisabled):
+		return metrics.LoginDisabled
+	case errors.Is(err, auth.ErrAccountExpired):
+		return metrics.LoginExpired
+	default:
+		return metrics.LoginError
+	}
+}
+
+// GenerateToken 產生 JWT Token，訪客的帳號類型、頻道與到期時間會寫入 token
 func (s *authService) GenerateToken(singleUser *user.User) (string, error) {
-	token, err := s.jwtManager.GenerateToken(rbac.NewRBACClaims(
+	claims := rbac.NewRBACClaims(
 		singleUser.ID,
 		singleUser.Email,
 		singleUser.Username,
 		singleUser.Role,
 		singleUser.Permissions,
-	))
+	)
+	if singleUser.IsGuest() {
+		claims.WithGuestScope(singleUser.AccountType, singleUser.GuestChannels, singleUser.ExpiresAt)
+	}
+
+	token, err := s.jwtManager.GenerateToken(claims)
 	if err != nil {
+		if errors.Is(err, authlib.ErrExpiredToken) {
+			return "", auth.ErrAccountExpired
+		}
 		return "", err
 	}
 	return token, nil
 }
+
+// RefreshToken 依資料庫中的使用者重新產生 token，已刪除、停用或過期的帳號不予換發
+func (s *authService) RefreshToken(ctx context.Context, userID uint) (string, error) {
+	singleUser, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || singleUser.IsDeleted {
+		return "", auth.ErrInvalidCredentials
+	}
+	if singleUser.IsDisabled {
+		return "", auth.ErrUserDisabled
+	}
+	if singleUser.IsExpired(time.Now()) {
+		return "", auth.ErrAccountExpired
+	}
+	return s.GenerateToken(singleUser)
+}
+
+// IssueTokenPair 簽發 access token 與 refresh token
+func (s *authService) IssueTokenPair(ctx context.Context, singleUser *user.User) (*oauth.TokenResponse, error) {
+	accessToken, err := s.GenerateToken(singleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Save(ctx, refreshToken, singleUser.ID, s.refreshTokenTTL); err != nil {
+		return nil, err
+	}
+
+	return &oauth.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.jwtManager.GetExpiresIn() / 1000,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// RefreshTokenPair 以 refresh token 換發新的 token pair，舊的 refresh token 隨即失效
+func (s *authService) RefreshTokenPair(ctx context.Context, refreshToken string) (*oauth.TokenResponse, error) {
+	userID, err := s.refreshTokenRepo.Consume(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, oauth.ErrNotFound) {
+			return nil, oauth.ErrInvalidGrant.WithDescription("refresh token is invalid or expired")
+		}
+		return nil, err
+	}
+
+	singleUser, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || singleUser.IsDeleted || singleUser.IsDisabled || singleUser.IsExpired(time.Now()) {
+		return nil, oauth.ErrInvalidGrant.WithDescription("user is not active")
+	}
+	return s.IssueTokenPair(ctx, singleUser)
+}
+
+// RevokeTokens 撤銷使用者目前持有的所有 token
+func (s *authService) RevokeTokens(ctx context.Context, userID uint) error {
+	ttl := time.Duration(s.jwtManager.GetExpiresIn()) * time.Millisecond
+	return s.revocationStore.RevokeUser(ctx, userID, time.Now(), ttl)
+}
+
+// DeactivateExpiredGuests 停用已過期的訪客並撤銷其 token，回傳處理的數量
+func (s *authService) DeactivateExpiredGuests(ctx context.Context, now time.Time) (int, error) {
+	guests, _, err := s.userRepo.List(ctx, &user.ListQuery{
+		Conditions: []user.Condition{
+			{Field: "account_type", Operator: user.OperatorNotEqual, Value: user.AccountTypeMember},
+			{Field: "expires_at", Operator: user.OperatorLessOrEqual, Value: now},
+			{Field: "is_disabled", Operator: user.OperatorEqual, Value: false},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deactivated := 0
+	for _, guest := range guests {
+		guest.IsDisabled = true
+		if err := s.userRepo.Update(ctx, guest); err != nil {
+			return deactivated, err
+		}
+		if err := s.RevokeTokens(ctx, guest.ID); err != nil {
+			// 帳號已停用無法再登入，既有 token 也會在帳號到期時失效，僅記錄錯誤
+			logger.Warn("failed to revoke guest tokens",
+				logger.Any("user_id", guest.ID),
+				logger.Err(err),
+			)
+		}
+		deactivated++
+	}
+	return deactivated, nil
+}
+
+// randomToken 產生不透明的隨機 token
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+func TestLogin(t *testing.T) {
+	hashed, err := authlib.HashPassword("correct-password")
+	require.NoError(t, err)
+	expiredAt := time.Now().Add(-time.Hour)
+	userRepo := newMockUserRepository(
+		&user.User{Username: "alice", Email: "alice@example.com", Password: hashed, Role: "user"},
+		&user.User{Username: "bob", Email: "bob@example.com", Password: hashed, Role: "user", IsDisabled: true},
+		&user.User{Username: "guest", Email: "guest@example.com", Password: hashed, Role: "user", ExpiresAt: &expiredAt},
+	)
+	service := newTestAuthService(miniredis.RunT(t), userRepo)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		err      error
+	}{
+		{name: "Valid credentials", email: "alice@example.com", password: "correct-password"},
+		{name: "Wrong password", email: "alice@example.com", password: "wrong-password", err: auth.ErrInvalidCredentials},
+		{name: "Unknown email", email: "nobody@example.com", password: "correct-password", err: auth.ErrInvalidCredentials},
+		{name: "Disabled user with correct password", email: "bob@example.com", password: "correct-password", err: auth.ErrUserDisabled},
+		{name: "Disabled user with wrong password", email: "bob@example.com", password: "wrong-password", err: auth.ErrInvalidCredentials},
+		{name: "Expired account with correct password", email: "guest@example.com", password: "correct-password", err: auth.ErrAccountExpired},
+		{name: "Expired account with wrong password", email: "guest@example.com", password: "wrong-password", err: auth.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := service.Login(context.Background(), tt.email, tt.password)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, token)
+		})
+	}
+}
+
+func TestRefreshTokenPair(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rotation invalidates the old refresh token", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		userRepo := newMockUserRepository(&user.User{Username: "alice", Email: "alice@example.com", Role: "user"})
+		service := newTestAuthService(mr, userRepo)
+
+		alice, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		first, err := service.IssueTokenPair(ctx, alice)
+		require.NoError(t, err)
+
+		second, err := service.RefreshTokenPair(ctx, first.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEmpty(t, second.AccessToken)
+		assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+		assert.Equal(t, 1, refreshTokens(mr))
+
+		_, err = service.RefreshTokenPair(ctx, first.RefreshToken)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+
+		_, err = service.RefreshTokenPair(ctx, second.RefreshToken)
+		require.NoError(t, err)
+	})
+
+	t.Run("Unknown refresh token", func(t *testing.T) {
+		service := newTestAuthService(miniredis.RunT(t), newMockUserRepository())
+		_, err := service.RefreshTokenPair(ctx, "unknown")
+		assert.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+	})
+
+	t.Run("Inactive user", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		userRepo := newMockUserRepository(&user.User{Username: "alice", Email: "alice@example.com", Role: "user"})
+		service := newTestAuthService(mr, userRepo)
+
+		alice, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		pair, err := service.IssueTokenPair(ctx, alice)
+		require.NoError(t, err)
+
+		alice.IsDisabled = true
+		require.NoError(t, userRepo.Update(ctx, alice))
+		_, err = service.RefreshTokenPair(ctx, pair.RefreshToken)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+		assert.Zero(t, refreshTokens(mr))
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Hour)
+	userRepo := newMockUserRepository(
+		&user.User{Username: "alice", Email: "alice@example.com", Role: "user"},
+		&user.User{Username: "bob", Email: "bob@example.com", Role: "user", IsDisabled: true},
+		&user.User{Username: "guest", Email: "guest@example.com", Role: "user",
+			AccountType: user.AccountTypeMultiChannelGuest, GuestChannels: []string{"general"}, ExpiresAt: &expiredAt},
+		&user.User{Username: "carol", Email: "carol@example.com", Role: "user", IsDeleted: true},
+	)
+	service := newTestAuthService(miniredis.RunT(t), userRepo)
+
+	tests := []struct {
+		name   string
+		userID uint
+		err    error
+	}{
+		{name: "Active user", userID: 1},
+		{name: "Disabled user", userID: 2, err: auth.ErrUserDisabled},
+		{name: "Expired guest", userID: 3, err: auth.ErrAccountExpired},
+		{name: "Deleted user", userID: 4, err: auth.ErrInvalidCredentials},
+		{name: "Unknown user", userID: 99, err: auth.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := service.RefreshToken(context.Background(), tt.userID)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, token)
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/scoped"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"gorm.io/gorm"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+// defaultAuthorizationCodeTTL 未配置時授權碼的有效期（秒）
+const defaultAuthorizationCodeTTL = 600
+
+type authorizationService struct {
+	clientRepo           oauth.ClientRepository
+	codeRepo             oauth.AuthorizationCodeRepository
+	userRepo             user.UserRepository
+	jwtManager           *scoped.ScopedJWTManager
+	authorizationCodeTTL int
+}
+
+// NewAuthorizationService 創建新的第三方應用程式授權服務實例
+func NewAuthorizationService(clientRepo oauth.ClientRepository, codeRepo oauth.AuthorizationCodeRepository,
+	userRepo user.UserRepository, jwtManager *scoped.ScopedJWTManager, cfg *configlib.OAuthConfig) oauth.AuthorizationService {
+	authorizationCodeTTL := cfg.AuthorizationCodeTTL
+	if authorizationCodeTTL <= 0 {
+		authorizationCodeTTL = defaultAuthorizationCodeTTL
+	}
+	return &authorizationService{
+		clientRepo:           clientRepo,
+		codeRepo:             codeRepo,
+		userRepo:             userRepo,
+		jwtManager:           jwtManager,
+		authorizationCodeTTL: authorizationCodeTTL,
+	}
+}
+
+// RegisterClient 註冊第三方應用程式，confidential client 會產生 client_secret
+func (s *authorizationService) RegisterClient(ctx context.Context, ownerID uint, req *oauth.ClientRegistrationRequest) (*oauth.ClientRegistrationResponse, error) {
+	for _, scope := range req.Scopes {
+		if _, ok := oauth.Scopes[scope]; !ok {
+			return nil, oauth.ErrInvalidScope.WithDescription("unknown scope " + scope)
+		}
+	}
+	for _, redirectURI := range req.RedirectURIs {
+		if parsed, err := url.Parse(redirectURI); err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			return nil, oauth.ErrInvalidRequest.WithDescription("redirect_uri must be an absolute URI without fragment")
+		}
+	}
+
+	client := &oauth.Client{
+		Name:         req.Name,
+		ClientType:   req.ClientType,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+		OwnerID:      ownerID,
+	}
+	if client.AllowsGrant(oauth.GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return nil, oauth.ErrInvalidRequest.WithDescription("redirect_uris is required for authorization_code")
+	}
+	// bot 應用程式需以 client_secret 驗證身分，public client 無法使用 client credentials
+	if client.IsPublic() && client.AllowsGrant(oauth.GrantTypeClientCredentials) {
+		return nil, oauth.ErrInvalidRequest.WithDescription("public clients cannot use client_credentials")
+	}
+
+	clientID, err := randomClientID()
+	if err != nil {
+		return nil, err
+	}
+	client.ClientID = clientID
+
+	var clientSecret string
+	if !client.IsPublic() {
+		if clientSecret, err = randomToken(); err != nil {
+			return nil, err
+		}
+		if client.ClientSecret, err = authlib.HashPassword(clientSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+	return &oauth.ClientRegistrationResponse{Client: client, ClientSecret: clientSecret}, nil
+}
+
+// ListClients 列出使用者註冊的應用程式
+func (s *authorizationService) ListClients(ctx context.Context, ownerID uint) ([]*oauth.Client, error) {
+	return s.clientRepo.FindByOwnerID(ctx, ownerID)
+}
+
+// DeleteClient 刪除使用者註冊的應用程式，已簽發的 token 於過期後失效
+func (s *authorizationService) DeleteClient(ctx context.Context, ownerID uint, clientID string) error {
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return oauth.ErrNotFound
+		}
+		return err
+	}
+	if client.OwnerID != ownerID {
+		return oauth.ErrNotFound
+	}
+	return s.clientRepo.Delete(ctx, client.ID)
+}
+
+// Authorize 驗證授權請求並回傳同意畫面所需資訊
+func (s *authorizationService) Authorize(ctx context.Context, req *oauth.AuthorizeRequest) (*oauth.ConsentInfo, error) {
+	client, redirectURI, scopes, err := s.validateAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := make([]oauth.ScopeDescription, 0, len(scopes))
+	for _, scope := range scopes {
+		descriptions = append(descriptions, oauth.ScopeDescription{Name: scope, Description: oauth.Scopes[scope]})
+	}
+	return &oauth.ConsentInfo{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: redirectURI,
+		Scopes:      descriptions,
+		State:       req.State,
+	}, nil
+}
+
+// Consent 記錄使用者的決定，同意時簽發授權碼
+func (s *authorizationService) Consent(ctx context.Context, userID uint, req *oauth.ConsentRequest) (*oauth.ConsentResponse, error) {
+	client, redirectURI, scopes, err := s.validateAuthorizeRequest(ctx, &req.AuthorizeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	if !req.Approve {
+		query.Set("error", oauth.ErrAccessDenied.Code)
+		return &oauth.ConsentResponse{RedirectURI: appendQuery(redirectURI, query)}, nil
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.codeRepo.Save(ctx, &oauth.AuthorizationCode{
+		Code:     code,
+		ClientID: client.ClientID,
+		UserID:   userID,
+		// 保存原始參數，token 請求須帶相同的 redirect_uri（RFC 6749 4.1.3）
+		RedirectURI:         req.RedirectURI,
+		Scope:               strings.Join(scopes, " "),
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(time.Duration(s.authorizationCodeTTL) * time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	query.Set("code", code)
+	return &oauth.ConsentResponse{RedirectURI: appendQuery(redirectURI, query)}, nil
+}
+
+// ExchangeCode 以授權碼與 code_verifier 換發 scoped access token（RFC 6749 4.1.3）
+func (s *authorizationService) ExchangeCode(ctx context.Context, req *oauth.TokenRequest) (*oauth.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsGrant(oauth.GrantTypeAuthorizationCode) {
+		return nil, oauth.ErrUnauthorizedClient
+	}
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauth.ErrInvalidRequest.WithDescription("code and code_verifier are required")
+	}
+
+	code, err := s.codeRepo.Consume(ctx, req.Code)
+	if err != nil {
+		if errors.Is(err, oauth.ErrNotFound) {
+			return nil, oauth.ErrInvalidGrant.WithDescription("code is invalid or expired")
+		}
+		return nil, err
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI {
+		return nil, oauth.ErrInvalidGrant.WithDescription("code was issued to another client or redirect_uri")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, oauth.ErrInvalidGrant.WithDescription("code_verifier does not match")
+	}
+
+	singleUser, err := s.userRepo.FindByID(ctx, code.UserID)
+	if err != nil || singleUser.IsDeleted || singleUser.IsDisabled || singleUser.IsExpired(time.Now()) {
+		return nil, oauth.ErrInvalidGrant.WithDescription("user is not active")
+	}
+
+	claims := scoped.NewScopedClaims(client.ClientID, singleUser.ID, "", singleUser.Username, oauth.ParseScope(code.Scope))
+	// 郵箱僅在使用者同意 users:read.email 時寫入 token
+	if claims.HasScope(oauth.ScopeUsersReadEmail) {
+		claims.Email = singleUser.Email
+	}
+	return s.issueToken(claims)
+}
+
+// ClientCredentials 以 client 身分換發不代表任何使用者的 bot token（RFC 6749 4.4）
+func (s *authorizationService) ClientCredentials(ctx context.Context, req *oauth.TokenRequest) (*oauth.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() || !client.AllowsGrant(oauth.GrantTypeClientCredentials) {
+		return nil, oauth.ErrUnauthorizedClient
+	}
+
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return nil, oauth.ErrInvalidScope.WithDescription("scope " + scope + " is not allowed")
+		}
+	}
+	return s.issueToken(scoped.NewScopedClaims(client.ClientID, 0, "", client.Name, scopes))
+}
+
+// validateAuthorizeRequest 驗證 client、redirect_uri、scope 與 PKCE 參數
+func (s *authorizationService) validateAuthorizeRequest(ctx context.Context, req *oauth.AuthorizeRequest) (*oauth.Client, string, []string, error) {
+	if req.ResponseType != oauth.ResponseTypeCode {
+		return nil, "", nil, oauth.ErrUnsupportedResponseType
+	}
+
+	client, err := s.clientRepo.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", nil, oauth.ErrInvalidClient.WithDescription("unknown client_id")
+		}
+		return nil, "", nil, err
+	}
+	if !client.AllowsGrant(oauth.GrantTypeAuthorizationCode) {
+		return nil, "", nil, oauth.ErrUnauthorizedClient
+	}
+
+	// 僅註冊單一 redirect_uri 時可省略（RFC 6749 3.1.2.3）
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.AllowsRedirectURI(redirectURI) {
+		return nil, "", nil, oauth.ErrInvalidRequest.WithDescription("redirect_uri does not match")
+	}
+
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		return nil, "", nil, oauth.ErrInvalidScope.WithDescription("scope is required")
+	}
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return nil, "", nil, oauth.ErrInvalidScope.WithDescription("scope " + scope + " is not allowed")
+		}
+	}
+
+	// 所有 client 皆須使用 PKCE，且不接受 plain
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != oauth.CodeChallengeMethodS256 {
+		return nil, "", nil, oauth.ErrInvalidRequest.WithDescription("code_challenge with S256 is required")
+	}
+	return client, redirectURI, scopes, nil
+}
+
+// authenticateClient 驗證 client 身分，public client 不帶 client_secret
+func (s *authorizationService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*oauth.Client, error) {
+	if clientID == "" {
+		return nil, oauth.ErrInvalidClient.WithDescription("client_id is required")
+	}
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauth.ErrInvalidClient
+		}
+		return nil, err
+	}
+	if !client.IsPublic() && authlib.CheckPassword(clientSecret, client.ClientSecret) != nil {
+		return nil, oauth.ErrInvalidClient
+	}
+	return client, nil
+}
+
+// issueToken 簽發 scoped access token，第三方應用程式不發放 refresh token
+func (s *authorizationService) issueToken(claims *scoped.ScopedClaims) (*oauth.TokenResponse, error) {
+	accessToken, err := s.jwtManager.GenerateToken(claims)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.jwtManager.GetExpiresIn() / 1000,
+		Scope:       claims.Scope,
+	}, nil
+}
+
+// verifyCodeChallenge 驗證 S256 code_verifier（RFC 7636 4.6）
+func verifyCodeChallenge(codeVerifier, codeChallenge string) bool {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(codeChallenge)) == 1
+}
+
+// randomClientID 產生 client_id
+func randomClientID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// appendQuery 將參數附加於 redirect_uri，保留原有的查詢參數
+func appendQuery(redirectURI string, query url.Values) string {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	values := parsed.Query()
+	for key := range query {
+		values.Set(key, query.Get(key))
+	}
+	parsed.RawQuery = values.Encode()
+	return parsed.String()
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/scoped"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	redisrepo "github.com/POABOB/slack-clone-back-end/services/user-service/internal/repository/redis"
+)
+
+const (
+	// testCodeVerifier 與 testCodeChallenge 為 S256 對應的 PKCE 參數
+	testCodeVerifier  = "M25iVXpKU3puUjFaYWg3T1NDTDQtcW1ROUY5YXlwalNoc0hhakxifmZHag"
+	testCodeChallenge = "qjrzSW9gMiUgpUvqgEPE4_-8swvyCtfOVvg55o5S_es"
+	testRedirectURI   = "https://app.example.com/callback"
+)
+
+type authorizationFixture struct {
+	service      oauth.AuthorizationService
+	jwtManager   *scoped.ScopedJWTManager
+	public       *oauth.Client
+	confidential *oauth.ClientRegistrationResponse
+}
+
+// newAuthorizationFixture creates an authorization service with a public and a confidential client owned by user 1.
+func newAuthorizationFixture(t *testing.T) *authorizationFixture {
+	mr := miniredis.RunT(t)
+	userRepo := newMockUserRepository(
+		&user.User{Username: "alice", Email: "alice@example.com"},
+		&user.User{Username: "bob", Email: "bob@example.com", IsDisabled: true},
+	)
+	jwtManager := scoped.NewScopedJWTManager(&configlib.JWTConfig{SecretKey: "test-secret-key-0123456789", ExpiresIn: 60000})
+	service := NewAuthorizationService(newMockClientRepository(),
+		redisrepo.NewAuthorizationCodeRepository(redis.NewClient(&redis.Options{Addr: mr.Addr()})),
+		userRepo, jwtManager, &configlib.OAuthConfig{})
+
+	ctx := context.Background()
+	public, err := service.RegisterClient(ctx, 1, &oauth.ClientRegistrationRequest{
+		Name:         "Public App",
+		ClientType:   oauth.ClientTypePublic,
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []string{oauth.GrantTypeAuthorizationCode},
+		Scopes:       []string{oauth.ScopeUsersRead, oauth.ScopeUsersReadEmail},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, public.ClientSecret)
+
+	confidential, err := service.RegisterClient(ctx, 1, &oauth.ClientRegistrationRequest{
+		Name:         "Bot",
+		ClientType:   oauth.ClientTypeConfidential,
+		RedirectURIs: []string{testRedirectURI, "https://app.example.com/other"},
+		GrantTypes:   []string{oauth.GrantTypeAuthorizationCode, oauth.GrantTypeClientCredentials},
+		Scopes:       []string{oauth.ScopeUsersRead, oauth.ScopeChatWrite},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, confidential.ClientSecret)
+
+	return &authorizationFixture{
+		service:      service,
+		jwtManager:   jwtManager,
+		public:       public.Client,
+		confidential: confidential,
+	}
+}
+
+// authorizeRequest builds a valid authorization request for client.
+func authorizeRequest(client *oauth.Client, scope string) oauth.AuthorizeRequest {
+	return oauth.AuthorizeRequest{
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            client.ClientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               scope,
+		State:               "xyz",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: oauth.CodeChallengeMethodS256,
+	}
+}
+
+// consent approves req as userID and returns the issued code.
+func (f *authorizationFixture) consent(t *testing.T, userID uint, req oauth.AuthorizeRequest) string {
+	resp, err := f.service.Consent(context.Background(), userID, &oauth.ConsentRequest{AuthorizeRequest: req, Approve: true})
+	require.NoError(t, err)
+
+	redirect, err := url.Parse(resp.RedirectURI)
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+	require.NotEmpty(t, redirect.Query().Get("code"))
+	return redirect.Query().Get("code")
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		expected  bool
+	}{
+		{name: "Matching verifier", verifier: testCodeVerifier, challenge: testCodeChallenge, expected: true},
+		{name: "Different verifier", verifier: testCodeVerifier + "x", challenge: testCodeChallenge},
+		{name: "Plain challenge is rejected", verifier: testCodeVerifier, challenge: testCodeVerifier},
+		{name: "Padded challenge is rejected", verifier: testCodeVerifier, challenge: testCodeChallenge + "="},
+		{name: "Empty challenge", verifier: testCodeVerifier, challenge: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, verifyCodeChallenge(tt.verifier, tt.challenge))
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	f := newAuthorizationFixture(t)
+
+	t.Run("Consent information", func(t *testing.T) {
+		req := authorizeRequest(f.public, "users:read users:read.email")
+		req.RedirectURI = ""
+		info, err := f.service.Authorize(context.Background(), &req)
+		require.NoError(t, err)
+		assert.Equal(t, "Public App", info.ClientName)
+		assert.Equal(t, testRedirectURI, info.RedirectURI)
+		assert.Len(t, info.Scopes, 2)
+	})
+
+	tests := []struct {
+		name   string
+		modify func(req *oauth.AuthorizeRequest)
+		err    error
+	}{
+		{name: "Unsupported response type", modify: func(req *oauth.AuthorizeRequest) { req.ResponseType = "token" }, err: oauth.ErrUnsupportedResponseType},
+		{name: "Unknown client", modify: func(req *oauth.AuthorizeRequest) { req.ClientID = "unknown" }, err: oauth.ErrInvalidClient},
+		{name: "Unregistered redirect_uri", modify: func(req *oauth.AuthorizeRequest) { req.RedirectURI = "https://evil.example.com" }, err: oauth.ErrInvalidRequest},
+		{name: "Scope not allowed", modify: func(req *oauth.AuthorizeRequest) { req.Scope = "chat:write" }, err: oauth.ErrInvalidScope},
+		{name: "Missing scope", modify: func(req *oauth.AuthorizeRequest) { req.Scope = "" }, err: oauth.ErrInvalidScope},
+		{name: "Missing code_challenge", modify: func(req *oauth.AuthorizeRequest) { req.CodeChallenge = "" }, err: oauth.ErrInvalidRequest},
+		{name: "Plain code_challenge_method", modify: func(req *oauth.AuthorizeRequest) { req.CodeChallengeMethod = "plain" }, err: oauth.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := authorizeRequest(f.public, "users:read")
+			tt.modify(&req)
+			_, err := f.service.Authorize(context.Background(), &req)
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+		})
+	}
+
+	t.Run("Ambiguous redirect_uri must be given", func(t *testing.T) {
+		req := authorizeRequest(f.confidential.Client, "users:read")
+		req.RedirectURI = ""
+		_, err := f.service.Authorize(context.Background(), &req)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidRequest))
+	})
+
+	t.Run("Denied consent redirects with access_denied", func(t *testing.T) {
+		resp, err := f.service.Consent(context.Background(), 1, &oauth.ConsentRequest{AuthorizeRequest: authorizeRequest(f.public, "users:read")})
+		require.NoError(t, err)
+		redirect, err := url.Parse(resp.RedirectURI)
+		require.NoError(t, err)
+		assert.Equal(t, "access_denied", redirect.Query().Get("error"))
+		assert.Equal(t, "xyz", redirect.Query().Get("state"))
+		assert.Empty(t, redirect.Query().Get("code"))
+	})
+}
+
+func TestExchangeCode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Public client exchanges code with PKCE once", func(t *testing.T) {
+		f := newAuthorizationFixture(t)
+		code := f.consent(t, 1, authorizeRequest(f.public, "users:read users:read.email"))
+
+		req := &oauth.TokenRequest{ClientID: f.public.ClientID, Code: code, RedirectURI: testRedirectURI, CodeVerifier: testCodeVerifier}
+		token, err := f.service.ExchangeCode(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Empty(t, token.RefreshToken)
+		assert.Equal(t, "users:read users:read.email", token.Scope)
+
+		claims, err := f.jwtManager.ValidateToken(token.AccessToken)
+		require.NoError(t, err)
+		scopedClaims := claims.(*scoped.ScopedClaims)
+		assert.Equal(t, f.public.ClientID, scopedClaims.GetClientID())
+		assert.Equal(t, uint(1), scopedClaims.UserID)
+		assert.Equal(t, "alice@example.com", scopedClaims.Email)
+
+		_, err = f.service.ExchangeCode(ctx, req)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+	})
+
+	t.Run("Email is omitted without users:read.email", func(t *testing.T) {
+		f := newAuthorizationFixture(t)
+		code := f.consent(t, 1, authorizeRequest(f.public, "users:read"))
+
+		token, err := f.service.ExchangeCode(ctx, &oauth.TokenRequest{
+			ClientID: f.public.ClientID, Code: code, RedirectURI: testRedirectURI, CodeVerifier: testCodeVerifier,
+		})
+		require.NoError(t, err)
+		claims, err := f.jwtManager.ValidateToken(token.AccessToken)
+		require.NoError(t, err)
+		assert.Empty(t, claims.(*scoped.ScopedClaims).Email)
+	})
+
+	t.Run("Confidential client must authenticate", func(t *testing.T) {
+		f := newAuthorizationFixture(t)
+		code := f.consent(t, 1, authorizeRequest(f.confidential.Client, "users:read"))
+		req := &oauth.TokenRequest{
+			ClientID: f.confidential.ClientID, Code: code, RedirectURI: testRedirectURI, CodeVerifier: testCodeVerifier,
+		}
+
+		req.ClientSecret = "wrong"
+		_, err := f.service.ExchangeCode(ctx, req)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidClient))
+
+		req.ClientSecret = f.confidential.ClientSecret
+		_, err = f.service.ExchangeCode(ctx, req)
+		require.NoError(t, err)
+	})
+
+	tests := []struct {
+		name   string
+		userID uint
+		modify func(f *authorizationFixture, req *oauth.TokenRequest)
+		err    error
+	}{
+		{
+			name:   "Wrong code_verifier",
+			userID: 1,
+			modify: func(_ *authorizationFixture, req *oauth.TokenRequest) { req.CodeVerifier = "wrong-verifier" },
+			err:    oauth.ErrInvalidGrant,
+		},
+		{
+			name:   "Missing code_verifier",
+			userID: 1,
+			modify: func(_ *authorizationFixture, req *oauth.TokenRequest) { req.CodeVerifier = "" },
+			err:    oauth.ErrInvalidRequest,
+		},
+		{
+			name:   "Different redirect_uri",
+			userID: 1,
+			modify: func(_ *authorizationFixture, req *oauth.TokenRequest) { req.RedirectURI = "" },
+			err:    oauth.ErrInvalidGrant,
+		},
+		{
+			name:   "Code issued to another client",
+			userID: 1,
+			modify: func(f *authorizationFixture, req *oauth.TokenRequest) {
+				req.ClientID, req.ClientSecret = f.confidential.ClientID, f.confidential.ClientSecret
+			},
+			err: oauth.ErrInvalidGrant,
+		},
+		{
+			name:   "Unknown code",
+			userID: 1,
+			modify: func(_ *authorizationFixture, req *oauth.TokenRequest) { req.Code = "unknown" },
+			err:    oauth.ErrInvalidGrant,
+		},
+		{
+			name:   "Inactive user",
+			userID: 2,
+			modify: func(*authorizationFixture, *oauth.TokenRequest) {},
+			err:    oauth.ErrInvalidGrant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthorizationFixture(t)
+			code := f.consent(t, tt.userID, authorizeRequest(f.public, "users:read"))
+			req := &oauth.TokenRequest{ClientID: f.public.ClientID, Code: code, RedirectURI: testRedirectURI, CodeVerifier: testCodeVerifier}
+			tt.modify(f, req)
+
+			_, err := f.service.ExchangeCode(ctx, req)
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+		})
+	}
+
+	t.Run("Failed exchange consumes the code", func(t *testing.T) {
+		f := newAuthorizationFixture(t)
+		code := f.consent(t, 1, authorizeRequest(f.public, "users:read"))
+		req := &oauth.TokenRequest{ClientID: f.public.ClientID, Code: code, RedirectURI: testRedirectURI, CodeVerifier: "wrong-verifier"}
+		_, err := f.service.ExchangeCode(ctx, req)
+		require.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+
+		req.CodeVerifier = testCodeVerifier
+		_, err = f.service.ExchangeCode(ctx, req)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+	})
+}
+
+func TestClientCredentials(t *testing.T) {
+	f := newAuthorizationFixture(t)
+	ctx := context.Background()
+
+	token, err := f.service.ClientCredentials(ctx, &oauth.TokenRequest{
+		ClientID: f.confidential.ClientID, ClientSecret: f.confidential.ClientSecret, Scope: "chat:write",
+	})
+	require.NoError(t, err)
+	claims, err := f.jwtManager.ValidateToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.(*scoped.ScopedClaims).IsBot())
+
+	_, err = f.service.ClientCredentials(ctx, &oauth.TokenRequest{
+		ClientID: f.confidential.ClientID, ClientSecret: f.confidential.ClientSecret, Scope: "channels:history",
+	})
+	assert.True(t, errors.Is(err, oauth.ErrInvalidScope))
+
+	_, err = f.service.ClientCredentials(ctx, &oauth.TokenRequest{ClientID: f.public.ClientID})
+	assert.True(t, errors.Is(err, oauth.ErrUnauthorizedClient))
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+const (
+	// userCodeCharset user_code 字元集，排除母音與易混淆字元（RFC 8628 6.1）
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	// userCodeLength user_code 長度，顯示時每 4 碼以 - 分隔
+	userCodeLength = 8
+	// userCodeRetries user_code 碰撞時的重試次數
+	userCodeRetries = 5
+	// defaultDeviceExpiresIn 未配置時裝置授權的有效期（秒）
+	defaultDeviceExpiresIn = 600
+	// defaultDeviceInterval 未配置時的最小輪詢間隔（秒）
+	defaultDeviceInterval = 5
+	// slowDownIncrement 收到 slow_down 後輪詢間隔增加的秒數
+	slowDownIncrement = 5
+)
+
+type deviceService struct {
+	deviceRepo      oauth.DeviceRepository
+	userRepo        user.UserRepository
+	authService     auth.AuthService
+	clientIDs       map[string]struct{}
+	verificationURI string
+	expiresIn       int
+	interval        int
+}
+
+// NewDeviceService 創建新的裝置授權服務實例
+func NewDeviceService(deviceRepo oauth.DeviceRepository, userRepo user.UserRepository,
+	authService auth.AuthService, cfg *configlib.OAuthConfig) oauth.DeviceService {
+	clientIDs := make(map[string]struct{}, len(cfg.Device.ClientIDs))
+	for _, clientID := range cfg.Device.ClientIDs {
+		clientIDs[clientID] = struct{}{}
+	}
+
+	expiresIn := cfg.Device.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = defaultDeviceExpiresIn
+	}
+	interval := cfg.Device.Interval
+	if interval <= 0 {
+		interval = defaultDeviceInterval
+	}
+
+	return &deviceService{
+		deviceRepo:      deviceRepo,
+		userRepo:        userRepo,
+		authService:     authService,
+		clientIDs:       clientIDs,
+		verificationURI: cfg.Device.VerificationURI,
+		expiresIn:       expiresIn,
+		interval:        interval,
+	}
+}
+
+// RequestCode 為裝置產生 device_code 與 user_code
+func (s *deviceService) RequestCode(ctx context.Context, req *oauth.DeviceCodeRequest) (*oauth.DeviceCodeResponse, error) {
+	if _, ok := s.clientIDs[req.ClientID]; !ok {
+		return nil, oauth.ErrInvalidClient.WithDescription("unknown client_id")
+	}
+
+	deviceCode, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	authorization := &oauth.DeviceAuthorization{
+		DeviceCode: deviceCode,
+		ClientID:   req.ClientID,
+		Scope:      req.Scope,
+		Status:     oauth.DeviceStatusPending,
+		Interval:   s.interval,
+		ExpiresAt:  time.Now().Add(time.Duration(s.expiresIn) * time.Second),
+	}
+	for i := 0; ; i++ {
+		if authorization.UserCode, err = randomUserCode(); err != nil {
+			return nil, err
+		}
+		err = s.deviceRepo.Save(ctx, authorization)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, oauth.ErrConflict) || i+1 >= userCodeRetries {
+			return nil, err
+		}
+	}
+
+	userCode := formatUserCode(authorization.UserCode)
+	return &oauth.DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         s.verificationURI,
+		VerificationURIComplete: s.verificationURI + "?user_code=" + url.QueryEscape(userCode),
+		ExpiresIn:               s.expiresIn,
+		Interval:                s.interval,
+	}, nil
+}
+
+// GetVerification 獲取待使用者確認的裝置授權
+func (s *deviceService) GetVerification(ctx context.Context, userCode string) (*oauth.DeviceVerificationInfo, error) {
+	authorization, err := s.findPending(ctx, userCode)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth.DeviceVerificationInfo{
+		UserCode:  formatUserCode(authorization.UserCode),
+		ClientID:  authorization.ClientID,
+		Scope:     authorization.Scope,
+		ExpiresAt: authorization.ExpiresAt,
+	}, nil
+}
+
+// Verify 使用者確認或拒絕裝置授權
+func (s *deviceService) Verify(ctx context.Context, userCode string, userID uint, approve bool) error {
+	authorization, err := s.findPending(ctx, userCode)
+	if err != nil {
+		return err
+	}
+
+	authorization.UserID = userID
+	authorization.Status = oauth.DeviceStatusDenied
+	if approve {
+		authorization.Status = oauth.DeviceStatusApproved
+	}
+	return s.deviceRepo.Update(ctx, authorization)
+}
+
+// PollToken 裝置輪詢 token，使用者確認後簽發 token pair（RFC 8628 3.4、3.5）
+func (s *deviceService) PollToken(ctx context.Context, clientID, deviceCode string) (*oauth.TokenResponse, error) {
+	authorization, err := s.deviceRepo.FindByDeviceCode(ctx, deviceCode)
+	if err != nil {
+		if errors.Is(err, oauth.ErrNotFound) {
+			return nil, oauth.ErrExpiredToken
+		}
+		return nil, err
+	}
+	if authorization.ClientID != clientID {
+		return nil, oauth.ErrInvalidGrant.WithDescription("device_code was issued to another client")
+	}
+
+	now := time.Now()
+	poll, err := s.deviceRepo.RecordPoll(ctx, authorization, now)
+	if err != nil {
+		return nil, err
+	}
+	interval := time.Duration(authorization.Interval+poll.SlowDown) * time.Second
+	if now.Sub(poll.LastPolledAt) < interval {
+		if err := s.deviceRepo.SlowDown(ctx, authorization, slowDownIncrement); err != nil {
+			return nil, err
+		}
+		return nil, oauth.ErrSlowDown
+	}
+	if authorization.Status == oauth.DeviceStatusPending {
+		return nil, oauth.ErrAuthorizationPending
+	}
+
+	// device_code 只能兌換一次，只有實際取走紀錄的請求能簽發 token
+	authorization, err = s.deviceRepo.Consume(ctx, authorization)
+	if err != nil {
+		if errors.Is(err, oauth.ErrNotFound) {
+			return nil, oauth.ErrInvalidGrant.WithDescription("device_code has already been used")
+		}
+		return nil, err
+	}
+	if authorization.Status != oauth.DeviceStatusApproved {
+		return nil, oauth.ErrAccessDenied
+	}
+
+	singleUser, err := s.userRepo.FindByID(ctx, authorization.UserID)
+	if err != nil || singleUser.IsDeleted || singleUser.IsDisabled || singleUser.IsExpired(now) {
+		return nil, oauth.ErrAccessDenied.WithDescription("user is not active")
+	}
+	token, err := s.authService.IssueTokenPair(ctx, singleUser)
+	if err != nil {
+		return nil, err
+	}
+	token.Scope = authorization.Scope
+	return token, nil
+}
+
+// findPending 以使用者輸入的 user_code 查詢尚未確認的授權
+func (s *deviceService) findPending(ctx context.Context, userCode string) (*oauth.DeviceAuthorization, error) {
+	authorization, err := s.deviceRepo.FindByUserCode(ctx, normalizeUserCode(userCode))
+	if err != nil {
+		return nil, err
+	}
+	if authorization.Status != oauth.DeviceStatusPending {
+		return nil, oauth.ErrNotFound
+	}
+	return authorization, nil
+}
+
+// randomUserCode 以 userCodeCharset 產生隨機 user_code
+func randomUserCode() (string, error) {
+	charsetSize := big.NewInt(int64(len(userCodeCharset)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, charsetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeCharset[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// formatUserCode 將 user_code 格式化為 XXXX-XXXX 方便使用者輸入
+func formatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// normalizeUserCode 移除分隔字元並轉大寫，使用者輸入不區分大小寫
+func normalizeUserCode(userCode string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(userCode))
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/revocation"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	redisrepo "github.com/POABOB/slack-clone-back-end/services/user-service/internal/repository/redis"
+)
+
+var testOAuthConfig = &configlib.OAuthConfig{
+	Device: configlib.DeviceFlowConfig{
+		ClientIDs:       []string{"slack-cli"},
+		VerificationURI: "https://app.example.com/device",
+	},
+}
+
+// newTestAuthService creates an auth service issuing real token pairs backed by mr.
+func newTestAuthService(mr *miniredis.Miniredis, userRepo user.UserRepository) auth.AuthService {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	jwtManager := rbac.NewRBACJWTManager(&configlib.JWTConfig{SecretKey: "test-secret-key-0123456789", ExpiresIn: 60000})
+	return NewAuthService(userRepo, redisrepo.NewRefreshTokenRepository(client), jwtManager,
+		revocation.NewRedisStore(client), testOAuthConfig)
+}
+
+// newTestDeviceService creates a device service with the active user 1 and the disabled user 2.
+func newTestDeviceService(t *testing.T) (oauth.DeviceService, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	userRepo := newMockUserRepository(
+		&user.User{Username: "alice", Email: "alice@example.com", Role: "user"},
+		&user.User{Username: "bob", Email: "bob@example.com", Role: "user", IsDisabled: true},
+	)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	service := NewDeviceService(redisrepo.NewDeviceRepository(client), userRepo, newTestAuthService(mr, userRepo), testOAuthConfig)
+	return service, mr
+}
+
+// rewindPoll moves the last poll of deviceCode back by d, as if the device had waited.
+func rewindPoll(t *testing.T, mr *miniredis.Miniredis, deviceCode string, d time.Duration) {
+	key := "oauth:device:poll:" + deviceCode
+	last, err := strconv.ParseInt(mr.HGet(key, "last_polled_at"), 10, 64)
+	require.NoError(t, err)
+	mr.HSet(key, "last_polled_at", strconv.FormatInt(last-int64(d), 10))
+}
+
+// refreshTokens counts the refresh tokens stored in mr.
+func refreshTokens(mr *miniredis.Miniredis) int {
+	count := 0
+	for _, key := range mr.Keys() {
+		if strings.HasPrefix(key, "oauth:refresh:") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDeviceRequestCode(t *testing.T) {
+	service, _ := newTestDeviceService(t)
+
+	t.Run("Issues codes", func(t *testing.T) {
+		resp, err := service.RequestCode(context.Background(), &oauth.DeviceCodeRequest{ClientID: "slack-cli", Scope: "users:read"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.DeviceCode)
+		assert.Regexp(t, `^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$`, resp.UserCode)
+		assert.Equal(t, "https://app.example.com/device", resp.VerificationURI)
+		assert.Equal(t, "https://app.example.com/device?user_code="+resp.UserCode, resp.VerificationURIComplete)
+		assert.Equal(t, defaultDeviceExpiresIn, resp.ExpiresIn)
+		assert.Equal(t, defaultDeviceInterval, resp.Interval)
+	})
+
+	t.Run("Unknown client", func(t *testing.T) {
+		_, err := service.RequestCode(context.Background(), &oauth.DeviceCodeRequest{ClientID: "unknown"})
+		assert.True(t, errors.Is(err, oauth.ErrInvalidClient))
+	})
+}
+
+func TestDevicePollToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Approved device receives a token pair once", func(t *testing.T) {
+		service, mr := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli", Scope: "users:read"})
+		require.NoError(t, err)
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrAuthorizationPending))
+
+		info, err := service.GetVerification(ctx, strings.ToLower(resp.UserCode))
+		require.NoError(t, err)
+		assert.Equal(t, "slack-cli", info.ClientID)
+		require.NoError(t, service.Verify(ctx, resp.UserCode, 1, true))
+
+		rewindPoll(t, mr, resp.DeviceCode, 5*time.Second)
+		token, err := service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.NotEmpty(t, token.RefreshToken)
+		assert.Equal(t, "users:read", token.Scope)
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrExpiredToken))
+		_, err = service.GetVerification(ctx, resp.UserCode)
+		assert.True(t, errors.Is(err, oauth.ErrNotFound))
+	})
+
+	t.Run("Polling too fast slows the device down", func(t *testing.T) {
+		service, mr := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+		require.NoError(t, err)
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrAuthorizationPending))
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrSlowDown))
+
+		// 間隔已增加為 10 秒
+		rewindPoll(t, mr, resp.DeviceCode, 6*time.Second)
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrSlowDown))
+
+		rewindPoll(t, mr, resp.DeviceCode, 16*time.Second)
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrAuthorizationPending))
+	})
+
+	t.Run("Polling does not overwrite an approval", func(t *testing.T) {
+		service, mr := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+		require.NoError(t, err)
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrAuthorizationPending))
+		require.NoError(t, service.Verify(ctx, resp.UserCode, 1, true))
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrSlowDown))
+
+		rewindPoll(t, mr, resp.DeviceCode, time.Minute)
+		token, err := service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+	})
+
+	t.Run("Denied device", func(t *testing.T) {
+		service, mr := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+		require.NoError(t, err)
+		require.NoError(t, service.Verify(ctx, resp.UserCode, 1, false))
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrAccessDenied))
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrExpiredToken))
+		assert.Zero(t, refreshTokens(mr))
+	})
+
+	t.Run("Verified code cannot be verified again", func(t *testing.T) {
+		service, _ := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+		require.NoError(t, err)
+		require.NoError(t, service.Verify(ctx, resp.UserCode, 1, true))
+
+		assert.True(t, errors.Is(service.Verify(ctx, resp.UserCode, 1, false), oauth.ErrNotFound))
+	})
+
+	t.Run("Inactive user", func(t *testing.T) {
+		service, mr := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+		require.NoError(t, err)
+		require.NoError(t, service.Verify(ctx, resp.UserCode, 2, true))
+
+		_, err = service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrAccessDenied))
+		assert.Zero(t, refreshTokens(mr))
+	})
+
+	t.Run("Another client", func(t *testing.T) {
+		service, _ := newTestDeviceService(t)
+		resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+		require.NoError(t, err)
+
+		_, err = service.PollToken(ctx, "slack-desktop", resp.DeviceCode)
+		assert.True(t, errors.Is(err, oauth.ErrInvalidGrant))
+	})
+
+	t.Run("Unknown device code", func(t *testing.T) {
+		service, _ := newTestDeviceService(t)
+		_, err := service.PollToken(ctx, "slack-cli", "unknown")
+		assert.True(t, errors.Is(err, oauth.ErrExpiredToken))
+	})
+}
+
+func TestDevicePollTokenConcurrentRedeem(t *testing.T) {
+	ctx := context.Background()
+	service, mr := newTestDeviceService(t)
+	resp, err := service.RequestCode(ctx, &oauth.DeviceCodeRequest{ClientID: "slack-cli"})
+	require.NoError(t, err)
+	require.NoError(t, service.Verify(ctx, resp.UserCode, 1, true))
+
+	const pollers = 20
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		tokens int
+	)
+	start := make(chan struct{})
+	for i := 0; i < pollers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			token, err := service.PollToken(ctx, "slack-cli", resp.DeviceCode)
+			if err != nil {
+				var oauthErr *oauth.Error
+				assert.True(t, errors.As(err, &oauthErr), "unexpected error %v", err)
+				return
+			}
+			assert.NotEmpty(t, token.AccessToken)
+			mu.Lock()
+			tokens++
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, 1, tokens)
+	assert.Equal(t, 1, refreshTokens(mr))
+}
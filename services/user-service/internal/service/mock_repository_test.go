@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/group"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/oauth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+// mockUserRepository is an in-memory user.UserRepository.
+type mockUserRepository struct {
+	mu     sync.Mutex
+	users  map[uint]*user.User
+	nextID uint
+}
+
+func newMockUserRepository(users ...*user.User) *mockUserRepository {
+	repo := &mockUserRepository{users: make(map[uint]*user.User)}
+	for _, u := range users {
+		_ = repo.Create(context.Background(), u)
+	}
+	return repo
+}
+
+func (r *mockUserRepository) Create(_ context.Context, u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u.ID == 0 {
+		r.nextID++
+		u.ID = r.nextID
+	} else if u.ID > r.nextID {
+		r.nextID = u.ID
+	}
+	stored := *u
+	r.users[u.ID] = &stored
+	return nil
+}
+
+func (r *mockUserRepository) FindByID(_ context.Context, id uint) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *u
+	return &found, nil
+}
+
+func (r *mockUserRepository) FindByEmail(_ context.Context, email string) (*user.User, error) {
+	return r.find(func(u *user.User) bool { return u.Email == email })
+}
+
+func (r *mockUserRepository) FindByUsername(_ context.Context, username string) (*user.User, error) {
+	return r.find(func(u *user.User) bool { return !u.IsDeleted && strings.EqualFold(u.Username, username) })
+}
+
+func (r *mockUserRepository) List(_ context.Context, _ *user.ListQuery) ([]*user.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]*user.User, 0, len(r.users))
+	for _, u := range r.users {
+		found := *u
+		users = append(users, &found)
+	}
+	return users, int64(len(users)), nil
+}
+
+func (r *mockUserRepository) Update(ctx context.Context, u *user.User) error {
+	return r.Create(ctx, u)
+}
+
+func (r *mockUserRepository) UpdateProfile(_ context.Context, id uint, req *user.UpdateRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.IsDeleted {
+		return user.ErrUserNotFound
+	}
+	if req.Username != nil {
+		u.Username = *req.Username
+	}
+	if req.Password != nil {
+		u.Password = *req.Password
+	}
+	return nil
+}
+
+func (r *mockUserRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+func (r *mockUserRepository) find(match func(u *user.User) bool) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// mockGroupRepository is an in-memory group.GroupRepository that records every write.
+type mockGroupRepository struct {
+	mu       sync.Mutex
+	userRepo *mockUserRepository
+	groups   map[uint]*group.Group
+	members  map[uint][]uint
+	nextID   uint
+	writes   int
+}
+
+func newMockGroupRepository(userRepo *mockUserRepository) *mockGroupRepository {
+	return &mockGroupRepository{
+		userRepo: userRepo,
+		groups:   make(map[uint]*group.Group),
+		members:  make(map[uint][]uint),
+	}
+}
+
+func (r *mockGroupRepository) Create(_ context.Context, g *group.Group, memberIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	g.ID = r.nextID
+	r.save(g, memberIDs)
+	return nil
+}
+
+func (r *mockGroupRepository) FindByID(ctx context.Context, id uint) (*group.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *g
+	for _, memberID := range r.members[id] {
+		if member, err := r.userRepo.FindByID(ctx, memberID); err == nil && !member.IsDeleted {
+			found.Members = append(found.Members, member)
+		}
+	}
+	return &found, nil
+}
+
+func (r *mockGroupRepository) FindByDisplayName(ctx context.Context, displayName string) (*group.Group, error) {
+	r.mu.Lock()
+	var id uint
+	for _, g := range r.groups {
+		if strings.EqualFold(g.DisplayName, displayName) {
+			id = g.ID
+		}
+	}
+	r.mu.Unlock()
+	if id == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *mockGroupRepository) FindByMemberID(ctx context.Context, userID uint) ([]*group.Group, error) {
+	r.mu.Lock()
+	ids := make([]uint, 0)
+	for id, memberIDs := range r.members {
+		for _, memberID := range memberIDs {
+			if memberID == userID {
+				ids = append(ids, id)
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	groups := make([]*group.Group, 0, len(ids))
+	for _, id := range ids {
+		g, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (r *mockGroupRepository) List(_ context.Context, _ *user.ListQuery) ([]*group.Group, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *mockGroupRepository) Update(_ context.Context, g *group.Group, memberIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[g.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.save(g, memberIDs)
+	return nil
+}
+
+// Patch applies the member changes to the stored members, like the targeted writes of the real repository.
+func (r *mockGroupRepository) Patch(_ context.Context, g *group.Group, members *group.MemberPatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[g.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	memberIDs := r.members[g.ID]
+	if members.Replace {
+		memberIDs = nil
+	}
+	memberIDs = slices.DeleteFunc(memberIDs, func(id uint) bool { return slices.Contains(members.Remove, id) })
+	for _, id := range members.Add {
+		if !slices.Contains(memberIDs, id) {
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	r.save(g, memberIDs)
+	return nil
+}
+
+func (r *mockGroupRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.groups, id)
+	delete(r.members, id)
+	return nil
+}
+
+// memberIDs returns the stored member IDs of the group.
+func (r *mockGroupRepository) memberIDs(id uint) []uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint(nil), r.members[id]...)
+}
+
+func (r *mockGroupRepository) save(g *group.Group, memberIDs []uint) {
+	stored := *g
+	stored.Members = nil
+	r.groups[g.ID] = &stored
+	r.members[g.ID] = append([]uint(nil), memberIDs...)
+	r.writes++
+}
+
+// mockClientRepository is an in-memory oauth.ClientRepository.
+type mockClientRepository struct {
+	mu      sync.Mutex
+	clients map[string]*oauth.Client
+	nextID  uint
+}
+
+func newMockClientRepository() *mockClientRepository {
+	return &mockClientRepository{clients: make(map[string]*oauth.Client)}
+}
+
+func (r *mockClientRepository) Create(_ context.Context, client *oauth.Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	client.ID = r.nextID
+	stored := *client
+	r.clients[client.ClientID] = &stored
+	return nil
+}
+
+func (r *mockClientRepository) FindByClientID(_ context.Context, clientID string) (*oauth.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *client
+	return &found, nil
+}
+
+func (r *mockClientRepository) FindByOwnerID(_ context.Context, ownerID uint) ([]*oauth.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clients := make([]*oauth.Client, 0)
+	for _, client := range r.clients {
+		if client.OwnerID == ownerID {
+			found := *client
+			clients = append(clients, &found)
+		}
+	}
+	return clients, nil
+}
+
+func (r *mockClientRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for clientID, client := range r.clients {
+		if client.ID == id {
+			delete(r.clients, clientID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/scim"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+// scimComparison 解析後的單一 filter 比較式
+type scimComparison struct {
+	Attribute string
+	Operator  string
+	Value     interface{}
+}
+
+// scimOperators 支援的比較運算子（RFC 7644 3.4.2.2）
+var scimOperators = map[string]struct{}{
+	user.OperatorEqual:          {},
+	user.OperatorNotEqual:       {},
+	user.OperatorContains:       {},
+	user.OperatorStartsWith:     {},
+	user.OperatorEndsWith:       {},
+	user.OperatorGreaterThan:    {},
+	user.OperatorGreaterOrEqual: {},
+	user.OperatorLessThan:       {},
+	user.OperatorLessOrEqual:    {},
+	user.OperatorPresent:        {},
+}
+
+// parseSCIMFilter 解析 SCIM filter，目前支援以 and 串接的比較式，例如：
+// userName eq "bjensen" and active eq true
+// or、not 與括號分組屬於進階語法，遇到時回傳 ErrInvalidFilter
+func parseSCIMFilter(filter string) ([]scimComparison, error) {
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	comparisons := make([]scimComparison, 0)
+	for i := 0; i < len(tokens); {
+		if len(comparisons) > 0 {
+			if !strings.EqualFold(tokens[i], "and") {
+				return nil, fmt.Errorf("%w: unsupported logical operator %q", scim.ErrInvalidFilter, tokens[i])
+			}
+			i++
+		}
+
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("%w: incomplete expression", scim.ErrInvalidFilter)
+		}
+
+		attribute := tokens[i]
+		if strings.ContainsAny(attribute, "()[]") || strings.EqualFold(attribute, "not") {
+			return nil, fmt.Errorf("%w: grouping is not supported", scim.ErrInvalidFilter)
+		}
+
+		operator := strings.ToLower(tokens[i+1])
+		if _, ok := scimOperators[operator]; !ok {
+			return nil, fmt.Errorf("%w: unsupported operator %q", scim.ErrInvalidFilter, tokens[i+1])
+		}
+
+		if operator == user.OperatorPresent {
+			comparisons = append(comparisons, scimComparison{Attribute: attribute, Operator: operator})
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(tokens) {
+			return nil, fmt.Errorf("%w: missing value for %q", scim.ErrInvalidFilter, attribute)
+		}
+		value, err := parseSCIMValue(tokens[i+2])
+		if err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, scimComparison{Attribute: attribute, Operator: operator, Value: value})
+		i += 3
+	}
+
+	return comparisons, nil
+}
+
+// tokenizeSCIMFilter 以空白切分 filter，保留雙引號字串（含跳脫字元）為單一 token
+func tokenizeSCIMFilter(filter string) ([]string, error) {
+	tokens := make([]string, 0)
+	var current strings.Builder
+	inQuotes, escaped := false, false
+
+	for _, r := range filter {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case inQuotes && r == '\\':
+			current.WriteRune(r)
+			escaped = true
+		case r == '"':
+			current.WriteRune(r)
+			inQuotes = !inQuotes
+		case !inQuotes && (r == ' ' || r == '\t'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("%w: unterminated string", scim.ErrInvalidFilter)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// parseSCIMValue 解析比較值：字串、布林、null 或數字
+func parseSCIMValue(token string) (interface{}, error) {
+	if strings.HasPrefix(token, `"`) {
+		var value string
+		if err := json.Unmarshal([]byte(token), &value); err != nil {
+			return nil, fmt.Errorf("%w: invalid string %s", scim.ErrInvalidFilter, token)
+		}
+		return value, nil
+	}
+
+	switch strings.ToLower(token) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if number, err := strconv.ParseFloat(token, 64); err == nil {
+		return number, nil
+	}
+	return nil, fmt.Errorf("%w: invalid value %s", scim.ErrInvalidFilter, token)
+}
+
+// normalizeSCIMAttribute 移除 schema URN 前綴並轉小寫，SCIM 屬性名稱不區分大小寫
+func normalizeSCIMAttribute(attribute, schema string) string {
+	lower := strings.ToLower(attribute)
+	prefix := strings.ToLower(schema) + ":"
+	return strings.TrimPrefix(lower, prefix)
+}
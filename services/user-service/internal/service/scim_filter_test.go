@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/scim"
+)
+
+func TestParseSCIMFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		expected []scimComparison
+		wantErr  bool
+	}{
+		{
+			name:     "Empty filter",
+			filter:   "",
+			expected: []scimComparison{},
+		},
+		{
+			name:     "Single comparison",
+			filter:   `userName eq "bjensen"`,
+			expected: []scimComparison{{Attribute: "userName", Operator: "eq", Value: "bjensen"}},
+		},
+		{
+			name:   "Comparisons joined by and",
+			filter: `userName sw "b" AND active eq true`,
+			expected: []scimComparison{
+				{Attribute: "userName", Operator: "sw", Value: "b"},
+				{Attribute: "active", Operator: "eq", Value: true},
+			},
+		},
+		{
+			name:     "Operators are case-insensitive",
+			filter:   `displayName CO "ops"`,
+			expected: []scimComparison{{Attribute: "displayName", Operator: "co", Value: "ops"}},
+		},
+		{
+			name:     "Quoted string with spaces and escapes",
+			filter:   `displayName eq "Ops \"Team\" A"`,
+			expected: []scimComparison{{Attribute: "displayName", Operator: "eq", Value: `Ops "Team" A`}},
+		},
+		{
+			name:     "Present operator takes no value",
+			filter:   `externalId pr and id eq "7"`,
+			expected: []scimComparison{{Attribute: "externalId", Operator: "pr"}, {Attribute: "id", Operator: "eq", Value: "7"}},
+		},
+		{
+			name:     "Number and null values",
+			filter:   `id gt 3 and externalId eq null`,
+			expected: []scimComparison{{Attribute: "id", Operator: "gt", Value: float64(3)}, {Attribute: "externalId", Operator: "eq", Value: nil}},
+		},
+		{
+			name:     "Schema-qualified attribute",
+			filter:   `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"`,
+			expected: []scimComparison{{Attribute: "urn:ietf:params:scim:schemas:core:2.0:User:userName", Operator: "eq", Value: "bjensen"}},
+		},
+		{name: "Or is not supported", filter: `userName eq "a" or userName eq "b"`, wantErr: true},
+		{name: "Not is not supported", filter: `not userName eq "a"`, wantErr: true},
+		{name: "Grouping is not supported", filter: `(userName eq "a")`, wantErr: true},
+		{name: "Value filter is not supported", filter: `emails[type eq "work"] pr`, wantErr: true},
+		{name: "Unknown operator", filter: `userName like "a"`, wantErr: true},
+		{name: "Missing value", filter: `userName eq`, wantErr: true},
+		{name: "Missing operator", filter: `userName`, wantErr: true},
+		{name: "Unterminated string", filter: `userName eq "bjensen`, wantErr: true},
+		{name: "Unquoted string", filter: `userName eq bjensen`, wantErr: true},
+		{name: "Trailing logical operator", filter: `userName eq "a" and`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparisons, err := parseSCIMFilter(tt.filter)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, scim.ErrInvalidFilter), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, comparisons)
+		})
+	}
+}
+
+func TestSCIMConditions(t *testing.T) {
+	service := &scimService{}
+
+	t.Run("User attributes map to columns", func(t *testing.T) {
+		conditions, err := service.userConditions(`emails.value ew "@example.com" and active eq false and id eq "7"`)
+		require.NoError(t, err)
+		require.Len(t, conditions, 3)
+		assert.Equal(t, "email", conditions[0].Field)
+		assert.Equal(t, "is_disabled", conditions[1].Field)
+		assert.Equal(t, true, conditions[1].Value)
+		assert.Equal(t, "id", conditions[2].Field)
+	})
+
+	t.Run("Unsupported user attribute", func(t *testing.T) {
+		_, err := service.userConditions(`title eq "Engineer"`)
+		assert.True(t, errors.Is(err, scim.ErrInvalidFilter))
+	})
+
+	t.Run("Active requires a boolean", func(t *testing.T) {
+		_, err := service.userConditions(`active eq "yes"`)
+		assert.True(t, errors.Is(err, scim.ErrInvalidFilter))
+	})
+
+	t.Run("Numeric id must be a non-negative integer", func(t *testing.T) {
+		conditions, err := service.userConditions(`id eq 7`)
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), conditions[0].Value)
+
+		for _, filter := range []string{`id eq -1`, `id eq 1.5`} {
+			_, err := service.userConditions(filter)
+			assert.True(t, errors.Is(err, scim.ErrInvalidFilter), filter)
+		}
+	})
+
+	t.Run("Group attributes map to columns", func(t *testing.T) {
+		conditions, err := service.groupConditions(`displayName eq "Ops" and externalId pr`)
+		require.NoError(t, err)
+		require.Len(t, conditions, 2)
+		assert.Equal(t, "display_name", conditions[0].Field)
+		assert.Equal(t, "external_id", conditions[1].Field)
+	})
+}
@@ -0,0 +1,837 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+	"gorm.io/gorm"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/auth"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/group"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/scim"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+const (
+	// scimBasePath SCIM 資源的路徑前綴，用於 meta.location 與 $ref
+	scimBasePath = "/scim/v2"
+	// defaultSCIMMaxResults 未配置時單次列表查詢的上限
+	defaultSCIMMaxResults = 100
+)
+
+// memberValueFilterPattern 匹配 PATCH path 中的 members[value eq "id"]
+var memberValueFilterPattern = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*]$`)
+
+type scimService struct {
+	userRepo    user.UserRepository
+	groupRepo   group.GroupRepository
+	authService auth.AuthService
+	maxResults  int
+}
+
+// NewSCIMService 創建新的 SCIM 佈建服務實例，停用或刪除使用者時透過 authService 撤銷其 token
+func NewSCIMService(userRepo user.UserRepository, groupRepo group.GroupRepository, authService auth.AuthService,
+	cfg *configlib.SCIMConfig) scim.SCIMService {
+	maxResults := cfg.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultSCIMMaxResults
+	}
+	return &scimService{
+		userRepo:    userRepo,
+		groupRepo:   groupRepo,
+		authService: authService,
+		maxResults:  maxResults,
+	}
+}
+
+// CreateUser 佈建新使用者，未提供密碼時產生隨機密碼（僅能透過 SSO 登入）
+func (s *scimService) CreateUser(ctx context.Context, u *scim.User) (*scim.User, error) {
+	email := primaryEmail(u.Emails)
+	if email == "" {
+		return nil, fmt.Errorf("%w: emails is required", scim.ErrInvalidValue)
+	}
+	if err := s.checkUserUniqueness(ctx, 0, u.UserName, email); err != nil {
+		return nil, err
+	}
+
+	password := u.Password
+	if password == "" {
+		var err error
+		if password, err = randomToken(); err != nil {
+			return nil, err
+		}
+	}
+	hashedPassword, err := authlib.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	singleUser := &user.User{
+		Email:      email,
+		Password:   hashedPassword,
+		Username:   u.UserName,
+		Role:       "user",
+		ExternalID: u.ExternalID,
+		IsDisabled: u.Active != nil && !*u.Active,
+	}
+	if err := s.userRepo.Create(ctx, singleUser); err != nil {
+		return nil, err
+	}
+	return s.toSCIMUser(ctx, singleUser, true)
+}
+
+// GetUser 獲取單一使用者
+func (s *scimService) GetUser(ctx context.Context, id string) (*scim.User, error) {
+	singleUser, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toSCIMUser(ctx, singleUser, true)
+}
+
+// ListUsers 依 filter 與分頁參數列出使用者
+func (s *scimService) ListUsers(ctx context.Context, params *scim.ListParams) (*scim.ListResponse, error) {
+	conditions, err := s.userConditions(params.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex, count := s.pagination(params)
+	users, total, err := s.userRepo.List(ctx, &user.ListQuery{
+		Conditions: conditions,
+		Offset:     startIndex - 1,
+		Limit:      max(count, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]interface{}, 0, len(users))
+	if count > 0 {
+		for _, singleUser := range users {
+			// 列表不展開 groups，避免 N+1 查詢
+			resource, err := s.toSCIMUser(ctx, singleUser, false)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return newListResponse(total, startIndex, resources), nil
+}
+
+// ReplaceUser 以完整資源取代使用者（PUT）
+func (s *scimService) ReplaceUser(ctx context.Context, id string, u *scim.User) (*scim.User, error) {
+	singleUser, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	email := primaryEmail(u.Emails)
+	if email == "" {
+		return nil, fmt.Errorf("%w: emails is required", scim.ErrInvalidValue)
+	}
+	if err := s.checkUserUniqueness(ctx, singleUser.ID, u.UserName, email); err != nil {
+		return nil, err
+	}
+
+	wasActive := !singleUser.IsDisabled
+	singleUser.Username = u.UserName
+	singleUser.Email = email
+	singleUser.ExternalID = u.ExternalID
+	singleUser.IsDisabled = u.Active != nil && !*u.Active
+	if u.Password != "" {
+		if singleUser.Password, err = authlib.HashPassword(u.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, singleUser); err != nil {
+		return nil, err
+	}
+	if err := s.revokeIfDeactivated(ctx, singleUser, wasActive); err != nil {
+		return nil, err
+	}
+	return s.toSCIMUser(ctx, singleUser, true)
+}
+
+// PatchUser 部分更新使用者，停用帳號即 replace active=false
+func (s *scimService) PatchUser(ctx context.Context, id string, req *scim.PatchRequest) (*scim.User, error) {
+	singleUser, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	wasActive := !singleUser.IsDisabled
+	for _, operation := range req.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return nil, fmt.Errorf("%w: unsupported op %q", scim.ErrInvalidValue, operation.Op)
+		}
+
+		if operation.Path == "" {
+			attributes, ok := operation.Value.(map[string]interface{})
+			if !ok || op == "remove" {
+				return nil, fmt.Errorf("%w: path is required", scim.ErrInvalidPath)
+			}
+			for attribute, value := range attributes {
+				if err := setUserAttribute(singleUser, op, attribute, value); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if err := setUserAttribute(singleUser, op, operation.Path, operation.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkUserUniqueness(ctx, singleUser.ID, singleUser.Username, singleUser.Email); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Update(ctx, singleUser); err != nil {
+		return nil, err
+	}
+	if err := s.revokeIfDeactivated(ctx, singleUser, wasActive); err != nil {
+		return nil, err
+	}
+	return s.toSCIMUser(ctx, singleUser, true)
+}
+
+// DeleteUser 刪除使用者並撤銷其 token
+func (s *scimService) DeleteUser(ctx context.Context, id string) error {
+	singleUser, err := s.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(ctx, singleUser.ID); err != nil {
+		return err
+	}
+	return s.authService.RevokeTokens(ctx, singleUser.ID)
+}
+
+// revokeIfDeactivated 使用者由啟用改為停用時撤銷其 token，撤銷失敗時回傳錯誤讓 IdP 重試
+func (s *scimService) revokeIfDeactivated(ctx context.Context, u *user.User, wasActive bool) error {
+	if !wasActive || !u.IsDisabled {
+		return nil
+	}
+	return s.authService.RevokeTokens(ctx, u.ID)
+}
+
+// CreateGroup 建立群組並設定成員
+func (s *scimService) CreateGroup(ctx context.Context, g *scim.Group) (*scim.Group, error) {
+	if err := s.checkGroupUniqueness(ctx, 0, g.DisplayName); err != nil {
+		return nil, err
+	}
+	memberIDs, err := s.memberIDs(ctx, g.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	singleGroup := &group.Group{
+		DisplayName: g.DisplayName,
+		ExternalID:  g.ExternalID,
+	}
+	if err := s.groupRepo.Create(ctx, singleGroup, memberIDs); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, strconv.FormatUint(uint64(singleGroup.ID), 10))
+}
+
+// GetGroup 獲取單一群組
+func (s *scimService) GetGroup(ctx context.Context, id string) (*scim.Group, error) {
+	singleGroup, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMGroup(singleGroup), nil
+}
+
+// ListGroups 依 filter 與分頁參數列出群組
+func (s *scimService) ListGroups(ctx context.Context, params *scim.ListParams) (*scim.ListResponse, error) {
+	conditions, err := s.groupConditions(params.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex, count := s.pagination(params)
+	groups, total, err := s.groupRepo.List(ctx, &user.ListQuery{
+		Conditions: conditions,
+		Offset:     startIndex - 1,
+		Limit:      max(count, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]interface{}, 0, len(groups))
+	if count > 0 {
+		for _, singleGroup := range groups {
+			resources = append(resources, toSCIMGroup(singleGroup))
+		}
+	}
+	return newListResponse(total, startIndex, resources), nil
+}
+
+// ReplaceGroup 以完整資源取代群組（PUT）
+func (s *scimService) ReplaceGroup(ctx context.Context, id string, g *scim.Group) (*scim.Group, error) {
+	singleGroup, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGroupUniqueness(ctx, singleGroup.ID, g.DisplayName); err != nil {
+		return nil, err
+	}
+	memberIDs, err := s.memberIDs(ctx, g.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	singleGroup.DisplayName = g.DisplayName
+	singleGroup.ExternalID = g.ExternalID
+	if err := s.groupRepo.Update(ctx, singleGroup, memberIDs); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, id)
+}
+
+// PatchGroup 部分更新群組，支援成員的新增、移除與取代
+// 所有操作先彙整為成員的增量變更並通過驗證後才一次寫入，任一操作失敗時不會留下部分結果（RFC 7644 3.5.2）
+// 只寫入有變動的成員，並行的 PATCH 不會互相覆蓋
+func (s *scimService) PatchGroup(ctx context.Context, id string, req *scim.PatchRequest) (*scim.Group, error) {
+	singleGroup, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	memberPatch := &group.MemberPatch{}
+	for _, operation := range req.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return nil, fmt.Errorf("%w: unsupported op %q", scim.ErrInvalidValue, operation.Op)
+		}
+
+		if operation.Path == "" {
+			attributes, ok := operation.Value.(map[string]interface{})
+			if !ok || op == "remove" {
+				return nil, fmt.Errorf("%w: path is required", scim.ErrInvalidPath)
+			}
+			for attribute, value := range attributes {
+				if err := s.patchGroupAttribute(ctx, singleGroup, memberPatch, op, attribute, value); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if err := s.patchGroupAttribute(ctx, singleGroup, memberPatch, op, operation.Path, operation.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkGroupUniqueness(ctx, singleGroup.ID, singleGroup.DisplayName); err != nil {
+		return nil, err
+	}
+	if err := s.groupRepo.Patch(ctx, singleGroup, memberPatch); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, id)
+}
+
+// DeleteGroup 刪除群組
+func (s *scimService) DeleteGroup(ctx context.Context, id string) error {
+	singleGroup, err := s.findGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(ctx, singleGroup.ID)
+}
+
+// patchGroupAttribute 套用單一群組屬性的 PATCH 操作，成員變更累積於 members，不會寫入資料庫
+func (s *scimService) patchGroupAttribute(ctx context.Context, g *group.Group, members *group.MemberPatch,
+	op, path string, value interface{}) error {
+	if matches := memberValueFilterPattern.FindStringSubmatch(path); matches != nil {
+		if op != "remove" {
+			return fmt.Errorf("%w: %s", scim.ErrInvalidPath, path)
+		}
+		memberID, err := parseResourceID(matches[1])
+		if err != nil {
+			return fmt.Errorf("%w: %s", scim.ErrNoTarget, path)
+		}
+		members.RemoveMembers([]uint{memberID})
+		return nil
+	}
+
+	switch normalizeSCIMAttribute(path, scim.SchemaGroup) {
+	case "displayname":
+		name, ok := value.(string)
+		if op == "remove" || !ok || name == "" {
+			return fmt.Errorf("%w: displayName", scim.ErrInvalidValue)
+		}
+		g.DisplayName = name
+	case "externalid":
+		if op == "remove" {
+			g.ExternalID = ""
+			return nil
+		}
+		externalID, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%w: externalId", scim.ErrInvalidValue)
+		}
+		g.ExternalID = externalID
+	case "members":
+		// 未指定 value 時移除全部成員
+		if op == "remove" && value == nil {
+			members.ReplaceMembers(nil)
+			return nil
+		}
+		decoded, err := decodeMembers(value)
+		if err != nil {
+			return err
+		}
+		patchIDs, err := s.memberIDs(ctx, decoded)
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case "add":
+			members.AddMembers(patchIDs)
+		case "replace":
+			members.ReplaceMembers(patchIDs)
+		default:
+			members.RemoveMembers(patchIDs)
+		}
+	default:
+		return fmt.Errorf("%w: %s", scim.ErrInvalidPath, path)
+	}
+	return nil
+}
+
+// setUserAttribute 套用單一使用者屬性，未儲存的屬性（如 name、title）直接忽略以相容各家 IdP
+func setUserAttribute(u *user.User, op, path string, value interface{}) error {
+	attribute := normalizeSCIMAttribute(path, scim.SchemaUser)
+
+	switch {
+	case attribute == "active":
+		if op == "remove" {
+			return fmt.Errorf("%w: active", scim.ErrInvalidValue)
+		}
+		active, err := decodeBool(value)
+		if err != nil {
+			return err
+		}
+		u.IsDisabled = !active
+	case attribute == "username":
+		username, ok := value.(string)
+		if op == "remove" || !ok || username == "" {
+			return fmt.Errorf("%w: userName", scim.ErrInvalidValue)
+		}
+		u.Username = username
+	case attribute == "externalid":
+		if op == "remove" {
+			u.ExternalID = ""
+			return nil
+		}
+		externalID, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%w: externalId", scim.ErrInvalidValue)
+		}
+		u.ExternalID = externalID
+	case attribute == "password":
+		password, ok := value.(string)
+		if op == "remove" || !ok || password == "" {
+			return fmt.Errorf("%w: password", scim.ErrInvalidValue)
+		}
+		hashedPassword, err := authlib.HashPassword(password)
+		if err != nil {
+			return fmt.Errorf("%w: %v", scim.ErrInvalidValue, err)
+		}
+		u.Password = hashedPassword
+	case strings.HasPrefix(attribute, "emails"):
+		if op == "remove" {
+			return fmt.Errorf("%w: emails is required", scim.ErrInvalidValue)
+		}
+		email, err := decodeEmail(value)
+		if err != nil {
+			return err
+		}
+		u.Email = email
+	}
+	return nil
+}
+
+// findUser 依 SCIM id 查找未刪除的使用者
+func (s *scimService) findUser(ctx context.Context, id string) (*user.User, error) {
+	userID, err := parseResourceID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	singleUser, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, scim.ErrNotFound
+		}
+		return nil, err
+	}
+	if singleUser.IsDeleted {
+		return nil, scim.ErrNotFound
+	}
+	return singleUser, nil
+}
+
+// findGroup 依 SCIM id 查找群組
+func (s *scimService) findGroup(ctx context.Context, id string) (*group.Group, error) {
+	groupID, err := parseResourceID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	singleGroup, err := s.groupRepo.FindByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, scim.ErrNotFound
+		}
+		return nil, err
+	}
+	return singleGroup, nil
+}
+
+// checkUserUniqueness 檢查 userName 與 email 是否已被其他使用者使用
+func (s *scimService) checkUserUniqueness(ctx context.Context, id uint, username, email string) error {
+	if existing, err := s.userRepo.FindByUsername(ctx, username); err == nil && existing.ID != id {
+		return fmt.Errorf("%w: userName %q", scim.ErrUniqueness, username)
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if existing, err := s.userRepo.FindByEmail(ctx, email); err == nil && existing.ID != id {
+		return fmt.Errorf("%w: email %q", scim.ErrUniqueness, email)
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// checkGroupUniqueness 檢查 displayName 是否已被其他群組使用
+func (s *scimService) checkGroupUniqueness(ctx context.Context, id uint, displayName string) error {
+	existing, err := s.groupRepo.FindByDisplayName(ctx, displayName)
+	if err == nil && existing.ID != id {
+		return fmt.Errorf("%w: displayName %q", scim.ErrUniqueness, displayName)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// memberIDs 解析成員 ID 並確認使用者存在
+func (s *scimService) memberIDs(ctx context.Context, members []scim.Member) ([]uint, error) {
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if _, err := s.findUser(ctx, member.Value); err != nil {
+			if errors.Is(err, scim.ErrNotFound) {
+				return nil, fmt.Errorf("%w: member %q not found", scim.ErrInvalidValue, member.Value)
+			}
+			return nil, err
+		}
+		id, _ := parseResourceID(member.Value)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// userConditions 將 User filter 轉換為使用者查詢條件
+func (s *scimService) userConditions(filter string) ([]user.Condition, error) {
+	comparisons, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]user.Condition, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		condition := user.Condition{Operator: comparison.Operator, Value: comparison.Value}
+
+		switch normalizeSCIMAttribute(comparison.Attribute, scim.SchemaUser) {
+		case "id":
+			id, err := filterID(comparison.Value)
+			if err != nil {
+				return nil, err
+			}
+			condition.Field, condition.Value = "id", id
+		case "username":
+			condition.Field = "username"
+		case "externalid":
+			condition.Field = "external_id"
+		case "emails", "emails.value":
+			condition.Field = "email"
+		case "active":
+			// active 對應 is_disabled，布林值需反轉
+			active, ok := comparison.Value.(bool)
+			if comparison.Operator != user.OperatorPresent && !ok {
+				return nil, fmt.Errorf("%w: active requires a boolean", scim.ErrInvalidFilter)
+			}
+			if comparison.Operator != user.OperatorPresent &&
+				comparison.Operator != user.OperatorEqual && comparison.Operator != user.OperatorNotEqual {
+				return nil, fmt.Errorf("%w: unsupported operator for active", scim.ErrInvalidFilter)
+			}
+			condition.Field, condition.Value = "is_disabled", !active
+		case "meta.created":
+			condition.Field = "created_at"
+		case "meta.lastmodified":
+			condition.Field = "updated_at"
+		default:
+			return nil, fmt.Errorf("%w: unsupported attribute %q", scim.ErrInvalidFilter, comparison.Attribute)
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// groupConditions 將 Group filter 轉換為群組查詢條件
+func (s *scimService) groupConditions(filter string) ([]user.Condition, error) {
+	comparisons, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]user.Condition, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		condition := user.Condition{Operator: comparison.Operator, Value: comparison.Value}
+
+		switch normalizeSCIMAttribute(comparison.Attribute, scim.SchemaGroup) {
+		case "id":
+			id, err := filterID(comparison.Value)
+			if err != nil {
+				return nil, err
+			}
+			condition.Field, condition.Value = "id", id
+		case "displayname":
+			condition.Field = "display_name"
+		case "externalid":
+			condition.Field = "external_id"
+		case "meta.created":
+			condition.Field = "created_at"
+		case "meta.lastmodified":
+			condition.Field = "updated_at"
+		default:
+			return nil, fmt.Errorf("%w: unsupported attribute %q", scim.ErrInvalidFilter, comparison.Attribute)
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// pagination 計算 startIndex 與 count，startIndex 小於 1 視為 1，count 不超過上限
+func (s *scimService) pagination(params *scim.ListParams) (int, int) {
+	startIndex := params.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	count := s.maxResults
+	if params.Count != nil {
+		count = min(max(*params.Count, 0), s.maxResults)
+	}
+	return startIndex, count
+}
+
+// toSCIMUser 將使用者實體轉換為 SCIM User，withGroups 決定是否查詢所屬群組
+func (s *scimService) toSCIMUser(ctx context.Context, u *user.User, withGroups bool) (*scim.User, error) {
+	id := strconv.FormatUint(uint64(u.ID), 10)
+	active := !u.IsDisabled
+	resource := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          id,
+		ExternalID:  u.ExternalID,
+		UserName:    u.Username,
+		DisplayName: u.Username,
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      &u.CreatedAt,
+			LastModified: &u.UpdatedAt,
+			Location:     scimBasePath + "/Users/" + id,
+		},
+	}
+	if u.Email != "" {
+		resource.Emails = []scim.Email{{Value: u.Email, Type: "work", Primary: true}}
+	}
+
+	if withGroups {
+		groups, err := s.groupRepo.FindByMemberID(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, singleGroup := range groups {
+			groupID := strconv.FormatUint(uint64(singleGroup.ID), 10)
+			resource.Groups = append(resource.Groups, scim.GroupRef{
+				Value:   groupID,
+				Ref:     scimBasePath + "/Groups/" + groupID,
+				Display: singleGroup.DisplayName,
+			})
+		}
+	}
+	return resource, nil
+}
+
+// toSCIMGroup 將群組實體轉換為 SCIM Group
+func toSCIMGroup(g *group.Group) *scim.Group {
+	id := strconv.FormatUint(uint64(g.ID), 10)
+	members := make([]scim.Member, 0, len(g.Members))
+	for _, member := range g.Members {
+		memberID := strconv.FormatUint(uint64(member.ID), 10)
+		members = append(members, scim.Member{
+			Value:   memberID,
+			Ref:     scimBasePath + "/Users/" + memberID,
+			Display: member.Username,
+		})
+	}
+
+	return &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          id,
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Members:     members,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      &g.CreatedAt,
+			LastModified: &g.UpdatedAt,
+			Location:     scimBasePath + "/Groups/" + id,
+		},
+	}
+}
+
+// newListResponse 創建新的列表查詢響應
+func newListResponse(total int64, startIndex int, resources []interface{}) *scim.ListResponse {
+	return &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		ItemsPerPage: len(resources),
+		StartIndex:   startIndex,
+		Resources:    resources,
+	}
+}
+
+// parseResourceID 解析 SCIM 資源 id，格式錯誤視為不存在
+func parseResourceID(id string) (uint, error) {
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || parsed == 0 {
+		return 0, scim.ErrNotFound
+	}
+	return uint(parsed), nil
+}
+
+// filterID 將 filter 中的 id 值轉為數字
+func filterID(value interface{}) (uint, error) {
+	switch v := value.(type) {
+	case string:
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid id %q", scim.ErrInvalidFilter, v)
+		}
+		return uint(id), nil
+	case float64:
+		// JSON 數字一律解為 float64，負數與小數不是合法的 ID
+		if v < 0 || v != math.Trunc(v) {
+			return 0, fmt.Errorf("%w: invalid id %v", scim.ErrInvalidFilter, v)
+		}
+		return uint(v), nil
+	default:
+		return 0, fmt.Errorf("%w: invalid id", scim.ErrInvalidFilter)
+	}
+}
+
+// primaryEmail 取出主要郵箱，若未標示 primary 則取第一個
+func primaryEmail(emails []scim.Email) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+// decodeBool 解析布林值，部分 IdP 會以字串 "True"/"False" 傳送
+func decodeBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			return false, fmt.Errorf("%w: %q is not a boolean", scim.ErrInvalidValue, v)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("%w: expected a boolean", scim.ErrInvalidValue)
+	}
+}
+
+// decodeEmail 解析 emails 的 PATCH 值，可為字串、單一物件或陣列
+func decodeEmail(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return v, nil
+		}
+	case map[string]interface{}:
+		if email, ok := v["value"].(string); ok && email != "" {
+			return email, nil
+		}
+	case []interface{}:
+		emails := make([]scim.Email, 0, len(v))
+		for _, item := range v {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			email, _ := entry["value"].(string)
+			primary, _ := entry["primary"].(bool)
+			emails = append(emails, scim.Email{Value: email, Primary: primary})
+		}
+		if email := primaryEmail(emails); email != "" {
+			return email, nil
+		}
+	}
+	return "", fmt.Errorf("%w: emails", scim.ErrInvalidValue)
+}
+
+// decodeMembers 解析 members 的 PATCH 值
+func decodeMembers(value interface{}) ([]scim.Member, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		items = []interface{}{value}
+	}
+
+	members := make([]scim.Member, 0, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: members", scim.ErrInvalidValue)
+		}
+		memberID, ok := entry["value"].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: members", scim.ErrInvalidValue)
+		}
+		members = append(members, scim.Member{Value: memberID})
+	}
+	return members, nil
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/group"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/scim"
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+// newTestSCIMService creates a SCIM service with three users and the group "Ops" holding users 1 and 2.
+// The returned miniredis backs the revocation store.
+func newTestSCIMService(t *testing.T) (scim.SCIMService, *mockGroupRepository, *miniredis.Miniredis) {
+	userRepo := newMockUserRepository(
+		&user.User{Username: "alice", Email: "alice@example.com"},
+		&user.User{Username: "bob", Email: "bob@example.com"},
+		&user.User{Username: "carol", Email: "carol@example.com"},
+	)
+	groupRepo := newMockGroupRepository(userRepo)
+	require.NoError(t, groupRepo.Create(context.Background(), &group.Group{DisplayName: "Ops"}, []uint{1, 2}))
+	require.NoError(t, groupRepo.Create(context.Background(), &group.Group{DisplayName: "Sales"}, nil))
+	groupRepo.writes = 0
+
+	mr := miniredis.RunT(t)
+	return NewSCIMService(userRepo, groupRepo, newTestAuthService(mr, userRepo), &configlib.SCIMConfig{}), groupRepo, mr
+}
+
+// members builds a PATCH members value.
+func members(ids ...string) []interface{} {
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, map[string]interface{}{"value": id})
+	}
+	return values
+}
+
+func TestPatchGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		operations  []scim.PatchOperation
+		expected    []uint
+		displayName string
+	}{
+		{
+			name:       "Add members",
+			operations: []scim.PatchOperation{{Op: "add", Path: "members", Value: members("2", "3")}},
+			expected:   []uint{1, 2, 3},
+		},
+		{
+			name:       "Remove member by value filter",
+			operations: []scim.PatchOperation{{Op: "remove", Path: `members[value eq "1"]`}},
+			expected:   []uint{2},
+		},
+		{
+			name:       "Remove members by value",
+			operations: []scim.PatchOperation{{Op: "remove", Path: "members", Value: members("2")}},
+			expected:   []uint{1},
+		},
+		{
+			name:       "Remove all members",
+			operations: []scim.PatchOperation{{Op: "remove", Path: "members"}},
+			expected:   []uint{},
+		},
+		{
+			name:       "Replace members",
+			operations: []scim.PatchOperation{{Op: "Replace", Path: "members", Value: members("3")}},
+			expected:   []uint{3},
+		},
+		{
+			name: "Operations apply in order",
+			operations: []scim.PatchOperation{
+				{Op: "replace", Path: "members", Value: members("3")},
+				{Op: "add", Path: "members", Value: members("1")},
+				{Op: "remove", Path: `members[value eq "3"]`},
+			},
+			expected: []uint{1},
+		},
+		{
+			name:        "Attributes without path",
+			operations:  []scim.PatchOperation{{Op: "replace", Value: map[string]interface{}{"displayName": "Platform"}}},
+			expected:    []uint{1, 2},
+			displayName: "Platform",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, groupRepo, _ := newTestSCIMService(t)
+
+			patched, err := service.PatchGroup(context.Background(), "1", &scim.PatchRequest{Operations: tt.operations})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.expected, groupRepo.memberIDs(1))
+			assert.Len(t, patched.Members, len(tt.expected))
+			assert.Equal(t, 1, groupRepo.writes)
+			if tt.displayName != "" {
+				assert.Equal(t, tt.displayName, patched.DisplayName)
+			}
+		})
+	}
+}
+
+func TestPatchGroupIsAtomic(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []scim.PatchOperation
+		err        error
+	}{
+		{
+			name: "Later operation has an invalid path",
+			operations: []scim.PatchOperation{
+				{Op: "add", Path: "members", Value: members("3")},
+				{Op: "replace", Path: "owner", Value: "alice"},
+			},
+			err: scim.ErrInvalidPath,
+		},
+		{
+			name: "Later operation references a missing user",
+			operations: []scim.PatchOperation{
+				{Op: "remove", Path: `members[value eq "1"]`},
+				{Op: "add", Path: "members", Value: members("42")},
+			},
+			err: scim.ErrInvalidValue,
+		},
+		{
+			name: "Display name conflicts after member changes",
+			operations: []scim.PatchOperation{
+				{Op: "replace", Path: "members", Value: members("3")},
+				{Op: "replace", Path: "displayName", Value: "sales"},
+			},
+			err: scim.ErrUniqueness,
+		},
+		{
+			name:       "Unsupported op",
+			operations: []scim.PatchOperation{{Op: "add", Path: "members", Value: members("3")}, {Op: "move", Path: "members"}},
+			err:        scim.ErrInvalidValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, groupRepo, _ := newTestSCIMService(t)
+
+			_, err := service.PatchGroup(context.Background(), "1", &scim.PatchRequest{Operations: tt.operations})
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+			assert.Zero(t, groupRepo.writes)
+			assert.ElementsMatch(t, []uint{1, 2}, groupRepo.memberIDs(1))
+
+			ops, err := service.GetGroup(context.Background(), "1")
+			require.NoError(t, err)
+			assert.Equal(t, "Ops", ops.DisplayName)
+		})
+	}
+}
+
+func TestPatchGroupKeepsSoftDeletedMembers(t *testing.T) {
+	ctx := context.Background()
+	service, groupRepo, _ := newTestSCIMService(t)
+	require.NoError(t, groupRepo.userRepo.Update(ctx, &user.User{ID: 2, Username: "bob", Email: "bob@example.com", IsDeleted: true}))
+
+	patched, err := service.PatchGroup(ctx, "1", &scim.PatchRequest{
+		Operations: []scim.PatchOperation{{Op: "add", Path: "members", Value: members("3")}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, patched.Members, 2)
+	assert.ElementsMatch(t, []uint{1, 2, 3}, groupRepo.memberIDs(1))
+}
+
+func TestReplaceGroup(t *testing.T) {
+	service, groupRepo, _ := newTestSCIMService(t)
+
+	replaced, err := service.ReplaceGroup(context.Background(), "1", &scim.Group{
+		DisplayName: "Platform",
+		Members:     []scim.Member{{Value: "3"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Platform", replaced.DisplayName)
+	assert.Equal(t, []uint{3}, groupRepo.memberIDs(1))
+	assert.Equal(t, 1, groupRepo.writes)
+
+	_, err = service.ReplaceGroup(context.Background(), "1", &scim.Group{DisplayName: "Sales"})
+	assert.True(t, errors.Is(err, scim.ErrUniqueness))
+}
+
+func TestDeprovisionRevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	inactive, active := false, true
+	aliceEmails := []scim.Email{{Value: "alice@example.com", Primary: true}}
+
+	tests := []struct {
+		name    string
+		apply   func(service scim.SCIMService) error
+		revoked bool
+	}{
+		{
+			name: "Patch active to false",
+			apply: func(service scim.SCIMService) error {
+				_, err := service.PatchUser(ctx, "1", &scim.PatchRequest{
+					Operations: []scim.PatchOperation{{Op: "replace", Path: "active", Value: false}},
+				})
+				return err
+			},
+			revoked: true,
+		},
+		{
+			name: "Replace with active false",
+			apply: func(service scim.SCIMService) error {
+				_, err := service.ReplaceUser(ctx, "1", &scim.User{UserName: "alice", Emails: aliceEmails, Active: &inactive})
+				return err
+			},
+			revoked: true,
+		},
+		{
+			name: "Delete user",
+			apply: func(service scim.SCIMService) error {
+				return service.DeleteUser(ctx, "1")
+			},
+			revoked: true,
+		},
+		{
+			name: "Patch that keeps the user active",
+			apply: func(service scim.SCIMService) error {
+				_, err := service.PatchUser(ctx, "1", &scim.PatchRequest{
+					Operations: []scim.PatchOperation{{Op: "replace", Path: "displayName", Value: "Alice"}},
+				})
+				return err
+			},
+		},
+		{
+			name: "Replace with active true",
+			apply: func(service scim.SCIMService) error {
+				_, err := service.ReplaceUser(ctx, "1", &scim.User{UserName: "alice", Emails: aliceEmails, Active: &active})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, mr := newTestSCIMService(t)
+			require.NoError(t, tt.apply(service))
+			assert.Equal(t, tt.revoked, mr.Exists("auth:revoked:user:1"))
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package service
 
 import (
+	"context"
+	"errors"
+
 	"github.com/POABOB/slack-clone-back-end/pkg/auth"
 	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+	"gorm.io/gorm"
 )
 
 type userService struct {
@@ -17,24 +21,29 @@ func NewUserService(repo user.UserRepository) user.UserService {
 }
 
 // GetUserByID 獲取使用者訊息
-func (s *userService) GetUserByID(id uint) (*user.User, error) {
-	return s.repo.FindByID(id)
+func (s *userService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
+	found, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, user.ErrUserNotFound
+	}
+	return found, err
 }
 
-// UpdateUser 更新使用者訊息
-func (s *userService) UpdateUser(user *user.User) error {
+// UpdateUser 更新使用者可自行修改的欄位
+func (s *userService) UpdateUser(ctx context.Context, id uint, req *user.UpdateRequest) error {
+	changes := *req
 	// 如果密碼被更新，需要重新加密
-	if user.Password != "" {
-		hashedPassword, err := auth.HashPassword(user.Password)
+	if changes.Password != nil {
+		hashedPassword, err := auth.HashPassword(*changes.Password)
 		if err != nil {
 			return err
 		}
-		user.Password = hashedPassword
+		changes.Password = &hashedPassword
 	}
-	return s.repo.Update(user)
+	return s.repo.UpdateProfile(ctx, id, &changes)
 }
 
 // DeleteUser 刪除使用者
-func (s *userService) DeleteUser(id uint) error {
-	return s.repo.Delete(id)
+func (s *userService) DeleteUser(ctx context.Context, id uint) error {
+	return s.repo.Delete(ctx, id)
 }
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authlib "github.com/POABOB/slack-clone-back-end/pkg/auth"
+
+	"github.com/POABOB/slack-clone-back-end/services/user-service/internal/domain/user"
+)
+
+func TestUpdateUser(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("Only editable fields change", func(t *testing.T) {
+		userRepo := newMockUserRepository(&user.User{
+			Username:      "guest",
+			Email:         "guest@example.com",
+			Role:          "guest",
+			AccountType:   user.AccountTypeSingleChannelGuest,
+			GuestChannels: []string{"general"},
+			ExpiresAt:     &expiresAt,
+			ExternalID:    "idp-1",
+			IsDisabled:    true,
+		})
+		service := NewUserService(userRepo)
+
+		username, password := "renamed", "New-password-1"
+		require.NoError(t, service.UpdateUser(ctx, 1, &user.UpdateRequest{Username: &username, Password: &password}))
+
+		updated, err := userRepo.FindByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", updated.Username)
+		assert.NoError(t, authlib.CheckPassword(password, updated.Password))
+		assert.Equal(t, user.AccountTypeSingleChannelGuest, updated.AccountType)
+		assert.Equal(t, []string{"general"}, updated.GuestChannels)
+		assert.Equal(t, &expiresAt, updated.ExpiresAt)
+		assert.Equal(t, "idp-1", updated.ExternalID)
+		assert.True(t, updated.IsDisabled)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		service := NewUserService(newMockUserRepository())
+		username := "renamed"
+		err := service.UpdateUser(ctx, 99, &user.UpdateRequest{Username: &username})
+		assert.True(t, errors.Is(err, user.ErrUserNotFound))
+	})
+}
@@ -1,16 +1,145 @@
 package pkg
 
 import (
+	"context"
+	"time"
+
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt"
 	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/rbac"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/jwt/scoped"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/revocation"
+	"github.com/POABOB/slack-clone-back-end/pkg/auth/servicetoken"
+	configlib "github.com/POABOB/slack-clone-back-end/pkg/config"
 	"github.com/POABOB/slack-clone-back-end/pkg/database/postgresql"
+	"github.com/POABOB/slack-clone-back-end/pkg/featureflag"
+	"github.com/POABOB/slack-clone-back-end/pkg/health"
+	"github.com/POABOB/slack-clone-back-end/pkg/logger"
+	"github.com/POABOB/slack-clone-back-end/pkg/metrics"
+	"github.com/POABOB/slack-clone-back-end/pkg/observability"
+	redislib "github.com/POABOB/slack-clone-back-end/pkg/redis"
+	"github.com/POABOB/slack-clone-back-end/pkg/resilience"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/fx"
+	"gorm.io/gorm"
 )
 
-// PostgresqlModule 依賴注入統一管理
+// PostgresqlModule 依賴注入統一管理，並註冊就緒檢查
 var PostgresqlModule = fx.Module("postgresql",
 	fx.Provide(postgresql.NewDatabase),
+	fx.Invoke(func(registry *health.Registry, db *gorm.DB) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return registry.Register("postgresql", health.PingChecker(sqlDB))
+	}),
+)
+
+// RedisModule Redis 客戶端，註冊就緒檢查並在應用停止時關閉連接
+var RedisModule = fx.Module("redis",
+	fx.Provide(redislib.NewClient),
+	fx.Invoke(func(lc fx.Lifecycle, registry *health.Registry, client *redis.Client) error {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return client.Close()
+			},
+		})
+		return registry.Register("redis", health.RedisChecker(client))
+	}),
 )
 
+// FeatureFlagModule 功能旗標，配置旗標檔案時由檔案載入，否則以 PostgreSQL 儲存並由 Redis 快取
+var FeatureFlagModule = fx.Module("featureflag",
+	fx.Provide(
+		func(cfg *configlib.FeatureFlagConfig, db *gorm.DB, client *redis.Client) (featureflag.Store, error) {
+			if cfg.File != "" {
+				return featureflag.NewFileStore(cfg.File)
+			}
+			ttl := time.Duration(cfg.CacheTTL) * time.Second
+			return featureflag.NewCachedStore(featureflag.NewPostgresStore(db), client, ttl), nil
+		},
+		featureflag.NewEvaluator,
+		featureflag.NewAdminHandler,
+	),
+)
+
+// TracingModule OpenTelemetry 追蹤，為 GORM 與 Redis 安裝建立子 span 的回呼，應用停止時送出尚未匯出的 span
+var TracingModule = fx.Module("tracing",
+	fx.Invoke(func(lc fx.Lifecycle, cfg *observability.TracingConfig, service *configlib.ServiceAuthConfig, db *gorm.DB, client *redis.Client) error {
+		provider, err := observability.NewTracerProvider(context.Background(), *cfg, service.Name)
+		if err != nil {
+			return err
+		}
+		if err := db.Use(observability.NewGormPlugin()); err != nil {
+			return err
+		}
+		client.AddHook(observability.NewRedisHook())
+
+		if provider != nil {
+			lc.Append(fx.Hook{
+				OnStop: func(ctx context.Context) error {
+					return provider.Shutdown(ctx)
+				},
+			})
+		}
+		return nil
+	}),
+)
+
+// MetricsModule 匯出 PostgreSQL 與 Redis 連線池指標，HTTP 指標由 config.NewGinEngine 安裝
+var MetricsModule = fx.Module("metrics",
+	fx.Invoke(func(cfg *configlib.DatabaseConfig, db *gorm.DB, client *redis.Client) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		if err := metrics.RegisterDBStats(sqlDB, cfg.DBName); err != nil {
+			return err
+		}
+		return metrics.RegisterRedisStats(client, "default")
+	}),
+)
+
+// ResilienceModule 以熔斷器保護 PostgreSQL 與 Redis，依賴異常時快速回傳 503 而不是等待逾時
+var ResilienceModule = fx.Module("resilience",
+	fx.Invoke(func(cfg *resilience.Config, db *gorm.DB, client *redis.Client) error {
+		if err := db.Use(resilience.NewGormPlugin(resilience.NewBreaker("postgresql", cfg.Breaker, resilience.IsDBFailure))); err != nil {
+			return err
+		}
+		client.AddHook(resilience.NewRedisHook(resilience.NewBreaker("redis", cfg.Breaker, resilience.IsRedisFailure)))
+		return nil
+	}),
+)
+
+// AuthModule JWT 與 service token 管理器，金鑰於配置重新載入時輪替
 var AuthModule = fx.Module("auth",
-	fx.Provide(rbac.NewRBACJWTManager, rbac.RBACMiddleware),
+	fx.Provide(
+		fx.Annotate(rbac.NewRBACJWTManager, fx.As(fx.Self()), fx.As(new(jwt.TokenManager))),
+		scoped.NewScopedJWTManager,
+		servicetoken.NewManager,
+		fx.Annotate(revocation.NewRedisStore, fx.As(new(revocation.Store))),
+		func(jwtManager *rbac.RBACJWTManager, store revocation.Store) gin.HandlerFunc {
+			return rbac.RBACMiddleware(jwtManager, revocation.Validator(store))
+		},
+	),
+	fx.Invoke(RotateKeys),
 )
+
+// RotateKeys 訂閱 JWT 與服務間金鑰的變更，輪替後的機密資訊不需重啟即可生效
+func RotateKeys(watcher *configlib.Watcher, rbacManager *rbac.RBACJWTManager, scopedManager *scoped.ScopedJWTManager, serviceManager *servicetoken.Manager) {
+	configlib.Subscribe(watcher, func(c *configlib.Config) string { return c.JWT.SecretKey },
+		func(change configlib.Change[string]) {
+			rbacManager.SetSecretKey(change.New)
+			scopedManager.SetSecretKey(change.New)
+			logger.Info("jwt secret key rotated")
+		})
+	configlib.Subscribe(watcher, func(c *configlib.Config) configlib.ServiceAuthConfig { return c.Service },
+		func(change configlib.Change[configlib.ServiceAuthConfig]) {
+			if err := serviceManager.SetKeys(&change.New); err != nil {
+				logger.Error("service keys rotation failed, keeping current keys", logger.Err(err))
+				return
+			}
+			logger.Info("service keys rotated")
+		})
+}